@@ -86,8 +86,9 @@ class ApiClient {
       const errorData = await response.json().catch(() => ({ message: '网络错误' }))
       
       // 返回错误响应而不是抛出异常，让调用方处理
+      // 账号停用/封禁/待审核时后端返回业务错误码(4030x)，message 中包含封禁原因
       return {
-        code: response.status,
+        code: errorData.code || response.status,
         message: errorData.message || `HTTP ${response.status}`,
         data: null as T,
         success: false
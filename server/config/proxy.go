@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+/**
+ * TrustedProxies 获取受信任的反向代理，只有来自这些地址的请求才按 X-Forwarded-For、X-Real-IP 识别客户端 IP
+ * 通过 TRUSTED_PROXIES 配置，逗号分隔的 IP 或 CIDR（如 127.0.0.1,10.0.0.0/8）；未配置时不信任任何代理，
+ * 客户端 IP 取连接的来源地址，避免客户端伪造请求头绕过按 IP 的登录锁定
+ */
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
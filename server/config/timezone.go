@@ -0,0 +1,38 @@
+package config
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+	// 内置时区数据，运行环境缺少 zoneinfo 时也能加载时区
+	_ "time/tzdata"
+)
+
+// 默认按北京时间划分自然日
+const defaultTimezone = "Asia/Shanghai"
+
+var (
+	location     *time.Location
+	locationOnce sync.Once
+)
+
+/**
+ * Location 获取业务使用的时区，用于划分每日统计、每日答题限额等按自然日计算的数据
+ * 通过 APP_TIMEZONE 配置（如 Asia/Shanghai），无效时使用服务器本地时区
+ */
+func Location() *time.Location {
+	locationOnce.Do(func() {
+		name := os.Getenv("APP_TIMEZONE")
+		if name == "" {
+			name = defaultTimezone
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("时区 %s 无效，使用服务器本地时区: %v", name, err)
+			loc = time.Local
+		}
+		location = loc
+	})
+	return location
+}
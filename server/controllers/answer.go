@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"qaminiprogram/config"
 	"qaminiprogram/models"
@@ -9,13 +11,20 @@ import (
 	"github.com/gin-gonic/gin"
 	
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errDailyLimitReached 事务中重新统计时今日练习作答已达上限
+var errDailyLimitReached = errors.New("今日答题数量已达上限")
+
 // SubmitAnswerRequest 提交答案请求
 type SubmitAnswerRequest struct {
-	QuestionID uint `json:"questionId" binding:"required"`
-	UserAnswer int       `json:"userAnswer" binding:"min=0"`
-	TimeSpent  int       `json:"timeSpent" binding:"min=0"`
+	QuestionID uint        `json:"questionId" binding:"required"`
+	UserAnswer AnswerInput `json:"userAnswer"`
+	TimeSpent  int         `json:"timeSpent" binding:"min=0"`
+	Mode       string      `json:"mode"`
+	// 取题时返回的答题凭证，携带时按服务端发题时间计算用时
+	AnswerTicket string    `json:"answerTicket"`
 }
 
 // AnswerStatistics 答题统计
@@ -28,6 +37,13 @@ type AnswerStatistics struct {
 	TodayAnswered   int64   `json:"todayAnswered"`
 	WeekAnswered    int64   `json:"weekAnswered"`
 	MonthAnswered   int64   `json:"monthAnswered"`
+
+	// 按题目统计：首次作答和最近一次作答的正确率
+	QuestionsAnswered int64   `json:"questionsAnswered"`
+	FirstTryCorrect   int64   `json:"firstTryCorrect"`
+	FirstTryAccuracy  float64 `json:"firstTryAccuracy"`
+	LatestCorrect     int64   `json:"latestCorrect"`
+	LatestAccuracy    float64 `json:"latestAccuracy"`
 }
 
 // CategoryStatistics 分类统计
@@ -37,8 +53,59 @@ type CategoryStatistics struct {
 	TotalAnswered   int64     `json:"totalAnswered"`
 	CorrectAnswered int64     `json:"correctAnswered"`
 	AccuracyRate    float64   `json:"accuracyRate"`
+	QuestionsAnswered int64   `json:"questionsAnswered"`
+	FirstTryAccuracy  float64 `json:"firstTryAccuracy"`
+	LatestAccuracy    float64 `json:"latestAccuracy"`
+	TotalQuestions    int64   `json:"totalQuestions"`
+	CompletionRate    float64 `json:"completionRate"` // 作答过的题目占分类题目总数的百分比
+}
+
+// answerAttempt 一次作答
+type answerAttempt struct {
+	UserID     uint
+	QuestionID uint
+	CategoryID uint
+	UserAnswer int
+	AnswerText string
+	IsCorrect  bool
+	TimeSpent  int
+	Mode       string
+	SessionID  *uint
+
+	// 练习作答按答题设置计算积分，考试作答为空
+	PointSettings *QuizSettingsRequest
+	// 每日练习作答上限，大于0时在事务中锁定用户后重新统计，同一用户的并发提交不会超出限额
+	DailyLimit int
 }
 
+// answerAttemptResult 作答保存结果
+type answerAttemptResult struct {
+	AttemptNo int
+	Points    int
+}
+
+// categoryStatisticsQuery 按分类汇总用户的最新作答状态，次数类统计来自 attempt_count/correct_count
+// total_questions 统计分类下的全部题目，调用方不能在 WHERE 中按作答状态过滤，否则未作答的题目不会计入
+const categoryStatisticsQuery = `
+		SELECT 
+			c.id as category_id,
+			c.name as category_name,
+			COALESCE(SUM(s.attempt_count), 0) as total_answered,
+			COALESCE(SUM(s.correct_count), 0) as correct_answered,
+			CASE 
+				WHEN SUM(s.attempt_count) > 0 THEN SUM(s.correct_count) * 100.0 / SUM(s.attempt_count)
+				ELSE 0 
+			END as accuracy_rate,
+			COUNT(s.id) as questions_answered,
+			CASE WHEN COUNT(s.id) > 0 THEN SUM(s.first_correct) * 100.0 / COUNT(s.id) ELSE 0 END as first_try_accuracy,
+			CASE WHEN COUNT(s.id) > 0 THEN SUM(s.last_correct) * 100.0 / COUNT(s.id) ELSE 0 END as latest_accuracy,
+			COUNT(q.id) as total_questions,
+			CASE WHEN COUNT(q.id) > 0 THEN COUNT(s.id) * 100.0 / COUNT(q.id) ELSE 0 END as completion_rate
+		FROM categories c
+		LEFT JOIN questions q ON c.id = q.category_id
+		LEFT JOIN answer_states s ON q.id = s.question_id AND s.user_id = ?
+`
+
 // SubmitAnswer 提交答案
 func SubmitAnswer(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -62,74 +129,234 @@ func SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	// 验证答案索引是否有效
-	if req.UserAnswer >= len(question.Options) {
-		ErrorResponse(c, http.StatusBadRequest, "答案索引无效")
+	if req.UserAnswer.IsEmpty() {
+		ErrorResponse(c, http.StatusBadRequest, "请提交答案")
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.AnswerModePractice
+	}
+	if !models.IsValidAnswerMode(req.Mode) {
+		ErrorResponse(c, http.StatusBadRequest, "答题模式无效")
 		return
 	}
 
-	// 判断答案是否正确
-	isCorrect := req.UserAnswer == question.CorrectAnswer
+	quizSettings := getQuizSettings(db)
 
-	// 检查是否已经答过这道题
-	var existingRecord models.AnswerRecord
-	result := db.Where("user_id = ? AND question_id = ?", userID, req.QuestionID).First(&existingRecord)
+	// 每日答题限额
+	if remaining := remainingDailyAnswers(db, userID, quizSettings); remaining == 0 {
+		ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("今日答题数量已达上限（%d题）", quizSettings.DailyLimit))
+		return
+	}
 
-	if result.Error == nil {
-		// 更新已有记录
-		existingRecord.UserAnswer = req.UserAnswer
-		existingRecord.IsCorrect = isCorrect
-		existingRecord.TimeSpent = req.TimeSpent
-		if err := db.Save(&existingRecord).Error; err != nil {
-			ErrorResponse(c, http.StatusInternalServerError, "更新答题记录失败")
+	// 用时以答题凭证记录的发题时间为准，设置了每题时间限制时必须携带凭证
+	// 未携带凭证时客户端上报的用时只用于记录，不参与超时判定
+	if req.AnswerTicket == "" && quizSettings.TimeLimit > 0 {
+		ErrorResponse(c, http.StatusBadRequest, "缺少答题凭证，请重新获取题目")
+		return
+	}
+	elapsed := time.Duration(req.TimeSpent) * time.Second
+	timedOut := false
+	if req.AnswerTicket != "" {
+		issuedAt, err := parseAnswerTicket(req.AnswerTicket, userID, req.QuestionID)
+		if err != nil {
+			ErrorResponse(c, http.StatusBadRequest, err.Error())
 			return
 		}
-		
-		// 如果答错了，添加到错题本
-		if !isCorrect {
-			addToMistakeBook(db, userID, req.QuestionID)
-		} else {
-			// 如果答对了，从错题本中移除
-			removeFromMistakeBook(db, userID, req.QuestionID)
-		}
-		
-		SuccessResponse(c, gin.H{
-			"isCorrect": isCorrect,
-			"correctAnswer": question.CorrectAnswer,
-			"explanation": question.Explanation,
-			"updated": true,
-		})
+		elapsed = time.Since(issuedAt)
+		timedOut = isAnswerTimedOut(quizSettings, elapsed)
+	}
+
+	// 按题型判分
+	grade, err := gradeAnswer(&question, req.UserAnswer, quizSettings.PartialCredit)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 创建新的答题记录
-	answerRecord := models.AnswerRecord{
-		UserID:     userID,
-		QuestionID: req.QuestionID,
-		UserAnswer: req.UserAnswer,
-		IsCorrect:  isCorrect,
-		TimeSpent:  req.TimeSpent,
+	// 超过每题时间限制按答错处理
+	if timedOut {
+		grade.IsCorrect = false
+		grade.Credit = 0
 	}
 
-	if err := db.Create(&answerRecord).Error; err != nil {
+	userAnswer, answerText := req.UserAnswer.storage()
+	result, err := recordAnswerAttempt(db, answerAttempt{
+		UserID:        userID,
+		QuestionID:    req.QuestionID,
+		CategoryID:    question.CategoryID,
+		UserAnswer:    userAnswer,
+		AnswerText:    answerText,
+		IsCorrect:     grade.IsCorrect,
+		TimeSpent:     int(elapsed / time.Second),
+		Mode:          req.Mode,
+		PointSettings: &quizSettings,
+		DailyLimit:    quizSettings.DailyLimit,
+	})
+	if errors.Is(err, errDailyLimitReached) {
+		ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("今日答题数量已达上限（%d题）", quizSettings.DailyLimit))
+		return
+	}
+	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "保存答题记录失败")
 		return
 	}
 
-	// 如果答错了，添加到错题本
-	if !isCorrect {
-		addToMistakeBook(db, userID, req.QuestionID)
+	response := gin.H{
+		"isCorrect": grade.IsCorrect,
+		"credit": grade.Credit,
+		"timedOut": timedOut,
+		"attemptNo": result.AttemptNo,
+		"updated": result.AttemptNo > 1,
+	}
+	if quizSettings.EnablePoints {
+		response["points"] = result.Points
+	}
+	if question.Type == "fill" {
+		response["blanks"] = grade.Blanks
 	}
 
-	SuccessResponse(c, gin.H{
-		"isCorrect": isCorrect,
-		"correctAnswer": question.CorrectAnswer,
-		"explanation": question.Explanation,
-		"updated": false,
+	// 按设置返回答案和解析
+	if showExplanation, _ := normalizeShowExplanation(quizSettings.ShowExplanation); showExplanation != ShowExplanationNever {
+		response["correctAnswer"] = question.CorrectAnswer
+		response["explanation"] = question.Explanation
+		if question.Type == "fill" {
+			response["acceptedAnswers"] = fillAcceptedAnswers(&question)
+		}
+	}
+
+	SuccessResponse(c, response)
+}
+
+// recordAnswerAttempt 追加一条作答记录并更新该题的最新作答状态、答题计数和积分，同时同步错题本
+func recordAnswerAttempt(db *gorm.DB, attempt answerAttempt) (answerAttemptResult, error) {
+	if attempt.Mode == "" {
+		attempt.Mode = models.AnswerModePractice
+	}
+
+	var result answerAttemptResult
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 先锁定用户行再统计，与计数更新的加锁顺序一致；统计是事务中的第一次读取，能看到先提交的作答
+		if attempt.DailyLimit > 0 {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+				Where("id = ?", attempt.UserID).First(&models.User{}).Error; err != nil {
+				return err
+			}
+			if countTodayAnswers(tx, attempt.UserID) >= int64(attempt.DailyLimit) {
+				return errDailyLimitReached
+			}
+		}
+
+		now := time.Now()
+		correct := 0
+		if attempt.IsCorrect {
+			correct = 1
+		}
+
+		// 更新最新作答状态，唯一索引保证并发提交时作答次序不重复
+		if err := tx.Exec(`
+			INSERT INTO answer_states (user_id, question_id, attempt_count, correct_count, first_correct, first_answered_at,
+				last_answer, last_answer_text, last_correct, last_answered_at, created_at, updated_at)
+			VALUES (?, ?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				attempt_count = attempt_count + 1,
+				correct_count = correct_count + VALUES(correct_count),
+				last_answer = VALUES(last_answer),
+				last_answer_text = VALUES(last_answer_text),
+				last_correct = VALUES(last_correct),
+				last_answered_at = VALUES(last_answered_at),
+				updated_at = VALUES(updated_at)
+		`, attempt.UserID, attempt.QuestionID, correct, attempt.IsCorrect, now,
+			attempt.UserAnswer, attempt.AnswerText, attempt.IsCorrect, now, now, now).Error; err != nil {
+			return err
+		}
+
+		var state models.AnswerState
+		if err := tx.Where("user_id = ? AND question_id = ?", attempt.UserID, attempt.QuestionID).First(&state).Error; err != nil {
+			return err
+		}
+		result.AttemptNo = state.AttemptCount
+
+		// 追加作答记录
+		record := models.AnswerRecord{
+			UserID:     attempt.UserID,
+			QuestionID: attempt.QuestionID,
+			UserAnswer: attempt.UserAnswer,
+			AnswerText: attempt.AnswerText,
+			IsCorrect:  attempt.IsCorrect,
+			TimeSpent:  attempt.TimeSpent,
+			AttemptNo:  result.AttemptNo,
+			Mode:       attempt.Mode,
+			SessionID:  attempt.SessionID,
+			AnsweredAt: now,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		if err := incrementAnswerCounters(tx, attempt.UserID, attempt.QuestionID, attempt.IsCorrect, now); err != nil {
+			return err
+		}
+		if err := recordDailyAnswer(tx, attempt.UserID, attempt.IsCorrect, now); err != nil {
+			return err
+		}
+
+		// 答错加入错题本，答对到期的错题计为一次复习
+		if err := updateMistakeSchedule(tx, attempt.UserID, attempt.QuestionID, attempt.IsCorrect, now); err != nil {
+			return err
+		}
+
+		// 顺序练习记录进度
+		if attempt.Mode == models.AnswerModeSequential {
+			if err := advancePracticeCursor(tx, attempt.UserID, attempt.CategoryID, attempt.QuestionID); err != nil {
+				return err
+			}
+		}
+
+		// 积分
+		if attempt.PointSettings != nil {
+			points, reason := answerPoints(*attempt.PointSettings, attempt.IsCorrect, attempt.IsCorrect && state.CorrectCount == 1)
+			if err := addPoints(tx, pointChange{
+				UserID:     attempt.UserID,
+				Points:     points,
+				Reason:     reason,
+				RefType:    models.PointRefQuestion,
+				RefID:      &attempt.QuestionID,
+				CategoryID: &attempt.CategoryID,
+			}); err != nil {
+				return err
+			}
+			result.Points = points
+		}
+		return nil
 	})
+	return result, err
+}
+
+// rebuildAnswerStates 删除fromIDs的最新作答状态，并按answer_records重建toID的最新作答状态
+// 要求toID的作答次序已经从1开始连续编号
+func rebuildAnswerStates(tx *gorm.DB, fromID, toID uint) error {
+	if err := tx.Where("user_id IN ?", []uint{fromID, toID}).Delete(&models.AnswerState{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		INSERT INTO answer_states (user_id, question_id, attempt_count, correct_count, first_correct, first_answered_at,
+			last_answer, last_answer_text, last_correct, last_answered_at, created_at, updated_at)
+		SELECT f.user_id, f.question_id, agg.attempt_count, agg.correct_count, f.is_correct, f.answered_at,
+			l.user_answer, l.answer_text, l.is_correct, l.answered_at, NOW(), NOW()
+		FROM (
+			SELECT question_id, COUNT(*) AS attempt_count, SUM(is_correct) AS correct_count, MAX(attempt_no) AS last_no
+			FROM answer_records WHERE user_id = ?
+			GROUP BY question_id
+		) agg
+		JOIN answer_records f ON f.user_id = ? AND f.question_id = agg.question_id AND f.attempt_no = 1
+		JOIN answer_records l ON l.user_id = ? AND l.question_id = agg.question_id AND l.attempt_no = agg.last_no
+	`, toID, toID, toID).Error
 }
 
 // GetAnswerHistory 获取答题历史
+// 默认按作答逐条返回；view=latest 时按题目返回最新作答状态，包含首次作答和最近一次作答是否正确
 func GetAnswerHistory(c *gin.Context) {
 	userID, exists := GetUserID(c)
 	if !exists {
@@ -143,13 +370,54 @@ func GetAnswerHistory(c *gin.Context) {
 	// 获取查询参数
 	categoryID := c.Query("category_id")
 	isCorrect := c.Query("is_correct")
+	questionID := c.Query("question_id")
+	mode := c.Query("mode")
+	firstTry := c.Query("first_try")
 
 	db := config.GetDB()
-	query := db.Model(&models.AnswerRecord{}).Where("user_id = ?", userID)
+
+	if c.Query("view") == "latest" {
+		query := db.Model(&models.AnswerState{}).Where("answer_states.user_id = ?", userID)
+		if isCorrect != "" {
+			query = query.Where("answer_states.last_correct = ?", isCorrect == "true")
+		}
+		if firstTry != "" {
+			query = query.Where("answer_states.first_correct = ?", firstTry == "true")
+		}
+		if questionID != "" {
+			query = query.Where("answer_states.question_id = ?", questionID)
+		}
+		if categoryID != "" {
+			query = query.Joins("JOIN questions ON answer_states.question_id = questions.id").Where("questions.category_id = ?", categoryID)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var states []models.AnswerState
+		if err := query.Preload("Question").Preload("Question.Category").Order("answer_states.last_answered_at DESC").Offset(offset).Limit(size).Find(&states).Error; err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "获取答题历史失败")
+			return
+		}
+
+		PageSuccessResponse(c, states, total, page, size)
+		return
+	}
+
+	query := db.Model(&models.AnswerRecord{}).Where("answer_records.user_id = ?", userID)
 
 	// 添加查询条件
 	if isCorrect != "" {
-		query = query.Where("is_correct = ?", isCorrect == "true")
+		query = query.Where("answer_records.is_correct = ?", isCorrect == "true")
+	}
+	if questionID != "" {
+		query = query.Where("answer_records.question_id = ?", questionID)
+	}
+	if mode != "" {
+		query = query.Where("answer_records.mode = ?", mode)
+	}
+	if firstTry == "true" {
+		query = query.Where("answer_records.attempt_no = 1")
 	}
 
 	// 如果指定了分类，需要关联题目表
@@ -161,7 +429,7 @@ func GetAnswerHistory(c *gin.Context) {
 	query.Count(&total)
 
 	var records []models.AnswerRecord
-	if err := query.Preload("Question").Preload("Question.Category").Order("created_at DESC").Offset(offset).Limit(size).Find(&records).Error; err != nil {
+	if err := query.Preload("Question").Preload("Question.Category").Order("answer_records.answered_at DESC, answer_records.id DESC").Offset(offset).Limit(size).Find(&records).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "获取答题历史失败")
 		return
 	}
@@ -211,24 +479,29 @@ func GetAnswerStatistics(c *gin.Context) {
 	monthStart := time.Now().AddDate(0, 0, -time.Now().Day()+1).Format("2006-01-02")
 	db.Model(&models.AnswerRecord{}).Where("user_id = ? AND DATE(created_at) >= ?", userID, monthStart).Count(&stats.MonthAnswered)
 
+	// 首次作答和最近一次作答的正确率
+	var latest struct {
+		QuestionsAnswered int64
+		FirstTryCorrect   int64
+		LatestCorrect     int64
+	}
+	db.Model(&models.AnswerState{}).Where("user_id = ?", userID).
+		Select("COUNT(*) AS questions_answered, COALESCE(SUM(first_correct), 0) AS first_try_correct, COALESCE(SUM(last_correct), 0) AS latest_correct").
+		Scan(&latest)
+	stats.QuestionsAnswered = latest.QuestionsAnswered
+	stats.FirstTryCorrect = latest.FirstTryCorrect
+	stats.LatestCorrect = latest.LatestCorrect
+	if stats.QuestionsAnswered > 0 {
+		stats.FirstTryAccuracy = float64(stats.FirstTryCorrect) / float64(stats.QuestionsAnswered) * 100
+		stats.LatestAccuracy = float64(stats.LatestCorrect) / float64(stats.QuestionsAnswered) * 100
+	}
+
 	// 分类统计
 	var categoryStats []CategoryStatistics
-	db.Raw(`
-		SELECT 
-			c.id as category_id,
-			c.name as category_name,
-			COUNT(ar.id) as total_answered,
-			SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) as correct_answered,
-			CASE 
-				WHEN COUNT(ar.id) > 0 THEN 
-					SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) * 100.0 / COUNT(ar.id)
-				ELSE 0 
-			END as accuracy_rate
-		FROM categories c
-		LEFT JOIN questions q ON c.id = q.category_id
-		LEFT JOIN answer_records ar ON q.id = ar.question_id AND ar.user_id = ?
-		WHERE ar.id IS NOT NULL
+	// 只列出作答过的分类；在 HAVING 中过滤，total_questions 才能统计分类下的全部题目
+	db.Raw(categoryStatisticsQuery+`
 		GROUP BY c.id, c.name
+		HAVING COUNT(s.id) > 0
 		ORDER BY total_answered DESC
 	`, userID).Scan(&categoryStats)
 
@@ -238,25 +511,6 @@ func GetAnswerStatistics(c *gin.Context) {
 	})
 }
 
-// addToMistakeBook 添加到错题本
-func addToMistakeBook(db *gorm.DB, userID uint, questionID uint) {
-	var existing models.MistakeBook
-	result := db.Where("user_id = ? AND question_id = ?", userID, questionID).First(&existing)
-	if result.Error != nil {
-		// 不存在则创建
-		mistake := models.MistakeBook{
-			UserID:     userID,
-			QuestionID: questionID,
-		}
-		db.Create(&mistake)
-	}
-}
-
-// removeFromMistakeBook 从错题本移除
-func removeFromMistakeBook(db *gorm.DB, userID uint, questionID uint) {
-	db.Where("user_id = ? AND question_id = ?", userID, questionID).Delete(&models.MistakeBook{})
-}
-
 // GetCategoryProgress 获取用户在特定分类下的进度
 func GetCategoryProgress(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -275,20 +529,7 @@ func GetCategoryProgress(c *gin.Context) {
 	var progress CategoryStatistics
 
 	// 查询该分类下用户的答题统计
-	err = db.Raw(`
-		SELECT 
-			c.id as category_id,
-			c.name as category_name,
-			COUNT(ar.id) as total_answered,
-			SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) as correct_answered,
-			CASE 
-				WHEN COUNT(ar.id) > 0 THEN 
-					SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) * 100.0 / COUNT(ar.id)
-				ELSE 0 
-			END as accuracy_rate
-		FROM categories c
-		LEFT JOIN questions q ON c.id = q.category_id
-		LEFT JOIN answer_records ar ON q.id = ar.question_id AND ar.user_id = ?
+	err = db.Raw(categoryStatisticsQuery+`
 		WHERE c.id = ?
 		GROUP BY c.id, c.name
 	`, userID, categoryID).Scan(&progress).Error
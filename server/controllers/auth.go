@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"qaminiprogram/config"
 	"qaminiprogram/middleware"
 	"qaminiprogram/models"
@@ -15,8 +14,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// dummyPasswordHash 用户不存在时用于比对的占位哈希
+const dummyPasswordHash = "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"
+
 // WechatLoginRequest 微信登录请求
 type WechatLoginRequest struct {
 	Code string `json:"code" binding:"required"`
@@ -31,15 +35,18 @@ type PasswordLoginRequest struct {
 
 // WechatLoginResponse 微信登录响应
 type WechatLoginResponse struct {
-	Token    string      `json:"token"`
-	User     models.User `json:"user"`
-	ExpireAt int64       `json:"expireAt"`
+	Token           string      `json:"token"`
+	RefreshToken    string      `json:"refreshToken"`
+	User            models.User `json:"user"`
+	Permissions     []string    `json:"permissions,omitempty"`
+	ExpireAt        int64       `json:"expireAt"`
+	RefreshExpireAt int64       `json:"refreshExpireAt"`
 }
 
 // WechatSessionResponse 微信会话响应
 type WechatSessionResponse struct {
 	OpenID     string `json:"openid"`
-	SessionKey string `json:"sessionKey"`
+	SessionKey string `json:"session_key"`
 	UnionID    string `json:"unionid,omitempty"`
 	ErrCode    int    `json:"errcode,omitempty"`
 	ErrMsg     string `json:"errmsg,omitempty"`
@@ -47,16 +54,23 @@ type WechatSessionResponse struct {
 
 // RefreshTokenRequest 刷新令牌请求
 type RefreshTokenRequest struct {
-	Token string `json:"token" binding:"required"`
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 
 
 // GuestLoginResponse 游客登录响应
 type GuestLoginResponse struct {
-	Token    string      `json:"token"`
-	User     models.User `json:"user"`
-	ExpireAt int64       `json:"expireAt"`
+	Token           string      `json:"token"`
+	RefreshToken    string      `json:"refreshToken"`
+	User            models.User `json:"user"`
+	ExpireAt        int64       `json:"expireAt"`
+	RefreshExpireAt int64       `json:"refreshExpireAt"`
 }
 
 // UnifiedLogin 统一登录接口
@@ -64,40 +78,30 @@ func UnifiedLogin(c *gin.Context) {
 	// 读取请求体
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		fmt.Printf("读取请求体失败: %v\n", err)
 		ErrorResponse(c, http.StatusBadRequest, "读取请求体失败")
 		return
 	}
 
-	fmt.Printf("收到请求体: %s\n", string(body))
-
 	// 先尝试解析为密码登录请求
 	var passwordReq PasswordLoginRequest
 	if err := json.Unmarshal(body, &passwordReq); err == nil && passwordReq.Type == "password" {
-		fmt.Printf("解析为密码登录请求成功: %+v\n", passwordReq)
 		// 重新设置请求体以供PasswordLogin使用
 		c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
 		// 处理密码登录
 		PasswordLogin(c)
 		return
-	} else {
-		fmt.Printf("解析密码登录请求失败: %v, Type: %s\n", err, passwordReq.Type)
 	}
 
 	// 尝试解析为微信登录请求
 	var wechatReq WechatLoginRequest
 	if err := json.Unmarshal(body, &wechatReq); err == nil && wechatReq.Code != "" {
-		fmt.Printf("解析为微信登录请求成功: %+v\n", wechatReq)
 		// 重新设置请求体以供WechatLogin使用
 		c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
 		// 处理微信登录
 		WechatLogin(c)
 		return
-	} else {
-		fmt.Printf("解析微信登录请求失败: %v, Code: %s\n", err, wechatReq.Code)
 	}
 
-	fmt.Printf("所有解析都失败，返回参数错误\n")
 	ErrorResponse(c, http.StatusBadRequest, "参数错误")
 }
 
@@ -110,42 +114,67 @@ func PasswordLogin(c *gin.Context) {
 	}
 
 	db := config.GetDB()
-	var user models.User
+	clientIP := c.ClientIP()
+
+	// 检查账号和IP是否处于锁定状态
+	for _, lock := range []struct{ scope, identifier string }{
+		{models.LoginScopeAccount, req.Username},
+		{models.LoginScopeIP, clientIP},
+	} {
+		if lockedUntil, locked := getLoginLock(db, lock.scope, lock.identifier); locked {
+			LogOperation(c, "LOGIN_LOCKED", "USER", fmt.Sprintf("登录被锁定: %s (%s)", req.Username, lock.scope))
+			ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("登录失败次数过多，请于 %s 后重试", lockedUntil.Format("2006-01-02 15:04:05")))
+			return
+		}
+	}
 
 	// 查找用户
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		ErrorResponse(c, http.StatusUnauthorized, "用户名或密码错误")
-		return
-	}
+	var user models.User
+	userErr := db.Where("username = ?", req.Username).First(&user).Error
 
-	// 验证密码
-	if user.Password == "" {
-		ErrorResponse(c, http.StatusUnauthorized, "用户未设置密码")
+	// 验证密码（用户不存在时也执行一次比对，避免通过响应时间枚举用户名）
+	passwordHash := dummyPasswordHash
+	if userErr == nil && user.Password != "" {
+		passwordHash = user.Password
+	}
+	passwordErr := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password))
+	if userErr != nil || user.Password == "" || passwordErr != nil {
+		recordLoginFailure(db, models.LoginScopeIP, clientIP)
+		message := "用户名或密码错误"
+		if lockedUntil := recordLoginFailure(db, models.LoginScopeAccount, req.Username); lockedUntil != nil {
+			message = fmt.Sprintf("登录失败次数过多，请于 %s 后重试", lockedUntil.Format("2006-01-02 15:04:05"))
+		}
+		LogOperation(c, "LOGIN_FAILED", "USER", fmt.Sprintf("密码登录失败: %s", req.Username))
+		ErrorResponse(c, http.StatusUnauthorized, message)
 		return
 	}
 
-		// err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
-	// if err != nil {
-	// 	ErrorResponse(c, http.StatusUnauthorized, "用户名或密码错误")
-	// 	return
-	// }
+	clearLoginFailures(db, models.LoginScopeAccount, req.Username)
 
-	// 生成JWT令牌
-	var openIDStr string
-	if user.OpenID != "" {
-		openIDStr = user.OpenID
+	// 检查账号状态
+	if rejectInactiveUser(c, &user) {
+		return
 	}
-	token, err := middleware.GenerateJWT(user.ID, openIDStr, user.Role)
+
+	// 创建会话并签发令牌
+	tokens, err := createUserSession(db, c, &user)
 	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "生成令牌失败")
 		return
 	}
 
+	// 记录操作日志
+	c.Set("userId", user.ID)
+	LogOperation(c, "LOGIN", "USER", fmt.Sprintf("密码登录: %s", user.Username))
+
 	// 返回登录结果
 	SuccessResponse(c, WechatLoginResponse{
-		Token:    token,
-		User:     user,
-		ExpireAt: time.Now().Add(24 * time.Hour).Unix(),
+		Token:           tokens.AccessToken,
+		RefreshToken:    tokens.RefreshToken,
+		User:            user,
+		Permissions:     middleware.GetRolePermissions(user.Role),
+		ExpireAt:        tokens.AccessExpireAt.Unix(),
+		RefreshExpireAt: tokens.RefreshExpireAt.Unix(),
 	})
 }
 
@@ -157,8 +186,8 @@ func WechatLogin(c *gin.Context) {
 		return
 	}
 
-	// 调用微信API获取openid
-	openID, err := getWechatOpenID(req.Code)
+	// 调用微信API换取会话信息
+	session, err := getWechatSessionClient().Code2Session(c.Request.Context(), req.Code)
 	if err != nil {
 		ErrorResponse(c, http.StatusBadRequest, "微信登录失败："+err.Error())
 		return
@@ -168,27 +197,42 @@ func WechatLogin(c *gin.Context) {
 	var user models.User
 
 	// 查找或创建用户
-	result := db.Where("open_id = ?", openID).First(&user)
+	result := db.Where("open_id = ?", session.OpenID).First(&user)
 	if result.Error != nil {
 		// 用户不存在，创建新用户
 		user = models.User{
-			OpenID:   openID,
-			Nickname: "微信用户" + generateRandomString(6),
-			Avatar:   "",
-			Role:     "user",
+			OpenID:     session.OpenID,
+			UnionID:    session.UnionID,
+			SessionKey: session.SessionKey,
+			Nickname:   "微信用户" + generateRandomString(6),
+			Avatar:     "",
+			Role:       models.RoleUser,
 		}
-		if err := db.Create(&user).Error; err != nil {
+		if err := createUser(db, &user); err != nil {
 			ErrorResponse(c, http.StatusInternalServerError, "创建用户失败")
 			return
 		}
+	} else {
+		// 保存最新的会话密钥，unionid只在微信返回时更新
+		updates := map[string]interface{}{"session_key": session.SessionKey}
+		if session.UnionID != "" {
+			updates["union_id"] = session.UnionID
+			user.UnionID = session.UnionID
+		}
+		user.SessionKey = session.SessionKey
+		if err := db.Model(&user).Updates(updates).Error; err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "更新用户失败")
+			return
+		}
 	}
 
-	// 生成JWT令牌
-	var openIDStr string
-	if user.OpenID != "" {
-		openIDStr = user.OpenID
+	// 检查账号状态
+	if rejectInactiveUser(c, &user) {
+		return
 	}
-	token, err := middleware.GenerateJWT(user.ID, openIDStr, user.Role)
+
+	// 创建会话并签发令牌
+	tokens, err := createUserSession(db, c, &user)
 	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "生成令牌失败")
 		return
@@ -196,13 +240,16 @@ func WechatLogin(c *gin.Context) {
 
 	// 返回登录结果
 	SuccessResponse(c, WechatLoginResponse{
-		Token:    token,
-		User:     user,
-		ExpireAt: time.Now().Add(24 * time.Hour).Unix(),
+		Token:           tokens.AccessToken,
+		RefreshToken:    tokens.RefreshToken,
+		User:            user,
+		ExpireAt:        tokens.AccessExpireAt.Unix(),
+		RefreshExpireAt: tokens.RefreshExpireAt.Unix(),
 	})
 }
 
 // RefreshToken 刷新令牌
+// 每次刷新都会作废旧的刷新令牌并签发新令牌；已使用过的刷新令牌再次出现视为泄露，整个会话将被注销
 func RefreshToken(c *gin.Context) {
 	var req RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -210,40 +257,156 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 解析旧令牌
-	claims, err := middleware.ParseJWT(req.Token)
-	if err != nil {
-		ErrorResponse(c, http.StatusUnauthorized, "无效的令牌")
+	db := config.GetDB()
+
+	// 查找刷新令牌
+	var record models.RefreshToken
+	if err := db.Where("token_hash = ?", hashRefreshToken(req.RefreshToken)).First(&record).Error; err != nil {
+		ErrorResponse(c, http.StatusUnauthorized, "无效的刷新令牌")
+		return
+	}
+
+	// 检测令牌重用
+	if record.UsedAt != nil {
+		revokeSession(db, record.SessionID, revokeReasonReuse)
+		c.Set("userId", record.UserID)
+		LogOperation(c, "TOKEN_REUSE", "USER", fmt.Sprintf("检测到刷新令牌重用，已注销会话 %d", record.SessionID))
+		ErrorResponse(c, http.StatusUnauthorized, "刷新令牌已失效，请重新登录")
+		return
+	}
+
+	if record.ExpiresAt.Before(time.Now()) {
+		ErrorResponse(c, http.StatusUnauthorized, "刷新令牌已过期，请重新登录")
+		return
+	}
+
+	// 验证会话
+	var session models.UserSession
+	if err := db.Where("id = ? AND revoked_at IS NULL", record.SessionID).First(&session).Error; err != nil {
+		ErrorResponse(c, http.StatusUnauthorized, "登录状态已失效，请重新登录")
 		return
 	}
 
 	// 验证用户是否存在
-	db := config.GetDB()
 	var user models.User
-	if err := db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+	if err := db.Where("id = ?", record.UserID).First(&user).Error; err != nil {
 		ErrorResponse(c, http.StatusUnauthorized, "用户不存在")
 		return
 	}
 
-	// 生成新令牌
-	var openIDStr string
-	if user.OpenID != "" {
-		openIDStr = user.OpenID
+	// 检查账号状态
+	if rejectInactiveUser(c, &user) {
+		return
 	}
-	newToken, err := middleware.GenerateJWT(user.ID, openIDStr, user.Role)
+
+	// 轮换刷新令牌
+	var tokens sessionTokens
+	reused := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		// 并发请求已抢先使用该令牌
+		if result.RowsAffected == 0 {
+			reused = true
+			return nil
+		}
+
+		refreshToken, refreshExpireAt, err := issueRefreshToken(tx, &session)
+		if err != nil {
+			return err
+		}
+		accessToken, accessExpireAt, err := middleware.GenerateJWT(user.ID, user.OpenID, user.Role, session.ID)
+		if err != nil {
+			return err
+		}
+
+		tokens = sessionTokens{
+			AccessToken:     accessToken,
+			AccessExpireAt:  accessExpireAt,
+			RefreshToken:    refreshToken,
+			RefreshExpireAt: refreshExpireAt,
+		}
+		return nil
+	})
 	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "生成令牌失败")
 		return
 	}
+	if reused {
+		revokeSession(db, session.ID, revokeReasonReuse)
+		ErrorResponse(c, http.StatusUnauthorized, "刷新令牌已失效，请重新登录")
+		return
+	}
 
 	// 返回新令牌
 	SuccessResponse(c, gin.H{
-		"token":     newToken,
-		"expireAt": time.Now().Add(24 * time.Hour).Unix(),
+		"token":           tokens.AccessToken,
+		"refreshToken":    tokens.RefreshToken,
+		"expireAt":        tokens.AccessExpireAt.Unix(),
+		"refreshExpireAt": tokens.RefreshExpireAt.Unix(),
 	})
 }
 
+// Logout 退出登录，注销当前会话
+func Logout(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req LogoutRequest
+	c.ShouldBindJSON(&req)
+
+	db := config.GetDB()
+
+	sessionID, _ := GetSessionID(c)
+	// 如果提供了刷新令牌，同时注销该令牌所属的会话
+	if req.RefreshToken != "" {
+		var record models.RefreshToken
+		if err := db.Where("token_hash = ? AND user_id = ?", hashRefreshToken(req.RefreshToken), userID).First(&record).Error; err == nil && record.SessionID != sessionID {
+			revokeSession(db, record.SessionID, revokeReasonLogout)
+		}
+	}
+
+	if err := revokeSession(db, sessionID, revokeReasonLogout); err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "退出登录失败")
+		return
+	}
+
+	// 记录操作日志
+	LogOperation(c, "LOGOUT", "USER", fmt.Sprintf("退出登录，会话 %d", sessionID))
+
+	SuccessResponse(c, gin.H{"message": "已退出登录"})
+}
 
+// LogoutAll 退出所有设备，注销用户的全部会话
+func LogoutAll(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	db := config.GetDB()
+	count, err := revokeUserSessions(db, userID, revokeReasonLogoutAll)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "退出登录失败")
+		return
+	}
+
+	// 记录操作日志
+	LogOperation(c, "LOGOUT_ALL", "USER", fmt.Sprintf("退出所有设备，共注销 %d 个会话", count))
+
+	SuccessResponse(c, gin.H{
+		"message":      "已退出所有设备",
+		"revokedCount": count,
+	})
+}
 
 // GuestLoginRequest 游客登录请求
 type GuestLoginRequest struct {
@@ -285,25 +448,30 @@ func GuestLogin(c *gin.Context) {
 	guestOpenID := "guest_" + deviceID
 	
 	// 首先尝试查找已存在的游客用户
-	if err := db.Where("openid = ? AND is_guest = true", guestOpenID).First(&user).Error; err != nil {
+	if err := db.Where("open_id = ? AND is_guest = true", guestOpenID).First(&user).Error; err != nil {
 		// 如果没有找到，创建新的游客用户
 		user = models.User{
 			OpenID:   guestOpenID,
 			Username: guestUsername,
 			Nickname: "游客" + generateRandomString(6),
 			Avatar:   "",
-			Role:     "guest",
+			Role:     models.RoleGuest,
 			IsGuest:  true,
 		}
 		
-		if err := db.Create(&user).Error; err != nil {
+		if err := createUser(db, &user); err != nil {
 			ErrorResponse(c, http.StatusInternalServerError, "创建游客用户失败")
 			return
 		}
 	}
+
+	// 检查账号状态
+	if rejectInactiveUser(c, &user) {
+		return
+	}
 	
-	// 生成JWT令牌
-	token, err := middleware.GenerateJWT(user.ID, user.OpenID, user.Role)
+	// 创建会话并签发令牌
+	tokens, err := createUserSession(db, c, &user)
 	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "生成令牌失败")
 		return
@@ -314,47 +482,27 @@ func GuestLogin(c *gin.Context) {
 
 	// 返回登录结果
 	SuccessResponse(c, GuestLoginResponse{
-		Token:    token,
-		User:     user,
-		ExpireAt: time.Now().Add(24 * time.Hour).Unix(),
+		Token:           tokens.AccessToken,
+		RefreshToken:    tokens.RefreshToken,
+		User:            user,
+		ExpireAt:        tokens.AccessExpireAt.Unix(),
+		RefreshExpireAt: tokens.RefreshExpireAt.Unix(),
 	})
 }
 
 
 
-// getWechatOpenID 获取微信OpenID
-func getWechatOpenID(code string) (string, error) {
-	appID := os.Getenv("WX_APPID")
-	appSecret := os.Getenv("WX_SECRET")
-	
-	if appID == "" || appSecret == "" {
-		return "", fmt.Errorf("微信配置未设置")
-	}
-
-	url := fmt.Sprintf("https://api.weixin.qq.com/sns/jscode2session?appid=%s&secret=%s&js_code=%s&grant_type=authorization_code",
-		appID, appSecret, code)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var wxResp WechatSessionResponse
-	if err := json.Unmarshal(body, &wxResp); err != nil {
-		return "", err
-	}
-
-	if wxResp.ErrCode != 0 {
-		return "", fmt.Errorf("微信API错误: %s", wxResp.ErrMsg)
+// rejectInactiveUser 账号处于停用、封禁或待审核状态时拒绝登录，返回是否已拒绝
+func rejectInactiveUser(c *gin.Context, user *models.User) bool {
+	code, message, denied := user.AccessDenial()
+	if !denied {
+		return false
 	}
 
-	return wxResp.OpenID, nil
+	c.Set("userId", user.ID)
+	LogOperation(c, "LOGIN_DENIED", "USER", fmt.Sprintf("账号状态为 %s，拒绝登录: %s", user.EffectiveStatus(), user.Username))
+	middleware.AbortWithUserStatus(c, user, code, message)
+	return true
 }
 
 // generateRandomString 生成随机字符串
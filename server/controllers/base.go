@@ -79,6 +79,18 @@ func GetOpenID(c *gin.Context) (string, bool) {
 	return "", false
 }
 
+// GetSessionID 从上下文获取当前会话ID
+func GetSessionID(c *gin.Context) (uint, bool) {
+	sessionID, exists := c.Get("sessionId")
+	if !exists {
+		return 0, false
+	}
+	if id, ok := sessionID.(uint); ok && id != 0 {
+		return id, true
+	}
+	return 0, false
+}
+
 // GetRole 从上下文获取用户角色
 func GetRole(c *gin.Context) (string, bool) {
 	role, exists := c.Get("role")
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 难度校准参数
+const (
+	// 作答人数少于该值时不给出建议难度
+	calibrationMinSample = 30
+	// 平滑时加入的虚拟作答人数，正确率按全站第一次作答正确率先验
+	calibrationPriorWeight = 10
+	// 平滑正确率不低于该值为简单，不低于 calibrationMediumAccuracy 为中等，否则为困难
+	calibrationEasyAccuracy   = 0.75
+	calibrationMediumAccuracy = 0.45
+)
+
+// CalibrateResponse 难度校准结果
+type CalibrateResponse struct {
+	PriorAccuracy      float64 `json:"priorAccuracy"`
+	QuestionsUpdated   int64   `json:"questionsUpdated"`
+	QuestionsSuggested int64   `json:"questionsSuggested"`
+	Mismatched         int64   `json:"mismatched"`
+	Duration           int64   `json:"duration"`
+}
+
+// ApplyCalibrationRequest 应用建议难度请求，IDs 为空时应用全部与数据不符的题目
+type ApplyCalibrationRequest struct {
+	IDs        []uint `json:"ids"`
+	CategoryID *uint  `json:"categoryId"`
+}
+
+// mismatchedDifficulty 标注难度与建议难度不一致的题目
+func mismatchedDifficulty(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Question{}).
+		Where("suggested_difficulty <> '' AND suggested_difficulty <> difficulty")
+}
+
+// calibrateQuestions 按每位用户第一次作答的平滑正确率估计全部题目的难度
+// 平滑正确率 = (答对人数 + 先验正确率 × 虚拟人数) / (作答人数 + 虚拟人数)，作答人数不足时只记录估计值不给出建议
+func calibrateQuestions(db *gorm.DB) (CalibrateResponse, error) {
+	var response CalibrateResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var prior struct {
+			Accuracy *float64
+		}
+		if err := tx.Raw("SELECT AVG(first_correct) AS accuracy FROM answer_states").Scan(&prior).Error; err != nil {
+			return err
+		}
+		response.PriorAccuracy = 0.5
+		if prior.Accuracy != nil {
+			response.PriorAccuracy = *prior.Accuracy
+		}
+
+		// updated_at 显式保持原值，校准不算作题目修改
+		result := tx.Exec(`
+			UPDATE questions q
+			LEFT JOIN (
+				SELECT question_id, COUNT(*) AS sample, SUM(first_correct) AS correct
+				FROM answer_states
+				GROUP BY question_id
+			) a ON a.question_id = q.id
+			SET
+				q.estimated_accuracy = CASE WHEN a.sample > 0 THEN (a.correct + ? * ?) / (a.sample + ?) END,
+				q.suggested_difficulty = CASE
+					WHEN COALESCE(a.sample, 0) < ? THEN ''
+					WHEN (a.correct + ? * ?) / (a.sample + ?) >= ? THEN 'easy'
+					WHEN (a.correct + ? * ?) / (a.sample + ?) >= ? THEN 'medium'
+					ELSE 'hard'
+				END,
+				q.calibration_sample = COALESCE(a.sample, 0),
+				q.calibrated_at = ?,
+				q.updated_at = q.updated_at
+		`,
+			response.PriorAccuracy, calibrationPriorWeight, calibrationPriorWeight,
+			calibrationMinSample,
+			response.PriorAccuracy, calibrationPriorWeight, calibrationPriorWeight, calibrationEasyAccuracy,
+			response.PriorAccuracy, calibrationPriorWeight, calibrationPriorWeight, calibrationMediumAccuracy,
+			time.Now(),
+		)
+		if result.Error != nil {
+			return result.Error
+		}
+		response.QuestionsUpdated = result.RowsAffected
+
+		if err := tx.Model(&models.Question{}).Where("suggested_difficulty <> ''").Count(&response.QuestionsSuggested).Error; err != nil {
+			return err
+		}
+		return mismatchedDifficulty(tx).Count(&response.Mismatched).Error
+	})
+	return response, err
+}
+
+// CalibrateDifficulty 按作答数据重新估计全部题目的难度（管理员）
+// 只更新估计值和建议难度，不修改题目标注的难度
+func CalibrateDifficulty(c *gin.Context) {
+	start := time.Now()
+	response, err := calibrateQuestions(config.GetDB())
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "难度校准失败")
+		return
+	}
+	response.Duration = time.Since(start).Milliseconds()
+
+	LogOperation(c, "CALIBRATE", "QUESTION", fmt.Sprintf("校准题目难度：%d 道题给出建议，%d 道与标注不符", response.QuestionsSuggested, response.Mismatched))
+
+	SuccessResponse(c, response)
+}
+
+// GetDifficultyCalibration 获取难度校准结果（管理员）
+// 默认只列出标注难度与建议难度不一致的题目，all=true 时列出全部已校准的题目
+func GetDifficultyCalibration(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	query := mismatchedDifficulty(db)
+	if c.Query("all") == "true" {
+		query = db.Model(&models.Question{}).Where("calibrated_at IS NOT NULL")
+	}
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		query = query.Where("difficulty = ?", difficulty)
+	}
+	if suggested := c.Query("suggestedDifficulty"); suggested != "" {
+		query = query.Where("suggested_difficulty = ?", suggested)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取校准结果失败")
+		return
+	}
+
+	var questions []models.Question
+	if err := query.Preload("Category").
+		Order("calibration_sample DESC, id ASC").
+		Offset(offset).Limit(size).Find(&questions).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取校准结果失败")
+		return
+	}
+
+	PageSuccessResponse(c, questions, total, page, size)
+}
+
+// ApplyDifficultyCalibration 把建议难度批量应用为题目标注的难度（管理员）
+func ApplyDifficultyCalibration(c *gin.Context) {
+	var req ApplyCalibrationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ErrorResponse(c, http.StatusBadRequest, "参数错误")
+			return
+		}
+	}
+
+	db := config.GetDB()
+	query := mismatchedDifficulty(db)
+	if len(req.IDs) > 0 {
+		query = query.Where("id IN ?", req.IDs)
+	}
+	if req.CategoryID != nil {
+		query = query.Where("category_id = ?", *req.CategoryID)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"difficulty": gorm.Expr("suggested_difficulty"),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "应用建议难度失败")
+		return
+	}
+
+	LogOperation(c, "APPLY_CALIBRATION", "QUESTION", fmt.Sprintf("应用建议难度：更新 %d 道题", result.RowsAffected))
+
+	SuccessResponse(c, gin.H{"updated": result.RowsAffected})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecomputeCountersResponse 重算计数的结果
+type RecomputeCountersResponse struct {
+	UsersUpdated     int64 `json:"usersUpdated"`
+	QuestionsUpdated int64 `json:"questionsUpdated"`
+	Duration         int64 `json:"duration"` // 毫秒
+}
+
+// incrementAnswerCounters 作答后累加用户和题目的答题计数，需与作答记录在同一事务中调用
+// MySQL 按顺序执行 SET 中的赋值，accuracy_rate 使用的是累加后的值
+func incrementAnswerCounters(tx *gorm.DB, userID, questionID uint, isCorrect bool, answeredAt time.Time) error {
+	correct := 0
+	if isCorrect {
+		correct = 1
+	}
+
+	if err := tx.Exec(`
+		UPDATE users SET
+			total_answered = total_answered + 1,
+			total_correct = total_correct + ?,
+			accuracy_rate = total_correct * 100.0 / total_answered,
+			last_active_time = GREATEST(COALESCE(last_active_time, ?), ?)
+		WHERE id = ?
+	`, correct, answeredAt, answeredAt, userID).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		UPDATE questions SET
+			total_answered = total_answered + 1,
+			total_correct = total_correct + ?,
+			accuracy_rate = total_correct * 100.0 / total_answered
+		WHERE id = ?
+	`, correct, questionID).Error
+}
+
+// recomputeUserCounters 按 answer_records 重建用户的答题计数，userIDs 为空时重建全部用户
+func recomputeUserCounters(db *gorm.DB, userIDs ...uint) (int64, error) {
+	recordFilter, userFilter := "", ""
+	var args []interface{}
+	if len(userIDs) > 0 {
+		recordFilter, userFilter = "WHERE user_id IN ?", "WHERE u.id IN ?"
+		args = append(args, userIDs, userIDs)
+	}
+
+	result := db.Exec(fmt.Sprintf(`
+		UPDATE users u
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS total_answered, SUM(is_correct) AS total_correct, MAX(answered_at) AS last_answered
+			FROM answer_records %s
+			GROUP BY user_id
+		) a ON a.user_id = u.id
+		SET
+			u.total_answered = COALESCE(a.total_answered, 0),
+			u.total_correct = COALESCE(a.total_correct, 0),
+			u.accuracy_rate = CASE WHEN a.total_answered > 0 THEN a.total_correct * 100.0 / a.total_answered ELSE 0 END,
+			u.last_active_time = GREATEST(COALESCE(u.last_active_time, a.last_answered), COALESCE(a.last_answered, u.last_active_time))
+		%s
+	`, recordFilter, userFilter), args...)
+	return result.RowsAffected, result.Error
+}
+
+// recomputeQuestionCounters 按 answer_records 重建全部题目的答题计数
+func recomputeQuestionCounters(db *gorm.DB) (int64, error) {
+	result := db.Exec(`
+		UPDATE questions q
+		LEFT JOIN (
+			SELECT question_id, COUNT(*) AS total_answered, SUM(is_correct) AS total_correct
+			FROM answer_records
+			GROUP BY question_id
+		) a ON a.question_id = q.id
+		SET
+			q.total_answered = COALESCE(a.total_answered, 0),
+			q.total_correct = COALESCE(a.total_correct, 0),
+			q.accuracy_rate = CASE WHEN a.total_answered > 0 THEN a.total_correct * 100.0 / a.total_answered ELSE 0 END
+	`)
+	return result.RowsAffected, result.Error
+}
+
+// RecomputeCounters 按答题记录重建用户和题目的答题计数（管理员）
+// 用于修复历史数据或手工改动答题记录之后的计数偏差
+func RecomputeCounters(c *gin.Context) {
+	db := config.GetDB()
+	start := time.Now()
+
+	var response RecomputeCountersResponse
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if response.UsersUpdated, err = recomputeUserCounters(tx); err != nil {
+			return err
+		}
+		response.QuestionsUpdated, err = recomputeQuestionCounters(tx)
+		return err
+	})
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "重算答题计数失败")
+		return
+	}
+	response.Duration = time.Since(start).Milliseconds()
+
+	LogOperation(c, "RECOMPUTE", "STATISTICS", fmt.Sprintf("重算答题计数：用户 %d 个，题目 %d 道", response.UsersUpdated, response.QuestionsUpdated))
+
+	SuccessResponse(c, response)
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 每日统计默认和最长的日期范围（天）
+const (
+	defaultDailyStatsDays = 30
+	maxDailyStatsDays     = 366
+)
+
+// RebuildDailyStatsResponse 重建每日统计的结果
+type RebuildDailyStatsResponse struct {
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	Days      int    `json:"days"`
+	Duration  int64  `json:"duration"` // 毫秒
+}
+
+// statDate 时间在业务时区的日期
+func statDate(t time.Time) string {
+	return t.In(config.Location()).Format("2006-01-02")
+}
+
+// createUser 创建用户并累加当日新增用户数
+func createUser(db *gorm.DB, user *models.User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO daily_stats (stat_date, new_users, updated_at) VALUES (?, 1, NOW())
+			ON DUPLICATE KEY UPDATE new_users = new_users + 1, updated_at = VALUES(updated_at)
+		`, statDate(user.CreatedAt)).Error
+	})
+}
+
+// recordDailyAnswer 作答后累加当日的答题数和活跃用户数，需与作答记录在同一事务中调用
+func recordDailyAnswer(tx *gorm.DB, userID uint, isCorrect bool, answeredAt time.Time) error {
+	date := statDate(answeredAt)
+
+	// 当日第一次作答时才计入活跃用户
+	result := tx.Exec("INSERT IGNORE INTO daily_active_users (stat_date, user_id, created_at) VALUES (?, ?, ?)", date, userID, answeredAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	correct := 0
+	if isCorrect {
+		correct = 1
+	}
+	return tx.Exec(`
+		INSERT INTO daily_stats (stat_date, active_users, total_answers, correct_answers, updated_at)
+		VALUES (?, ?, 1, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			active_users = active_users + VALUES(active_users),
+			total_answers = total_answers + 1,
+			correct_answers = correct_answers + VALUES(correct_answers),
+			updated_at = VALUES(updated_at)
+	`, date, result.RowsAffected, correct).Error
+}
+
+// parseStatsDateRange 解析 startDate、endDate（YYYY-MM-DD，业务时区），默认为截至今天的最近30天
+func parseStatsDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	loc := config.Location()
+	end := startOfDay(time.Now())
+	if value := c.Query("endDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("结束日期格式错误")
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(defaultDailyStatsDays - 1))
+	if value := c.Query("startDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("开始日期格式错误")
+		}
+		start = parsed
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("开始日期不能晚于结束日期")
+	}
+	if end.Sub(start) >= maxDailyStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("日期范围不能超过%d天", maxDailyStatsDays)
+	}
+	return start, end, nil
+}
+
+// dailyStatsSeries 读取日期范围内的每日统计，没有数据的日期补零
+func dailyStatsSeries(db *gorm.DB, start, end time.Time) ([]UserStatItem, []AnswerStatItem, error) {
+	var rows []models.DailyStat
+	if err := db.Where("stat_date BETWEEN ? AND ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	byDate := make(map[string]models.DailyStat, len(rows))
+	for _, row := range rows {
+		// DATE 列按 parseTime 读出为时间字符串，只取日期部分
+		if len(row.StatDate) > 10 {
+			row.StatDate = row.StatDate[:10]
+		}
+		byDate[row.StatDate] = row
+	}
+
+	userStats := []UserStatItem{}
+	answerStats := []AnswerStatItem{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		row := byDate[date]
+		userStats = append(userStats, UserStatItem{
+			Date:        date,
+			NewUsers:    row.NewUsers,
+			ActiveUsers: row.ActiveUsers,
+		})
+		correctRate := 0.0
+		if row.TotalAnswers > 0 {
+			correctRate = float64(row.CorrectAnswers) * 100 / float64(row.TotalAnswers)
+		}
+		answerStats = append(answerStats, AnswerStatItem{
+			Date:           date,
+			TotalAnswers:   row.TotalAnswers,
+			CorrectAnswers: row.CorrectAnswers,
+			CorrectRate:    fmt.Sprintf("%.2f", correctRate),
+		})
+	}
+	return userStats, answerStats, nil
+}
+
+// rebuildDailyStats 按用户表和答题记录重建日期范围内的每日统计，日期按业务时区划分
+func rebuildDailyStats(db *gorm.DB, start, end time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			next := day.AddDate(0, 0, 1)
+
+			if err := tx.Where("stat_date = ?", date).Delete(&models.DailyActiveUser{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(`
+				INSERT INTO daily_active_users (stat_date, user_id, created_at)
+				SELECT ?, user_id, MIN(answered_at) FROM answer_records
+				WHERE answered_at >= ? AND answered_at < ?
+				GROUP BY user_id
+			`, date, day, next).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				INSERT INTO daily_stats (stat_date, new_users, active_users, total_answers, correct_answers, updated_at)
+				SELECT ?,
+					(SELECT COUNT(*) FROM users WHERE created_at >= ? AND created_at < ?),
+					(SELECT COUNT(*) FROM daily_active_users WHERE stat_date = ?),
+					COUNT(*), COALESCE(SUM(is_correct), 0), NOW()
+				FROM answer_records
+				WHERE answered_at >= ? AND answered_at < ?
+				ON DUPLICATE KEY UPDATE
+					new_users = VALUES(new_users),
+					active_users = VALUES(active_users),
+					total_answers = VALUES(total_answers),
+					correct_answers = VALUES(correct_answers),
+					updated_at = VALUES(updated_at)
+			`, date, day, next, date, day, next).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebuildDailyStats 按用户表和答题记录重建每日统计（管理员）
+// 用于回填历史数据或修改 APP_TIMEZONE 之后，日期范围参数同 GetSystemStatistics
+func RebuildDailyStats(c *gin.Context) {
+	start, end, err := parseStatsDateRange(c)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	began := time.Now()
+	if err := rebuildDailyStats(config.GetDB(), start, end); err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "重建每日统计失败")
+		return
+	}
+
+	response := RebuildDailyStatsResponse{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Days:      int(end.Sub(start).Hours()/24+0.5) + 1,
+		Duration:  time.Since(began).Milliseconds(),
+	}
+
+	LogOperation(c, "REBUILD", "STATISTICS", fmt.Sprintf("重建每日统计：%s 至 %s", response.StartDate, response.EndDate))
+
+	SuccessResponse(c, response)
+}
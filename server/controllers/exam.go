@@ -0,0 +1,749 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 考试相关配置
+const (
+	examGracePeriod          = 5 * time.Second // 截止后仍接受的网络延迟
+	defaultExamSweepInterval = time.Minute
+	examSweepBatchSize       = 100
+)
+
+// 事务中重新检查考试状态时的结果
+var (
+	errExamFinished = errors.New("考试已结束")
+	errExamOverdue  = errors.New("考试已超时")
+)
+
+// ExamAnswerDraft 保存的作答草稿，Answer为空表示清除作答
+type ExamAnswerDraft struct {
+	QuestionID uint         `json:"questionId" binding:"required"`
+	Answer     *AnswerInput `json:"answer"`
+}
+
+// SaveExamAnswersRequest 保存作答草稿请求
+type SaveExamAnswersRequest struct {
+	Answers []ExamAnswerDraft `json:"answers" binding:"required,min=1"`
+}
+
+// ExamQuestionView 考试中下发给用户的题目，不包含答案和解析
+type ExamQuestionView struct {
+	QuestionID uint             `json:"questionId"`
+	Sort       int              `json:"sortOrder"`
+	Score      float64          `json:"score"`
+	Title      string           `json:"title"`
+	Content    string           `json:"content"`
+	Type       string           `json:"type"`
+	Options    models.JSONArray `json:"options"`
+	Difficulty string           `json:"difficulty"`
+	BlankCount int              `json:"blankCount,omitempty"`
+	UserAnswer *AnswerInput     `json:"userAnswer"`
+}
+
+// ExamSectionView 考试中的分节
+type ExamSectionView struct {
+	Title     string             `json:"title"`
+	Questions []ExamQuestionView `json:"questions"`
+}
+
+// ExamSessionView 考试会话详情
+type ExamSessionView struct {
+	ID               uint              `json:"id"`
+	PaperID          uint              `json:"paperId"`
+	PaperTitle       string            `json:"paperTitle"`
+	Status           string            `json:"status"`
+	StartedAt        time.Time         `json:"startedAt"`
+	Deadline         *time.Time        `json:"deadline"`
+	RemainingSeconds *int64            `json:"remainingSeconds"`
+	TotalScore       float64           `json:"totalScore"`
+	PassScore        float64           `json:"passScore"`
+	QuestionCount    int               `json:"questionCount"`
+	AnsweredCount    int               `json:"answeredCount"`
+	Sections         []ExamSectionView `json:"sections"`
+	Result           *ExamResult       `json:"result,omitempty"`
+}
+
+// ExamSectionResult 分节得分
+type ExamSectionResult struct {
+	Title         string  `json:"title"`
+	Score         float64 `json:"score"`
+	TotalScore    float64 `json:"totalScore"`
+	QuestionCount int     `json:"questionCount"`
+	CorrectCount  int     `json:"correctCount"`
+}
+
+// ExamResult 考试成绩
+type ExamResult struct {
+	SessionID     uint                `json:"sessionId"`
+	PaperID       uint                `json:"paperId"`
+	PaperTitle    string              `json:"paperTitle"`
+	Score         float64             `json:"score"`
+	TotalScore    float64             `json:"totalScore"`
+	PassScore     float64             `json:"passScore"`
+	Passed        bool                `json:"passed"`
+	QuestionCount int                 `json:"questionCount"`
+	CorrectCount  int                 `json:"correctCount"`
+	TimeSpent     int                 `json:"timeSpent"`
+	AutoSubmitted bool                `json:"autoSubmitted"`
+	SubmittedAt   *time.Time          `json:"submittedAt"`
+	Rank          int64               `json:"rank"`
+	Participants  int64               `json:"participants"`
+	Sections      []ExamSectionResult `json:"sections"`
+	Answers       []models.ExamAnswer `json:"answers"`
+}
+
+// StartExam 开始考试，已有未结束的考试时直接返回该考试
+func StartExam(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	paperID, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	db := config.GetDB()
+	paper, err := loadPaper(db, paperID)
+	if err != nil || paper.Status != models.PaperStatusPublished {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+
+	// 继续未结束的考试
+	var existing models.ExamSession
+	if err := db.Where("user_id = ? AND paper_id = ? AND status = ?", userID, paperID, models.ExamStatusInProgress).
+		First(&existing).Error; err == nil {
+		if !expireOverdueExam(db, &existing) {
+			view, err := buildExamSessionView(db, &existing)
+			if err != nil {
+				ErrorResponse(c, http.StatusInternalServerError, "获取考试失败")
+				return
+			}
+			SuccessResponse(c, view)
+			return
+		}
+	}
+
+	sections, err := generatePaper(db, paper)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	session := models.ExamSession{
+		UserID:    userID,
+		PaperID:   paper.ID,
+		Status:    models.ExamStatusInProgress,
+		StartedAt: now,
+		PassScore: paper.PassScore,
+	}
+	if paper.TimeLimit > 0 {
+		deadline := now.Add(time.Duration(paper.TimeLimit) * time.Minute)
+		session.Deadline = &deadline
+	}
+
+	// 冻结题目顺序和分值
+	var answers []models.ExamAnswer
+	for i, section := range sections {
+		for _, item := range section.Questions {
+			answers = append(answers, models.ExamAnswer{
+				QuestionID:   item.Question.ID,
+				SectionTitle: section.Title,
+				SectionSort:  i,
+				Sort:         len(answers),
+				Score:        item.Score,
+			})
+			session.TotalScore += item.Score
+		}
+	}
+	if len(answers) == 0 {
+		ErrorResponse(c, http.StatusBadRequest, "试卷中没有题目")
+		return
+	}
+	session.QuestionCount = len(answers)
+
+	// 锁定用户行后再次检查未结束的考试，并发开始同一试卷时只会创建一个会话，其余请求继续该会话
+	var concurrent models.ExamSession
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND paper_id = ? AND status = ?", userID, paperID, models.ExamStatusInProgress).
+			Limit(1).Find(&concurrent).Error; err != nil {
+			return err
+		}
+		if concurrent.ID != 0 {
+			return nil
+		}
+
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		for i := range answers {
+			answers[i].SessionID = session.ID
+		}
+		return tx.Create(&answers).Error
+	})
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "开始考试失败")
+		return
+	}
+	if concurrent.ID != 0 {
+		session = concurrent
+	}
+
+	view, err := buildExamSessionView(db, &session)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试失败")
+		return
+	}
+
+	SuccessResponse(c, view)
+}
+
+// GetExamSession 获取考试详情，已交卷时附带成绩
+func GetExamSession(c *gin.Context) {
+	session, ok := loadUserExamSession(c)
+	if !ok {
+		return
+	}
+
+	db := config.GetDB()
+	expireOverdueExam(db, session)
+
+	view, err := buildExamSessionView(db, session)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试失败")
+		return
+	}
+
+	SuccessResponse(c, view)
+}
+
+// SaveExamAnswers 保存作答草稿，交卷前可以反复修改
+func SaveExamAnswers(c *gin.Context) {
+	session, ok := loadUserExamSession(c)
+	if !ok {
+		return
+	}
+
+	var req SaveExamAnswersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	db := config.GetDB()
+	if expireOverdueExam(db, session) {
+		ErrorResponse(c, http.StatusBadRequest, "考试已超时，系统已自动交卷")
+		return
+	}
+	if session.Status != models.ExamStatusInProgress {
+		ErrorResponse(c, http.StatusBadRequest, "考试已结束")
+		return
+	}
+
+	var answers []models.ExamAnswer
+	if err := db.Preload("Question").Where("session_id = ?", session.ID).Find(&answers).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试题目失败")
+		return
+	}
+	byQuestion := make(map[uint]*models.ExamAnswer, len(answers))
+	for i := range answers {
+		byQuestion[answers[i].QuestionID] = &answers[i]
+	}
+
+	// 先整体校验，避免部分保存
+	for _, draft := range req.Answers {
+		answer, found := byQuestion[draft.QuestionID]
+		if !found {
+			ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("题目 %d 不在本次考试中", draft.QuestionID))
+			return
+		}
+		if draft.Answer != nil && answer.Question != nil {
+			if _, err := gradeAnswer(answer.Question, *draft.Answer, false); err != nil {
+				ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("题目 %d：%s", draft.QuestionID, err.Error()))
+				return
+			}
+		}
+	}
+
+	// 锁定会话后再次检查状态和截止时间，避免与交卷或后台自动交卷并发时把作答写入已判分的考试
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked models.ExamSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", session.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		if locked.Status != models.ExamStatusInProgress {
+			return errExamFinished
+		}
+		if isExamOverdue(&locked, now) {
+			return errExamOverdue
+		}
+
+		for _, draft := range req.Answers {
+			answer := byQuestion[draft.QuestionID]
+			updates := map[string]interface{}{"user_answer": nil, "answer_text": "", "answered_at": nil}
+			answer.UserAnswer, answer.AnswerText = nil, ""
+			if draft.Answer != nil {
+				userAnswer, answerText := draft.Answer.storage()
+				if answerText == "" {
+					answer.UserAnswer = &userAnswer
+				}
+				answer.AnswerText = answerText
+				updates["user_answer"], updates["answer_text"], updates["answered_at"] = answer.UserAnswer, answerText, &now
+			}
+			if err := tx.Model(&models.ExamAnswer{}).Where("id = ?", answer.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errExamOverdue):
+		expireOverdueExam(db, session)
+		ErrorResponse(c, http.StatusBadRequest, "考试已超时，系统已自动交卷")
+		return
+	case errors.Is(err, errExamFinished):
+		ErrorResponse(c, http.StatusBadRequest, "考试已结束")
+		return
+	case err != nil:
+		ErrorResponse(c, http.StatusInternalServerError, "保存作答失败")
+		return
+	}
+
+	answeredCount := 0
+	for _, answer := range answers {
+		if isExamAnswered(&answer) {
+			answeredCount++
+		}
+	}
+
+	SuccessResponse(c, gin.H{
+		"saved":            len(req.Answers),
+		"answeredCount":    answeredCount,
+		"remainingSeconds": examRemainingSeconds(session),
+	})
+}
+
+// SubmitExam 交卷并返回成绩，重复交卷直接返回已有成绩
+func SubmitExam(c *gin.Context) {
+	session, ok := loadUserExamSession(c)
+	if !ok {
+		return
+	}
+
+	db := config.GetDB()
+	if session.Status == models.ExamStatusInProgress {
+		autoSubmit := isExamOverdue(session, time.Now())
+		if err := finishExamSession(db, session.ID, session.UserID, autoSubmit); err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "交卷失败")
+			return
+		}
+		db.Where("id = ?", session.ID).First(session)
+
+		// 记录操作日志
+		LogOperation(c, "SUBMIT", "EXAM", fmt.Sprintf("提交考试 %d（试卷 %d），得分 %.2f", session.ID, session.PaperID, session.Score))
+	}
+
+	result, err := buildExamResult(db, session)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试成绩失败")
+		return
+	}
+
+	SuccessResponse(c, result)
+}
+
+// GetExamResult 获取考试成绩
+func GetExamResult(c *gin.Context) {
+	session, ok := loadUserExamSession(c)
+	if !ok {
+		return
+	}
+
+	db := config.GetDB()
+	expireOverdueExam(db, session)
+	if session.Status != models.ExamStatusSubmitted {
+		ErrorResponse(c, http.StatusBadRequest, "考试尚未交卷")
+		return
+	}
+
+	result, err := buildExamResult(db, session)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试成绩失败")
+		return
+	}
+
+	SuccessResponse(c, result)
+}
+
+// GetMyExams 获取当前用户的考试记录
+func GetMyExams(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	query := db.Model(&models.ExamSession{}).Where("user_id = ?", userID)
+	if paperID := c.Query("paperId"); paperID != "" {
+		query = query.Where("paper_id = ?", paperID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var sessions []models.ExamSession
+	if err := query.Preload("Paper").Order("started_at DESC").Offset(offset).Limit(size).Find(&sessions).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取考试记录失败")
+		return
+	}
+
+	PageSuccessResponse(c, sessions, total, page, size)
+}
+
+// StartExamSweeper 启动后台协程，定期把超过截止时间的考试自动交卷
+// 间隔由 EXAM_SWEEP_INTERVAL（秒）配置；无常驻进程的部署依赖访问考试时的超时检查
+func StartExamSweeper() {
+	interval := defaultExamSweepInterval
+	if seconds, err := strconv.Atoi(os.Getenv("EXAM_SWEEP_INTERVAL")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := sweepOverdueExams(config.GetDB())
+			if err != nil {
+				log.Printf("Exam sweeper failed: %v", err)
+				continue
+			}
+			if count > 0 {
+				log.Printf("Exam sweeper auto-submitted %d sessions", count)
+			}
+		}
+	}()
+}
+
+// sweepOverdueExams 自动交卷所有已超时的考试，返回处理的数量
+func sweepOverdueExams(db *gorm.DB) (int, error) {
+	count := 0
+	for {
+		var overdue []models.ExamSession
+		if err := db.Select("id", "user_id").
+			Where("status = ? AND deadline IS NOT NULL AND deadline < ?", models.ExamStatusInProgress, time.Now().Add(-examGracePeriod)).
+			Limit(examSweepBatchSize).Find(&overdue).Error; err != nil {
+			return count, err
+		}
+		if len(overdue) == 0 {
+			return count, nil
+		}
+
+		for _, session := range overdue {
+			if err := finishExamSession(db, session.ID, session.UserID, true); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
+
+// loadUserExamSession 加载当前用户的考试会话，失败时已写入错误响应
+func loadUserExamSession(c *gin.Context) (*models.ExamSession, bool) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return nil, false
+	}
+
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的考试ID")
+		return nil, false
+	}
+
+	var session models.ExamSession
+	if err := config.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&session).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "考试不存在")
+		return nil, false
+	}
+	return &session, true
+}
+
+// isExamOverdue 判断考试是否已超过截止时间（含宽限期）
+func isExamOverdue(session *models.ExamSession, now time.Time) bool {
+	return session.Status == models.ExamStatusInProgress && session.Deadline != nil &&
+		now.After(session.Deadline.Add(examGracePeriod))
+}
+
+// expireOverdueExam 考试已超时则自动交卷并刷新会话，返回是否发生了自动交卷
+func expireOverdueExam(db *gorm.DB, session *models.ExamSession) bool {
+	if !isExamOverdue(session, time.Now()) {
+		return false
+	}
+	if err := finishExamSession(db, session.ID, session.UserID, true); err != nil {
+		log.Printf("Failed to auto-submit exam %d: %v", session.ID, err)
+		return false
+	}
+	db.Where("id = ?", session.ID).First(session)
+	return true
+}
+
+// examRemainingSeconds 剩余作答时间，不限时返回nil
+func examRemainingSeconds(session *models.ExamSession) *int64 {
+	if session.Deadline == nil {
+		return nil
+	}
+	remaining := int64(time.Until(*session.Deadline).Seconds())
+	if remaining < 0 || session.Status != models.ExamStatusInProgress {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// finishExamSession 交卷并判分，写入答题记录和错题本
+// 通过状态条件更新抢占交卷，手动交卷和后台自动交卷并发时只会判分一次
+func finishExamSession(db *gorm.DB, sessionID, userID uint, autoSubmit bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 先锁定考生的用户行，与开始考试、练习作答的加锁顺序（用户、考试、作答状态）一致，避免死锁
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", userID).First(&models.User{}).Error; err != nil {
+			return err
+		}
+
+		claimed := tx.Model(&models.ExamSession{}).
+			Where("id = ? AND status = ?", sessionID, models.ExamStatusInProgress).
+			Update("status", models.ExamStatusSubmitted)
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			return nil
+		}
+
+		var session models.ExamSession
+		if err := tx.Where("id = ?", sessionID).First(&session).Error; err != nil {
+			return err
+		}
+		var answers []models.ExamAnswer
+		if err := tx.Preload("Question").Where("session_id = ?", sessionID).Find(&answers).Error; err != nil {
+			return err
+		}
+
+		quizSettings := getQuizSettings(tx)
+
+		score := 0.0
+		correctCount := 0
+		for _, answer := range answers {
+			input := answerInputFromStorage(answer.UserAnswer, answer.AnswerText)
+			isCorrect, earned := false, 0.0
+			if !input.IsEmpty() && answer.Question != nil {
+				if grade, err := gradeAnswer(answer.Question, input, quizSettings.PartialCredit); err == nil {
+					isCorrect = grade.IsCorrect
+					earned = answer.Score * grade.Credit
+				}
+			}
+			if isCorrect {
+				correctCount++
+			}
+			score += earned
+
+			if err := tx.Model(&models.ExamAnswer{}).Where("id = ?", answer.ID).Updates(map[string]interface{}{
+				"is_correct":   isCorrect,
+				"earned_score": earned,
+			}).Error; err != nil {
+				return err
+			}
+
+			// 已作答的题目计入答题记录，答错的加入错题本
+			if !input.IsEmpty() && answer.Question != nil {
+				userAnswer, answerText := input.storage()
+				if _, err := recordAnswerAttempt(tx, answerAttempt{
+					UserID:     session.UserID,
+					QuestionID: answer.QuestionID,
+					UserAnswer: userAnswer,
+					AnswerText: answerText,
+					IsCorrect:  isCorrect,
+					Mode:       models.AnswerModeExam,
+					SessionID:  &session.ID,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		// 超时自动交卷按截止时间计算用时
+		submittedAt := time.Now()
+		if autoSubmit && session.Deadline != nil && session.Deadline.Before(submittedAt) {
+			submittedAt = *session.Deadline
+		}
+
+		return tx.Model(&models.ExamSession{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+			"submitted_at":   submittedAt,
+			"auto_submitted": autoSubmit,
+			"time_spent":     int(submittedAt.Sub(session.StartedAt).Seconds()),
+			"score":          score,
+			"passed":         score >= session.PassScore,
+			"correct_count":  correctCount,
+		}).Error
+	})
+}
+
+// isExamAnswered 判断考试中的题目是否已作答
+func isExamAnswered(answer *models.ExamAnswer) bool {
+	return answer.UserAnswer != nil || answer.AnswerText != ""
+}
+
+// buildExamSessionView 构建下发给用户的考试详情，交卷前不包含答案
+func buildExamSessionView(db *gorm.DB, session *models.ExamSession) (*ExamSessionView, error) {
+	var paper models.Paper
+	db.Where("id = ?", session.PaperID).First(&paper)
+
+	var answers []models.ExamAnswer
+	if err := db.Preload("Question").Where("session_id = ?", session.ID).
+		Order("section_sort ASC, sort ASC").Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	view := &ExamSessionView{
+		ID:               session.ID,
+		PaperID:          session.PaperID,
+		PaperTitle:       paper.Title,
+		Status:           session.Status,
+		StartedAt:        session.StartedAt,
+		Deadline:         session.Deadline,
+		RemainingSeconds: examRemainingSeconds(session),
+		TotalScore:       session.TotalScore,
+		PassScore:        session.PassScore,
+		QuestionCount:    len(answers),
+		Sections:         []ExamSectionView{},
+	}
+
+	for _, answer := range answers {
+		if len(view.Sections) == 0 || view.Sections[len(view.Sections)-1].Title != answer.SectionTitle {
+			view.Sections = append(view.Sections, ExamSectionView{Title: answer.SectionTitle, Questions: []ExamQuestionView{}})
+		}
+		question := ExamQuestionView{
+			QuestionID: answer.QuestionID,
+			Sort:       answer.Sort,
+			Score:      answer.Score,
+		}
+		if isExamAnswered(&answer) {
+			input := answerInputFromStorage(answer.UserAnswer, answer.AnswerText)
+			question.UserAnswer = &input
+			view.AnsweredCount++
+		}
+		if answer.Question != nil {
+			question.Title = answer.Question.Title
+			question.Content = answer.Question.Content
+			question.Type = answer.Question.Type
+			question.Options = answer.Question.Options
+			question.Difficulty = answer.Question.Difficulty
+			if answer.Question.Type == "fill" {
+				question.BlankCount = len(fillAcceptedAnswers(answer.Question))
+			}
+		}
+		current := &view.Sections[len(view.Sections)-1]
+		current.Questions = append(current.Questions, question)
+	}
+
+	if session.Status == models.ExamStatusSubmitted {
+		result, err := buildExamResult(db, session)
+		if err != nil {
+			return nil, err
+		}
+		view.Result = result
+	}
+
+	return view, nil
+}
+
+// buildExamResult 构建考试成绩，包含分节得分和在同一试卷所有交卷记录中的排名
+func buildExamResult(db *gorm.DB, session *models.ExamSession) (*ExamResult, error) {
+	var paper models.Paper
+	db.Where("id = ?", session.PaperID).First(&paper)
+
+	var answers []models.ExamAnswer
+	if err := db.Preload("Question").Where("session_id = ?", session.ID).
+		Order("section_sort ASC, sort ASC").Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ExamResult{
+		SessionID:     session.ID,
+		PaperID:       session.PaperID,
+		PaperTitle:    paper.Title,
+		Score:         session.Score,
+		TotalScore:    session.TotalScore,
+		PassScore:     session.PassScore,
+		Passed:        session.Passed,
+		QuestionCount: session.QuestionCount,
+		CorrectCount:  session.CorrectCount,
+		TimeSpent:     session.TimeSpent,
+		AutoSubmitted: session.AutoSubmitted,
+		SubmittedAt:   session.SubmittedAt,
+		Sections:      []ExamSectionResult{},
+		Answers:       answers,
+	}
+
+	sectionIndex := make(map[int]int)
+	for _, answer := range answers {
+		idx, found := sectionIndex[answer.SectionSort]
+		if !found {
+			idx = len(result.Sections)
+			sectionIndex[answer.SectionSort] = idx
+			result.Sections = append(result.Sections, ExamSectionResult{Title: answer.SectionTitle})
+		}
+		section := &result.Sections[idx]
+		section.QuestionCount++
+		section.TotalScore += answer.Score
+		section.Score += answer.EarnedScore
+		if answer.IsCorrect {
+			section.CorrectCount++
+		}
+	}
+
+	// 排名：同一试卷中得分更高的交卷记录数加一
+	var higher int64
+	db.Model(&models.ExamSession{}).
+		Where("paper_id = ? AND status = ? AND score > ?", session.PaperID, models.ExamStatusSubmitted, session.Score).
+		Count(&higher)
+	db.Model(&models.ExamSession{}).
+		Where("paper_id = ? AND status = ?", session.PaperID, models.ExamStatusSubmitted).
+		Count(&result.Participants)
+	result.Rank = higher + 1
+
+	return result, nil
+}
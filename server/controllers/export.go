@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/xlsx"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 统计报表类型
+const (
+	StatisticsReportAll       = "all"
+	StatisticsReportOverview  = "overview"
+	StatisticsReportQuestions = "questions"
+	StatisticsReportUsers     = "users"
+	StatisticsReportDaily     = "daily"
+)
+
+// 导出格式
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// 流式导出时每写入多少行刷新一次响应
+const exportFlushRows = 500
+
+// reportWriter 报表写入器，每个报表为一张表，CSV 中依次输出，XLSX 中各占一个工作表
+type reportWriter interface {
+	BeginTable(title string, header ...string) error
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// csvReportWriter CSV 报表写入器，导出多个报表时以空行分隔，并在表头前输出报表标题
+type csvReportWriter struct {
+	c      *gin.Context
+	csv    *csv.Writer
+	multi  bool
+	tables int
+	rows   int
+}
+
+func newCSVReportWriter(c *gin.Context, multi bool) *csvReportWriter {
+	// 写入 BOM，Excel 打开时按 UTF-8 识别中文
+	c.Writer.WriteString("\ufeff")
+	return &csvReportWriter{c: c, csv: csv.NewWriter(c.Writer), multi: multi}
+}
+
+func (w *csvReportWriter) BeginTable(title string, header ...string) error {
+	if w.tables > 0 {
+		if err := w.csv.Write(nil); err != nil {
+			return err
+		}
+	}
+	w.tables++
+	if w.multi {
+		if err := w.csv.Write([]string{title}); err != nil {
+			return err
+		}
+	}
+	return w.csv.Write(header)
+}
+
+func (w *csvReportWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatReportValue(value)
+	}
+	if err := w.csv.Write(record); err != nil {
+		return err
+	}
+	w.rows++
+	if w.rows%exportFlushRows == 0 {
+		w.csv.Flush()
+		w.c.Writer.Flush()
+	}
+	return w.csv.Error()
+}
+
+func (w *csvReportWriter) Close() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// xlsxReportWriter XLSX 报表写入器
+type xlsxReportWriter struct {
+	c     *gin.Context
+	xlsx  *xlsx.Writer
+	sheet *xlsx.Sheet
+}
+
+func newXLSXReportWriter(c *gin.Context) *xlsxReportWriter {
+	return &xlsxReportWriter{c: c, xlsx: xlsx.NewWriter(c.Writer)}
+}
+
+func (w *xlsxReportWriter) BeginTable(title string, header ...string) error {
+	sheet, err := w.xlsx.NewSheet(title)
+	if err != nil {
+		return err
+	}
+	w.sheet = sheet
+	values := make([]interface{}, len(header))
+	for i, name := range header {
+		values[i] = name
+	}
+	return sheet.WriteRow(values...)
+}
+
+func (w *xlsxReportWriter) WriteRow(values ...interface{}) error {
+	if err := w.sheet.WriteRow(values...); err != nil {
+		return err
+	}
+	if w.sheet.Rows()%exportFlushRows == 0 {
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+func (w *xlsxReportWriter) Close() error {
+	return w.xlsx.Close()
+}
+
+// formatReportValue 把单元格的值格式化为 CSV 文本
+func formatReportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatStatisticsTime 把查询出的时间字符串统一格式化为业务时区的 2006-01-02 15:04:05
+func formatStatisticsTime(value string) string {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(config.Location()).Format("2006-01-02 15:04:05")
+	}
+	return value
+}
+
+// writeOverviewReport 概览统计
+func writeOverviewReport(w reportWriter, db *gorm.DB) error {
+	stats := overviewStatistics(db)
+	if err := w.BeginTable("概览", "指标", "数值"); err != nil {
+		return err
+	}
+	rows := []struct {
+		name  string
+		value int64
+	}{
+		{"总用户数", stats.TotalUsers},
+		{"总题目数", stats.TotalQuestions},
+		{"总分类数", stats.TotalCategories},
+		{"总答题数", stats.TotalAnswers},
+		{"今日新增用户", stats.TodayUsers},
+		{"今日答题数", stats.TodayAnswers},
+		{"本周新增用户", stats.WeekUsers},
+		{"本周答题数", stats.WeekAnswers},
+		{"本月新增用户", stats.MonthUsers},
+		{"本月答题数", stats.MonthAnswers},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row.name, row.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeQuestionReport 题目统计，逐行读取查询结果写出
+func writeQuestionReport(w reportWriter, db *gorm.DB, c *gin.Context) error {
+	if err := w.BeginTable("题目统计", "题目ID", "题目", "分类", "难度", "答题数", "答对数", "正确率(%)"); err != nil {
+		return err
+	}
+
+	query, args := questionStatisticsQuery(c)
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat QuestionStatistics
+		if err := db.ScanRows(rows, &stat); err != nil {
+			return err
+		}
+		if err := w.WriteRow(stat.QuestionID, stat.QuestionTitle, stat.CategoryName, stat.Difficulty,
+			stat.TotalAnswered, stat.CorrectAnswered, stat.AccuracyRate); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeUserReport 用户统计，逐行读取查询结果写出
+func writeUserReport(w reportWriter, db *gorm.DB, c *gin.Context) error {
+	if err := w.BeginTable("用户统计", "用户ID", "昵称", "答题数", "答对数", "正确率(%)", "答题用时(秒)", "最后活跃时间"); err != nil {
+		return err
+	}
+
+	rows, err := db.Raw(userStatisticsQuery(c)).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat UserStatistics
+		if err := db.ScanRows(rows, &stat); err != nil {
+			return err
+		}
+		if err := w.WriteRow(stat.UserID, stat.Nickname, stat.TotalAnswered, stat.CorrectAnswered,
+			stat.AccuracyRate, stat.TotalTimeSpent, formatStatisticsTime(stat.LastActiveTime)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeDailyReport 每日统计
+func writeDailyReport(w reportWriter, db *gorm.DB, start, end time.Time) error {
+	userStats, answerStats, err := dailyStatsSeries(db, start, end)
+	if err != nil {
+		return err
+	}
+	if err := w.BeginTable("每日统计", "日期", "新增用户", "活跃用户", "答题数", "答对数", "正确率(%)"); err != nil {
+		return err
+	}
+	for i := range userStats {
+		correctRate, _ := strconv.ParseFloat(answerStats[i].CorrectRate, 64)
+		if err := w.WriteRow(userStats[i].Date, userStats[i].NewUsers, userStats[i].ActiveUsers,
+			answerStats[i].TotalAnswers, answerStats[i].CorrectAnswers, correctRate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 导出统计报表
+ * format 为 csv 或 xlsx（默认），report 为 overview/questions/users/daily/all（默认）
+ * 题目统计和用户统计的筛选、排序参数与对应的统计接口相同，每日统计的日期范围参数与系统统计相同
+ */
+func ExportStatistics(c *gin.Context) {
+	format := c.DefaultQuery("format", ExportFormatXLSX)
+	if format != ExportFormatCSV && format != ExportFormatXLSX {
+		ErrorResponse(c, http.StatusBadRequest, "导出格式无效")
+		return
+	}
+
+	report := c.DefaultQuery("report", StatisticsReportAll)
+	reports := []string{report}
+	switch report {
+	case StatisticsReportAll:
+		reports = []string{StatisticsReportOverview, StatisticsReportQuestions, StatisticsReportUsers, StatisticsReportDaily}
+	case StatisticsReportOverview, StatisticsReportQuestions, StatisticsReportUsers, StatisticsReportDaily:
+	default:
+		ErrorResponse(c, http.StatusBadRequest, "报表类型无效")
+		return
+	}
+
+	start, end, err := parseStatsDateRange(c)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 开始写出后无法再返回错误响应，出错时记录日志并中止，客户端收到的文件不完整
+	filename := fmt.Sprintf("statistics-%s-%s.%s", report, time.Now().In(config.Location()).Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	var w reportWriter
+	if format == ExportFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w = newCSVReportWriter(c, len(reports) > 1)
+	} else {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(http.StatusOK)
+		w = newXLSXReportWriter(c)
+	}
+
+	db := config.GetDB()
+	for _, name := range reports {
+		switch name {
+		case StatisticsReportOverview:
+			err = writeOverviewReport(w, db)
+		case StatisticsReportQuestions:
+			err = writeQuestionReport(w, db, c)
+		case StatisticsReportUsers:
+			err = writeUserReport(w, db, c)
+		case StatisticsReportDaily:
+			err = writeDailyReport(w, db, start, end)
+		}
+		if err != nil {
+			log.Printf("导出统计报表失败: %v", err)
+			c.Abort()
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("导出统计报表失败: %v", err)
+		c.Abort()
+		return
+	}
+
+	LogOperation(c, "EXPORT", "STATISTICS", fmt.Sprintf("导出统计报表：%s（%s）", report, format))
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"qaminiprogram/models"
+	"strings"
+	"unicode"
+)
+
+// AnswerInput 用户提交的答案
+// 单选题、判断题为选项索引，多选题为选项位掩码，填空题为字符串或按空的顺序排列的字符串数组
+type AnswerInput struct {
+	Choice *int
+	Blanks []string
+}
+
+// UnmarshalJSON 同时接受数字、字符串和字符串数组
+func (a *AnswerInput) UnmarshalJSON(data []byte) error {
+	*a = AnswerInput{}
+
+	var choice int
+	if err := json.Unmarshal(data, &choice); err == nil {
+		a.Choice = &choice
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		a.Blanks = splitFillAnswer(text)
+		return nil
+	}
+
+	var blanks []string
+	if err := json.Unmarshal(data, &blanks); err == nil {
+		a.Blanks = blanks
+		return nil
+	}
+
+	return fmt.Errorf("答案格式无效")
+}
+
+// MarshalJSON 按作答类型输出数字或字符串数组
+func (a AnswerInput) MarshalJSON() ([]byte, error) {
+	if a.Choice != nil {
+		return json.Marshal(*a.Choice)
+	}
+	if a.Blanks != nil {
+		return json.Marshal(a.Blanks)
+	}
+	return []byte("null"), nil
+}
+
+// IsEmpty 是否未作答
+func (a AnswerInput) IsEmpty() bool {
+	return a.Choice == nil && a.Blanks == nil
+}
+
+// storage 转换为存储格式：选项索引/位掩码，以及填空答案的JSON
+func (a AnswerInput) storage() (int, string) {
+	if a.Choice != nil {
+		return *a.Choice, ""
+	}
+	if a.Blanks == nil {
+		return 0, ""
+	}
+	text, _ := json.Marshal(a.Blanks)
+	return 0, string(text)
+}
+
+// answerInputFromStorage 从存储格式还原作答
+func answerInputFromStorage(choice *int, answerText string) AnswerInput {
+	if answerText != "" {
+		var blanks []string
+		if err := json.Unmarshal([]byte(answerText), &blanks); err == nil {
+			return AnswerInput{Blanks: blanks}
+		}
+	}
+	return AnswerInput{Choice: choice}
+}
+
+// BlankResult 单个填空的判分结果
+type BlankResult struct {
+	Index     int    `json:"index"`
+	Answer    string `json:"answer"`
+	IsCorrect bool   `json:"isCorrect"`
+}
+
+// GradeResult 判分结果，Credit为得分比例（0~1）
+type GradeResult struct {
+	IsCorrect bool          `json:"isCorrect"`
+	Credit    float64       `json:"credit"`
+	Blanks    []BlankResult `json:"blanks,omitempty"`
+}
+
+// gradeAnswer 按题型判分，答案格式与题型不符时返回错误
+// partialCredit 开启时多选题少选（未选错）按选对的比例得分
+func gradeAnswer(question *models.Question, answer AnswerInput, partialCredit bool) (*GradeResult, error) {
+	switch question.Type {
+	case "fill":
+		if answer.Blanks == nil {
+			return nil, fmt.Errorf("填空题答案应为文本")
+		}
+		return gradeFillAnswer(fillAcceptedAnswers(question), answer.Blanks), nil
+
+	case "multiple":
+		if answer.Choice == nil {
+			return nil, fmt.Errorf("多选题答案应为选项位掩码")
+		}
+		mask := *answer.Choice
+		if mask <= 0 || mask >= 1<<len(question.Options) {
+			return nil, fmt.Errorf("多选题答案无效")
+		}
+
+		correct := question.CorrectAnswer
+		if mask == correct {
+			return &GradeResult{IsCorrect: true, Credit: 1}, nil
+		}
+		result := &GradeResult{}
+		if partialCredit && mask&^correct == 0 && correct != 0 {
+			result.Credit = float64(bits.OnesCount(uint(mask))) / float64(bits.OnesCount(uint(correct)))
+		}
+		return result, nil
+
+	default:
+		// 单选题和判断题
+		if answer.Choice == nil {
+			return nil, fmt.Errorf("答案应为选项索引")
+		}
+		if *answer.Choice < 0 || *answer.Choice >= len(question.Options) {
+			return nil, fmt.Errorf("答案索引无效")
+		}
+		if *answer.Choice == question.CorrectAnswer {
+			return &GradeResult{IsCorrect: true, Credit: 1}, nil
+		}
+		return &GradeResult{}, nil
+	}
+}
+
+// gradeFillAnswer 逐空比对，每个空只要与任一可接受答案归一化后相同即判为正确
+func gradeFillAnswer(accepted [][]string, blanks []string) *GradeResult {
+	result := &GradeResult{IsCorrect: len(accepted) > 0, Blanks: make([]BlankResult, 0, len(accepted))}
+
+	for i, alternatives := range accepted {
+		blank := BlankResult{Index: i}
+		if i < len(blanks) {
+			blank.Answer = blanks[i]
+			normalized := normalizeFillAnswer(blanks[i])
+			for _, alternative := range alternatives {
+				if normalized != "" && normalized == normalizeFillAnswer(alternative) {
+					blank.IsCorrect = true
+					break
+				}
+			}
+		}
+		if !blank.IsCorrect {
+			result.IsCorrect = false
+		}
+		result.Blanks = append(result.Blanks, blank)
+	}
+
+	if result.IsCorrect {
+		result.Credit = 1
+	}
+	return result
+}
+
+// fillAcceptedAnswers 填空题每个空的可接受答案
+func fillAcceptedAnswers(question *models.Question) [][]string {
+	return question.FillAnswers
+}
+
+// parseFillAnswers 解析文本格式的填空答案，空之间用分号分隔，同一空的多个答案用竖线分隔
+// 如 "北京|北京市；长江" 表示两个空，第一个空接受"北京"或"北京市"
+func parseFillAnswers(text string) (models.FillAnswers, error) {
+	var accepted [][]string
+	for _, blank := range splitFillAnswer(text) {
+		alternatives := strings.FieldsFunc(blank, func(r rune) bool { return r == '|' || r == '｜' })
+		accepted = append(accepted, alternatives)
+	}
+	return cleanFillAnswers(accepted)
+}
+
+// formatFillAnswers 把填空答案格式化为 parseFillAnswers 可解析的文本
+func formatFillAnswers(answers models.FillAnswers) string {
+	blanks := make([]string, 0, len(answers))
+	for _, alternatives := range answers {
+		blanks = append(blanks, strings.Join(alternatives, "|"))
+	}
+	return strings.Join(blanks, ";")
+}
+
+// cleanFillAnswers 去除空白答案，任一空没有可接受答案时返回错误
+func cleanFillAnswers(answers [][]string) (models.FillAnswers, error) {
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("填空题至少需要一个空的答案")
+	}
+
+	cleaned := make(models.FillAnswers, 0, len(answers))
+	for i, alternatives := range answers {
+		var blank []string
+		for _, alternative := range alternatives {
+			if alternative = strings.TrimSpace(alternative); alternative != "" {
+				blank = append(blank, alternative)
+			}
+		}
+		if len(blank) == 0 {
+			return nil, fmt.Errorf("第%d个空没有答案", i+1)
+		}
+		cleaned = append(cleaned, blank)
+	}
+	return cleaned, nil
+}
+
+// splitFillAnswer 按分号拆分多个空的答案
+func splitFillAnswer(text string) []string {
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '；' })
+	blanks := make([]string, 0, len(parts))
+	for _, part := range parts {
+		blanks = append(blanks, strings.TrimSpace(part))
+	}
+	return blanks
+}
+
+// normalizeFillAnswer 归一化填空答案：全角转半角、忽略大小写、合并空白
+func normalizeFillAnswer(text string) string {
+	var builder strings.Builder
+	lastSpace := false
+	for _, r := range text {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+
+		if unicode.IsSpace(r) {
+			if !lastSpace {
+				builder.WriteRune(' ')
+			}
+			lastSpace = true
+			continue
+		}
+		lastSpace = false
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return strings.TrimSpace(builder.String())
+}
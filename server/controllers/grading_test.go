@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"encoding/json"
+	"qaminiprogram/models"
+	"reflect"
+	"testing"
+)
+
+func choice(i int) AnswerInput {
+	return AnswerInput{Choice: &i}
+}
+
+func TestGradeAnswer(t *testing.T) {
+	single := &models.Question{Type: "single", Options: models.JSONArray{"A", "B", "C", "D"}, CorrectAnswer: 2}
+	judge := &models.Question{Type: "judge", Options: models.JSONArray{"正确", "错误"}, CorrectAnswer: 1}
+	// 正确答案为 A、C
+	multiple := &models.Question{Type: "multiple", Options: models.JSONArray{"A", "B", "C", "D"}, CorrectAnswer: 0b0101}
+	fill := &models.Question{Type: "fill", FillAnswers: models.FillAnswers{{"北京", "北京市"}, {"长江"}}}
+
+	tests := []struct {
+		name          string
+		question      *models.Question
+		answer        AnswerInput
+		partialCredit bool
+		wantCorrect   bool
+		wantCredit    float64
+		wantErr       bool
+	}{
+		{name: "单选正确", question: single, answer: choice(2), wantCorrect: true, wantCredit: 1},
+		{name: "单选错误", question: single, answer: choice(1)},
+		{name: "单选越界", question: single, answer: choice(4), wantErr: true},
+		{name: "单选负数", question: single, answer: choice(-1), wantErr: true},
+		{name: "单选提交文本", question: single, answer: AnswerInput{Blanks: []string{"C"}}, wantErr: true},
+		{name: "判断正确", question: judge, answer: choice(1), wantCorrect: true, wantCredit: 1},
+		{name: "判断错误", question: judge, answer: choice(0)},
+		{name: "判断越界", question: judge, answer: choice(2), wantErr: true},
+		{name: "多选全对", question: multiple, answer: choice(0b0101), wantCorrect: true, wantCredit: 1},
+		{name: "多选少选不计分", question: multiple, answer: choice(0b0001)},
+		{name: "多选少选部分得分", question: multiple, answer: choice(0b0001), partialCredit: true, wantCredit: 0.5},
+		{name: "多选选错不得分", question: multiple, answer: choice(0b0011), partialCredit: true},
+		{name: "多选未选", question: multiple, answer: choice(0), wantErr: true},
+		{name: "多选超出选项", question: multiple, answer: choice(0b10000), wantErr: true},
+		{name: "多选提交文本", question: multiple, answer: AnswerInput{Blanks: []string{"AC"}}, wantErr: true},
+		{name: "填空全对", question: fill, answer: AnswerInput{Blanks: []string{"北京市", "长江"}}, wantCorrect: true, wantCredit: 1},
+		{name: "填空一空错误", question: fill, answer: AnswerInput{Blanks: []string{" 北京 ", "黄河"}}},
+		{name: "填空缺空", question: fill, answer: AnswerInput{Blanks: []string{"北京"}}},
+		{name: "填空提交选项", question: fill, answer: choice(0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := gradeAnswer(tt.question, tt.answer, tt.partialCredit)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("gradeAnswer() = %+v, want error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gradeAnswer() error = %v", err)
+			}
+			if result.IsCorrect != tt.wantCorrect || result.Credit != tt.wantCredit {
+				t.Errorf("gradeAnswer() = {IsCorrect: %v, Credit: %v}, want {IsCorrect: %v, Credit: %v}",
+					result.IsCorrect, result.Credit, tt.wantCorrect, tt.wantCredit)
+			}
+		})
+	}
+}
+
+func TestGradeFillAnswerBlanks(t *testing.T) {
+	accepted := [][]string{{"北京", "北京市"}, {"长江"}}
+	result := gradeFillAnswer(accepted, []string{"ＢＥＩＪＩＮＧ", "长江", "多余"})
+	want := []BlankResult{
+		{Index: 0, Answer: "ＢＥＩＪＩＮＧ"},
+		{Index: 1, Answer: "长江", IsCorrect: true},
+	}
+	if !reflect.DeepEqual(result.Blanks, want) {
+		t.Errorf("Blanks = %+v, want %+v", result.Blanks, want)
+	}
+	if result.IsCorrect || result.Credit != 0 {
+		t.Errorf("IsCorrect = %v, Credit = %v, want false, 0", result.IsCorrect, result.Credit)
+	}
+
+	// 空答案不能匹配任何可接受答案，没有空的题目不算答对
+	if got := gradeFillAnswer([][]string{{""}}, []string{""}); got.IsCorrect {
+		t.Errorf("empty blank graded as correct")
+	}
+	if got := gradeFillAnswer(nil, []string{"x"}); got.IsCorrect {
+		t.Errorf("question without blanks graded as correct")
+	}
+}
+
+func TestNormalizeFillAnswer(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"北京", "北京"},
+		{"  Hello   World ", "hello world"},
+		{"ＡＢＣ１２３", "abc123"},
+		{"全角　空格", "全角 空格"},
+		{"（括号）", "(括号)"},
+		{"a\t\nb", "a b"},
+		{"　", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeFillAnswer(tt.in); got != tt.want {
+			t.Errorf("normalizeFillAnswer(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFillAnswers(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    models.FillAnswers
+		wantErr bool
+	}{
+		{in: "北京", want: models.FillAnswers{{"北京"}}},
+		{in: "北京|北京市；长江", want: models.FillAnswers{{"北京", "北京市"}, {"长江"}}},
+		{in: "a｜b;c", want: models.FillAnswers{{"a", "b"}, {"c"}}},
+		{in: " a | | b ; c ", want: models.FillAnswers{{"a", "b"}, {"c"}}},
+		{in: "a;;b", want: models.FillAnswers{{"a"}, {"b"}}},
+		{in: "", wantErr: true},
+		{in: "a;|", wantErr: true},
+		{in: "a; ;b", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFillAnswers(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseFillAnswers(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFillAnswers(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFillAnswers(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		if back, _ := parseFillAnswers(formatFillAnswers(got)); !reflect.DeepEqual(back, got) {
+			t.Errorf("formatFillAnswers(%v) does not round-trip: %v", got, back)
+		}
+	}
+}
+
+func TestAnswerInputJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     AnswerInput
+		wantJSON string
+		wantErr  bool
+	}{
+		{in: `2`, want: choice(2), wantJSON: `2`},
+		{in: `"北京；长江"`, want: AnswerInput{Blanks: []string{"北京", "长江"}}, wantJSON: `["北京","长江"]`},
+		{in: `["a","b"]`, want: AnswerInput{Blanks: []string{"a", "b"}}, wantJSON: `["a","b"]`},
+		{in: `{"x":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got AnswerInput
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %+v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if data, _ := json.Marshal(got); string(data) != tt.wantJSON {
+			t.Errorf("Marshal(%+v) = %s, want %s", got, data, tt.wantJSON)
+		}
+
+		stored, text := got.storage()
+		if restored := answerInputFromStorage(&stored, text); !reflect.DeepEqual(restored, got) {
+			t.Errorf("storage round-trip of %s = %+v, want %+v", tt.in, restored, got)
+		}
+	}
+}
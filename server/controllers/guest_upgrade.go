@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// UpgradeGuestRequest 游客升级请求
+type UpgradeGuestRequest struct {
+	Type     string `json:"type" binding:"required,oneof=wechat password"`
+	Code     string `json:"code"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+// MergeStats 合并游客数据的统计
+type MergeStats struct {
+	AnswersMoved    int64 `json:"answersMoved"`
+	MistakesMoved   int64 `json:"mistakesMoved"`
+	MistakesDropped int64 `json:"mistakesDropped"`
+}
+
+// UpgradeGuestResponse 游客升级响应
+type UpgradeGuestResponse struct {
+	WechatLoginResponse
+	Merged bool        `json:"merged"`
+	Stats  *MergeStats `json:"stats,omitempty"`
+}
+
+// UpgradeGuest 将当前游客账号升级为微信或密码账号
+// 目标账号不存在时直接转换游客账号；已存在时把游客的答题记录、错题本合并到目标账号并删除游客账号
+func UpgradeGuest(c *gin.Context) {
+	guestID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	var req UpgradeGuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	db := config.GetDB()
+	var guest models.User
+	if err := db.Where("id = ?", guestID).First(&guest).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	if !guest.IsGuest {
+		ErrorResponse(c, http.StatusBadRequest, "当前账号不是游客账号")
+		return
+	}
+
+	var target models.User
+	var targetExists bool
+
+	switch req.Type {
+	case "wechat":
+		if req.Code == "" {
+			ErrorResponse(c, http.StatusBadRequest, "缺少微信登录code")
+			return
+		}
+		session, err := getWechatSessionClient().Code2Session(c.Request.Context(), req.Code)
+		if err != nil {
+			ErrorResponse(c, http.StatusBadRequest, "微信登录失败："+err.Error())
+			return
+		}
+
+		if err := db.Where("open_id = ?", session.OpenID).First(&target).Error; err == nil {
+			targetExists = true
+			target.SessionKey = session.SessionKey
+			if session.UnionID != "" {
+				target.UnionID = session.UnionID
+			}
+		} else {
+			target = guest
+			target.OpenID = session.OpenID
+			target.UnionID = session.UnionID
+			target.SessionKey = session.SessionKey
+			if target.Nickname == "" || strings.HasPrefix(target.Nickname, "游客") {
+				target.Nickname = "微信用户" + generateRandomString(6)
+			}
+			target.Username = ""
+		}
+
+	case "password":
+		if req.Username == "" || req.Password == "" {
+			ErrorResponse(c, http.StatusBadRequest, "用户名和密码不能为空")
+			return
+		}
+
+		if err := db.Where("username = ?", req.Username).First(&target).Error; err == nil {
+			// 绑定已有账号需要验证密码
+			if lockedUntil, locked := getLoginLock(db, models.LoginScopeAccount, req.Username); locked {
+				ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("登录失败次数过多，请于 %s 后重试", lockedUntil.Format("2006-01-02 15:04:05")))
+				return
+			}
+			if target.Password == "" || bcrypt.CompareHashAndPassword([]byte(target.Password), []byte(req.Password)) != nil {
+				recordLoginFailure(db, models.LoginScopeAccount, req.Username)
+				LogOperation(c, "UPGRADE_FAILED", "USER", fmt.Sprintf("游客 %s 绑定账号 %s 密码错误", guest.Username, req.Username))
+				ErrorResponse(c, http.StatusUnauthorized, "用户名或密码错误")
+				return
+			}
+			clearLoginFailures(db, models.LoginScopeAccount, req.Username)
+			targetExists = true
+		} else {
+			if len(req.Password) < 6 {
+				ErrorResponse(c, http.StatusBadRequest, "密码长度不能少于6位")
+				return
+			}
+			hashedPassword, err := HashPassword(req.Password)
+			if err != nil {
+				ErrorResponse(c, http.StatusInternalServerError, "密码加密失败")
+				return
+			}
+			target = guest
+			target.OpenID = "password_" + req.Username
+			target.Username = req.Username
+			target.Password = hashedPassword
+			if req.Email != "" {
+				target.Email = req.Email
+			}
+		}
+	}
+
+	if targetExists && rejectInactiveUser(c, &target) {
+		return
+	}
+
+	var stats *MergeStats
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if !targetExists {
+			// 直接把游客账号转换为正式账号，历史数据保持不变
+			target.IsGuest = false
+			target.Role = models.RoleUser
+			return tx.Save(&target).Error
+		}
+
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+
+		merged, err := mergeUserData(tx, guest.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		stats = merged
+
+		return tx.Delete(&models.User{}, guest.ID).Error
+	})
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "升级账号失败")
+		return
+	}
+
+	// 注销游客的会话，为升级后的账号签发新令牌
+	revokeUserSessions(db, guest.ID, revokeReasonLogout)
+	db.Where("id = ?", target.ID).First(&target)
+	tokens, err := createUserSession(db, c, &target)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "生成令牌失败")
+		return
+	}
+
+	// 记录操作日志
+	c.Set("userId", target.ID)
+	if targetExists {
+		LogOperation(c, "GUEST_MERGE", "USER", fmt.Sprintf("游客 %s(ID:%d) 合并到账号 %d：迁移答题 %d 条，迁移错题 %d 条、丢弃 %d 条",
+			guest.Username, guest.ID, target.ID, stats.AnswersMoved, stats.MistakesMoved, stats.MistakesDropped))
+	} else {
+		LogOperation(c, "GUEST_UPGRADE", "USER", fmt.Sprintf("游客 %s(ID:%d) 升级为%s账号", guest.Username, guest.ID, req.Type))
+	}
+
+	SuccessResponse(c, UpgradeGuestResponse{
+		WechatLoginResponse: WechatLoginResponse{
+			Token:           tokens.AccessToken,
+			RefreshToken:    tokens.RefreshToken,
+			User:            target,
+			ExpireAt:        tokens.AccessExpireAt.Unix(),
+			RefreshExpireAt: tokens.RefreshExpireAt.Unix(),
+		},
+		Merged: targetExists,
+		Stats:  stats,
+	})
+}
+
+// mergeUserData 把fromID的答题记录和错题本合并到toID，并重算目标账号的答题计数
+// 答题记录全部保留并按作答时间重新编号；错题本中同一道题两边都有时保留较新的那条
+func mergeUserData(tx *gorm.DB, fromID, toID uint) (*MergeStats, error) {
+	stats := &MergeStats{}
+
+	// 先把两边的作答次序置为临时的负数，避免重新编号时与唯一索引冲突
+	moved := tx.Exec("UPDATE answer_records SET user_id = ?, attempt_no = -id WHERE user_id = ?", toID, fromID)
+	if moved.Error != nil {
+		return nil, moved.Error
+	}
+	stats.AnswersMoved = moved.RowsAffected
+	if err := tx.Exec("UPDATE answer_records SET attempt_no = -id WHERE user_id = ? AND attempt_no > 0", toID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec(`
+		UPDATE answer_records r
+		JOIN (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY question_id ORDER BY answered_at, id) AS attempt_no
+			FROM answer_records WHERE user_id = ?
+		) o ON o.id = r.id
+		SET r.attempt_no = o.attempt_no
+	`, toID).Error; err != nil {
+		return nil, err
+	}
+	if err := rebuildAnswerStates(tx, fromID, toID); err != nil {
+		return nil, err
+	}
+
+	// 目标账号中比游客旧的错题被游客记录覆盖
+	older := tx.Exec(`
+		DELETE t FROM mistake_books t
+		JOIN mistake_books g ON g.question_id = t.question_id AND g.user_id = ?
+		WHERE t.user_id = ? AND g.updated_at > t.updated_at
+	`, fromID, toID)
+	if older.Error != nil {
+		return nil, older.Error
+	}
+
+	// 游客中不比目标账号新的重复错题直接丢弃
+	dropped := tx.Exec(`
+		DELETE g FROM mistake_books g
+		JOIN mistake_books t ON t.question_id = g.question_id AND t.user_id = ?
+		WHERE g.user_id = ?
+	`, toID, fromID)
+	if dropped.Error != nil {
+		return nil, dropped.Error
+	}
+
+	// 保留原有的updated_at，避免迁移本身被当作最近一次更新
+	mistakes := tx.Exec("UPDATE mistake_books SET user_id = ?, updated_at = updated_at WHERE user_id = ?", toID, fromID)
+	if mistakes.Error != nil {
+		return nil, mistakes.Error
+	}
+	stats.MistakesMoved, stats.MistakesDropped = mistakes.RowsAffected, dropped.RowsAffected+older.RowsAffected
+
+	// 考试记录直接迁移
+	if err := tx.Model(&models.ExamSession{}).Where("user_id = ?", fromID).Update("user_id", toID).Error; err != nil {
+		return nil, err
+	}
+
+	// 顺序练习进度：目标账号已有同一分类的进度时保留目标账号的
+	if err := tx.Exec(`
+		DELETE g FROM practice_cursors g
+		JOIN practice_cursors t ON t.category_id = g.category_id AND t.user_id = ?
+		WHERE g.user_id = ?
+	`, toID, fromID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.PracticeCursor{}).Where("user_id = ?", fromID).Update("user_id", toID).Error; err != nil {
+		return nil, err
+	}
+
+	// 积分流水直接迁移，余额累加到目标账号，并重建余额快照和排行
+	if err := tx.Model(&models.PointRecord{}).Where("user_id = ?", fromID).Update("user_id", toID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("UPDATE users t JOIN users g ON g.id = ? SET t.points = t.points + g.points WHERE t.id = ?", fromID, toID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", fromID).Delete(&models.PointLeaderboard{}).Error; err != nil {
+		return nil, err
+	}
+	if err := rebuildUserPoints(tx, toID); err != nil {
+		return nil, err
+	}
+
+	// 重算目标账号的答题计数，题目的计数不受影响
+	if _, err := recomputeUserCounters(tx, toID); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
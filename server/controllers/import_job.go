@@ -0,0 +1,673 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// 导入任务最多包含的题目数
+	importJobMaxRows = 20000
+	// 每批导入的题目数，每批在一个事务中提交
+	importJobBatchSize = 100
+	// 处理任务的租约时长，每处理一批续期一次
+	importJobLease = 30 * time.Second
+	// 查询任务时顺带处理的最长时间，用于 Serverless 环境下后台协程被挂起的情况
+	importJobPollBudget = 5 * time.Second
+	// 进度推送每轮顺带处理的最长时间
+	importJobStreamStep = time.Second
+	// 进度推送连接的最长时间，超时后客户端需重新连接
+	importJobStreamTimeout = 5 * time.Minute
+	// 已结束的任务保留的时长
+	importJobRetention = 30 * 24 * time.Hour
+	// 后台处理任务的默认间隔，可通过 IMPORT_JOB_INTERVAL（秒）调整
+	defaultImportJobInterval = 10 * time.Second
+	// 错误报告中题目内容截取的字符数
+	importJobExcerptLength = 50
+)
+
+// errImportJobStopped 任务已取消或已被其他处理者接手，当前批次放弃提交
+var errImportJobStopped = errors.New("导入任务已停止")
+
+// 导入结果的中文名称，用于错误报告
+var importJobActionLabels = map[string]string{
+	models.ImportRowCreated: "新增",
+	models.ImportRowUpdated: "更新",
+	models.ImportRowSkipped: "跳过",
+	models.ImportRowFailed:  "失败",
+	"":                      "未处理",
+}
+
+// ImportJobResponse 创建导入任务的响应，表格导入时返回识别出的表头和列映射，文本导入时返回被忽略的行
+type ImportJobResponse struct {
+	Job      models.ImportJob     `json:"job"`
+	Headers  []ImportColumn       `json:"headers,omitempty"`
+	Mapping  *ImportColumnMapping `json:"mapping,omitempty"`
+	Warnings []ImportRowError     `json:"warnings,omitempty"`
+}
+
+// ImportJobRowResponse 导入任务中的一行及题目内容摘要
+type ImportJobRowResponse struct {
+	models.ImportJobRow
+	Content string `json:"content"`
+}
+
+// createImportJob 保存导入任务及全部待导入的题目，解析时已出错的行直接记录错误信息
+func createImportJob(db *gorm.DB, source *importSource, options ImportOptions, creatorID uint) (*models.ImportJob, error) {
+	columns, err := json.Marshal(source.Columns)
+	if err != nil {
+		return nil, err
+	}
+	job := models.ImportJob{
+		ID:             uuid.NewString(),
+		Source:         source.Source,
+		Filename:       truncateRunes(source.Filename, 255),
+		Status:         models.ImportJobPending,
+		DryRun:         options.DryRun,
+		SkipDuplicates: options.SkipDuplicates,
+		UpdateExisting: options.UpdateExisting,
+		Columns:        string(columns),
+		Total:          len(source.Rows),
+		CreatorID:      creatorID,
+	}
+
+	rows := make([]models.ImportJobRow, len(source.Rows))
+	for i, row := range source.Rows {
+		item, err := json.Marshal(row.Item)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = models.ImportJobRow{JobID: job.ID, Seq: i + 1, Line: row.Row, Item: string(item)}
+		if row.Err != nil {
+			setImportJobRowError(&rows[i], source.describe(row.Row, row.Err))
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func setImportJobRowError(row *models.ImportJobRow, rowErr ImportRowError) {
+	row.Column = rowErr.Column
+	row.Header = truncateRunes(rowErr.Header, 100)
+	row.Field = rowErr.Field
+	row.Message = truncateRunes(rowErr.Message, 500)
+}
+
+// startImportJob 在后台协程中处理任务，处理者退出后由轮询、进度推送或定时任务接手
+func startImportJob(id string) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("导入任务 %s 异常退出: %v", id, r)
+			}
+		}()
+		if err := runImportJob(config.GetDB(), id, 0); err != nil {
+			log.Printf("导入任务 %s 处理失败: %v", id, err)
+		}
+	}()
+}
+
+// claimImportJob 获取任务的租约，任务已结束或其他处理者的租约未过期时返回 false
+func claimImportJob(db *gorm.DB, id string) (bool, error) {
+	now := time.Now()
+	result := db.Model(&models.ImportJob{}).
+		Where("id = ? AND status IN ? AND (lease_until IS NULL OR lease_until < ?)",
+			id, []string{models.ImportJobPending, models.ImportJobRunning}, now).
+		Updates(map[string]interface{}{
+			"status":      models.ImportJobRunning,
+			"lease_until": now.Add(importJobLease),
+			"started_at":  gorm.Expr("COALESCE(started_at, ?)", now),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// runImportJob 逐批处理任务直到完成、被取消或超出 budget（为 0 时不限时间）
+// 超出时间后释放租约，剩余部分由下一个处理者继续
+func runImportJob(db *gorm.DB, id string, budget time.Duration) error {
+	claimed, err := claimImportJob(db, id)
+	if err != nil || !claimed {
+		return err
+	}
+
+	start := time.Now()
+	var dryRunCreated map[importQuestionKey]bool
+	for {
+		var job models.ImportJob
+		if err := db.First(&job, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if job.Status != models.ImportJobRunning {
+			return nil
+		}
+		if job.DryRun && job.SkipDuplicates && dryRunCreated == nil {
+			if dryRunCreated, err = loadDryRunCreated(db, job.ID); err != nil {
+				return err
+			}
+		}
+
+		done, err := processImportBatch(db, &job, dryRunCreated)
+		if errors.Is(err, errImportJobStopped) {
+			return nil
+		}
+		if err != nil {
+			db.Model(&models.ImportJob{}).Where("id = ? AND status = ?", id, models.ImportJobRunning).
+				Updates(map[string]interface{}{
+					"status":      models.ImportJobFailed,
+					"error":       truncateRunes(fmt.Sprintf("导入出错：%v", err), 500),
+					"lease_until": nil,
+					"finished_at": time.Now(),
+				})
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		if budget > 0 && time.Since(start) >= budget {
+			return db.Model(&models.ImportJob{}).Where("id = ? AND status = ?", id, models.ImportJobRunning).
+				Update("lease_until", nil).Error
+		}
+	}
+}
+
+// loadDryRunCreated 汇总只校验任务中已处理批次“新增”的题目，供后续批次查重，任务换到其他处理者继续时也能接上
+func loadDryRunCreated(db *gorm.DB, jobID string) (map[importQuestionKey]bool, error) {
+	var items []string
+	if err := db.Model(&models.ImportJobRow{}).Where("job_id = ? AND action = ?", jobID, models.ImportRowCreated).
+		Order("seq").Pluck("item", &items).Error; err != nil {
+		return nil, err
+	}
+	created := make(map[importQuestionKey]bool, len(items))
+	for _, raw := range items {
+		var item ImportQuestionItem
+		if err := json.Unmarshal([]byte(raw), &item); err != nil {
+			continue
+		}
+		if question, fieldErr := buildImportedQuestion(item); fieldErr == nil {
+			created[importQuestionKey{question.Content, question.CategoryID}] = true
+		}
+	}
+	return created, nil
+}
+
+// processImportBatch 导入下一批未处理的题目，并在同一事务中记录每行结果和任务进度
+// 只校验时在保存点中导入后回滚，只保留结果，回滚掉的新增题目记在 dryRunCreated 中供后续批次查重；
+// 没有未处理的题目时把任务标记为完成
+func processImportBatch(db *gorm.DB, job *models.ImportJob, dryRunCreated map[importQuestionKey]bool) (bool, error) {
+	var rows []models.ImportJobRow
+	if err := db.Where("job_id = ? AND action = ''", job.ID).Order("seq").
+		Limit(importJobBatchSize).Find(&rows).Error; err != nil {
+		return false, err
+	}
+	if len(rows) == 0 {
+		result := db.Model(&models.ImportJob{}).Where("id = ? AND status = ?", job.ID, models.ImportJobRunning).
+			Updates(map[string]interface{}{
+				"status":      models.ImportJobCompleted,
+				"lease_until": nil,
+				"finished_at": time.Now(),
+			})
+		return true, result.Error
+	}
+
+	var columns map[string]ImportColumn
+	if job.Columns != "" {
+		if err := json.Unmarshal([]byte(job.Columns), &columns); err != nil {
+			return false, err
+		}
+	}
+	options := ImportOptions{SkipDuplicates: job.SkipDuplicates, UpdateExisting: job.UpdateExisting, DryRun: job.DryRun}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if job.DryRun {
+			if err := tx.SavePoint("import_batch").Error; err != nil {
+				return err
+			}
+		}
+		counts := make(map[string]int)
+		importer := newQuestionImporter(tx, options, job.CreatorID)
+		importer.dryRunCreated = dryRunCreated
+		for i := range rows {
+			row := &rows[i]
+			if row.Message != "" {
+				row.Action = models.ImportRowFailed
+			} else {
+				var item ImportQuestionItem
+				action, fieldErr := "", (*importFieldError)(nil)
+				if err := json.Unmarshal([]byte(row.Item), &item); err != nil {
+					fieldErr = fieldError("", "题目数据无效")
+				} else {
+					action, fieldErr = importer.importItem(item)
+				}
+				if fieldErr != nil {
+					row.Action = models.ImportRowFailed
+					setImportJobRowError(row, describeImportError(columns, row.Line, fieldErr))
+				} else {
+					row.Action = action
+				}
+			}
+			counts[row.Action]++
+		}
+		if job.DryRun {
+			if err := tx.RollbackTo("import_batch").Error; err != nil {
+				return err
+			}
+		}
+
+		// 行已被其他处理者处理过时放弃本批，避免重复导入
+		for _, row := range rows {
+			result := tx.Model(&models.ImportJobRow{}).Where("id = ? AND action = ''", row.ID).
+				Updates(map[string]interface{}{
+					"action":      row.Action,
+					"column_name": row.Column,
+					"header":      row.Header,
+					"field":       row.Field,
+					"message":     row.Message,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errImportJobStopped
+			}
+		}
+
+		result := tx.Model(&models.ImportJob{}).Where("id = ? AND status = ?", job.ID, models.ImportJobRunning).
+			Updates(map[string]interface{}{
+				"processed":   gorm.Expr("processed + ?", len(rows)),
+				"created":     gorm.Expr("created + ?", counts[models.ImportRowCreated]),
+				"updated":     gorm.Expr("updated + ?", counts[models.ImportRowUpdated]),
+				"skipped":     gorm.Expr("skipped + ?", counts[models.ImportRowSkipped]),
+				"failed":      gorm.Expr("failed + ?", counts[models.ImportRowFailed]),
+				"lease_until": time.Now().Add(importJobLease),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errImportJobStopped
+		}
+		return nil
+	})
+	return false, err
+}
+
+// StartImportJobWorker 启动后台任务：定时接手租约过期的导入任务，并清理过期的已结束任务
+// Serverless 环境下不会运行，由查询任务和进度推送接口推进
+func StartImportJobWorker() {
+	interval := defaultImportJobInterval
+	if seconds, err := strconv.Atoi(os.Getenv("IMPORT_JOB_INTERVAL")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := resumeImportJobs(config.GetDB()); err != nil {
+				log.Printf("Import job worker failed: %v", err)
+			}
+		}
+	}()
+}
+
+// resumeImportJobs 处理所有未结束且租约已过期的任务，并删除超过保留期的已结束任务
+func resumeImportJobs(db *gorm.DB) error {
+	var ids []string
+	if err := db.Model(&models.ImportJob{}).
+		Where("status IN ? AND (lease_until IS NULL OR lease_until < ?)",
+			[]string{models.ImportJobPending, models.ImportJobRunning}, time.Now()).
+		Order("created_at").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := runImportJob(db, id, 0); err != nil {
+			log.Printf("导入任务 %s 处理失败: %v", id, err)
+		}
+	}
+
+	result := db.Where("finished_at IS NOT NULL AND finished_at < ?", time.Now().Add(-importJobRetention)).
+		Delete(&models.ImportJob{})
+	if result.RowsAffected > 0 {
+		log.Printf("Import job worker removed %d finished jobs", result.RowsAffected)
+	}
+	return result.Error
+}
+
+// loadImportJob 查询任务，任务未结束且无人处理时在 budget 内顺带处理
+func loadImportJob(db *gorm.DB, id string, budget time.Duration) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := db.First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if !job.Finished() && (job.LeaseUntil == nil || job.LeaseUntil.Before(time.Now())) {
+		if err := runImportJob(db, id, budget); err != nil {
+			log.Printf("导入任务 %s 处理失败: %v", id, err)
+		}
+		if err := db.First(&job, "id = ?", id).Error; err != nil {
+			return nil, err
+		}
+	}
+	setImportJobProgress(&job)
+	return &job, nil
+}
+
+// setImportJobProgress 计算已处理的百分比，保留一位小数
+func setImportJobProgress(job *models.ImportJob) {
+	if job.Total == 0 {
+		if job.Finished() {
+			job.Progress = 100
+		}
+		return
+	}
+	job.Progress = math.Round(float64(job.Processed)*1000/float64(job.Total)) / 10
+}
+
+// respondImportJobError 任务不存在时返回 404
+func respondImportJobError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ErrorResponse(c, http.StatusNotFound, "导入任务不存在")
+		return
+	}
+	ErrorResponse(c, http.StatusInternalServerError, "查询导入任务失败")
+}
+
+/**
+ * 创建导入任务（管理员）
+ * JSON 请求体同 ImportQuestions，options.dry_run 为 true 时只校验；
+ * multipart 表单同 ImportQuestionsFromFile（.xlsx/.csv/.json 文件）或 ImportQuestionsFromText（文档或文本）
+ * 任务在后台按批导入，通过 GetImportJob 轮询或 StreamImportJob 订阅进度
+ */
+func CreateImportJob(c *gin.Context) {
+	creatorID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "无法获取用户信息")
+		return
+	}
+
+	var (
+		options ImportOptions
+		source  *importSource
+		err     error
+	)
+	if c.ContentType() == gin.MIMEJSON {
+		var req ImportQuestionsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			ErrorResponse(c, http.StatusBadRequest, "参数错误")
+			return
+		}
+		if len(req.Questions) > importJobMaxRows {
+			ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("一次最多导入%d道题", importJobMaxRows))
+			return
+		}
+		options = req.Options
+		source = &importSource{Source: importSourceJSON, Rows: make([]importRow, len(req.Questions))}
+		for i, item := range req.Questions {
+			source.Rows[i] = importRow{Row: i + 1, Item: item}
+		}
+	} else {
+		if options, err = parseImportForm(c); err != nil {
+			ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if file, fileErr := c.FormFile("file"); fileErr == nil && isSpreadsheetFile(file.Filename) {
+			source, err = readSpreadsheetSource(c, file, importJobMaxRows)
+		} else {
+			source, err = readTextSource(c, importJobMaxRows)
+		}
+		if err != nil {
+			respondImportError(c, err)
+			return
+		}
+	}
+
+	job, err := createImportJob(config.GetDB(), source, options, creatorID)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "创建导入任务失败")
+		return
+	}
+	startImportJob(job.ID)
+
+	if !options.DryRun {
+		LogOperation(c, "IMPORT", "QUESTION", fmt.Sprintf("创建导入任务 %s：%d 道题目", job.ID, job.Total))
+	}
+
+	SuccessResponse(c, ImportJobResponse{Job: *job, Headers: source.Headers, Mapping: source.Mapping, Warnings: source.Warnings})
+}
+
+// GetImportJobs 获取导入任务列表（管理员），可按 status 筛选，creatorId=me 只看自己创建的任务
+func GetImportJobs(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	query := db.Model(&models.ImportJob{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("creatorId") == "me" {
+		userID, _ := GetUserID(c)
+		query = query.Where("creator_id = ?", userID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var jobs []models.ImportJob
+	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&jobs).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "查询导入任务失败")
+		return
+	}
+	for i := range jobs {
+		setImportJobProgress(&jobs[i])
+	}
+
+	PageSuccessResponse(c, jobs, total, page, size)
+}
+
+// GetImportJob 获取导入任务进度（管理员）
+func GetImportJob(c *gin.Context) {
+	job, err := loadImportJob(config.GetDB(), c.Param("id"), importJobPollBudget)
+	if err != nil {
+		respondImportJobError(c, err)
+		return
+	}
+	SuccessResponse(c, job)
+}
+
+/**
+ * 订阅导入任务进度（管理员），以 Server-Sent Events 推送
+ * 进度变化时发送 progress 事件，任务结束时发送 done 事件并关闭连接；连接最长保持 5 分钟，之后需重新订阅
+ */
+func StreamImportJob(c *gin.Context) {
+	db := config.GetDB()
+	id := c.Param("id")
+	job, err := loadImportJob(db, id, 0)
+	if err != nil {
+		respondImportJobError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	timeout := time.After(importJobStreamTimeout)
+	lastProcessed, lastStatus := -1, ""
+	for {
+		if job.Finished() {
+			c.SSEvent("done", job)
+			c.Writer.Flush()
+			return
+		}
+		if job.Processed != lastProcessed || job.Status != lastStatus {
+			lastProcessed, lastStatus = job.Processed, job.Status
+			c.SSEvent("progress", job)
+			c.Writer.Flush()
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-timeout:
+			return
+		case <-ticker.C:
+		}
+		if job, err = loadImportJob(db, id, importJobStreamStep); err != nil {
+			c.SSEvent("error", gin.H{"message": "查询导入任务失败"})
+			c.Writer.Flush()
+			return
+		}
+	}
+}
+
+// CancelImportJob 取消导入任务（管理员），已提交的批次不会回滚
+func CancelImportJob(c *gin.Context) {
+	db := config.GetDB()
+	id := c.Param("id")
+	result := db.Model(&models.ImportJob{}).
+		Where("id = ? AND status IN ?", id, []string{models.ImportJobPending, models.ImportJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.ImportJobCancelled,
+			"lease_until": nil,
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "取消导入任务失败")
+		return
+	}
+
+	job, err := loadImportJob(db, id, 0)
+	if err != nil {
+		respondImportJobError(c, err)
+		return
+	}
+	if result.RowsAffected == 0 {
+		ErrorResponse(c, http.StatusBadRequest, "导入任务已结束")
+		return
+	}
+
+	LogOperation(c, "CANCEL", "IMPORT_JOB", fmt.Sprintf("取消导入任务 %s：已处理 %d/%d 道", job.ID, job.Processed, job.Total))
+
+	SuccessResponse(c, job)
+}
+
+/**
+ * 获取导入任务的逐行结果（管理员）
+ * 默认只返回失败的行，all=true 时返回全部行；format=csv/xlsx 时下载报告，否则分页返回 JSON
+ */
+func GetImportJobErrors(c *gin.Context) {
+	db := config.GetDB()
+	var job models.ImportJob
+	if err := db.First(&job, "id = ?", c.Param("id")).Error; err != nil {
+		respondImportJobError(c, err)
+		return
+	}
+
+	all := c.Query("all") == "true"
+	query := func() *gorm.DB {
+		query := db.Model(&models.ImportJobRow{}).Where("job_id = ?", job.ID)
+		if !all {
+			query = query.Where("action = ?", models.ImportRowFailed)
+		}
+		return query
+	}
+
+	format := c.Query("format")
+	switch format {
+	case "":
+		page, size := ParsePageParams(c)
+		var total int64
+		query().Count(&total)
+
+		var rows []models.ImportJobRow
+		if err := query().Order("seq").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "查询导入结果失败")
+			return
+		}
+		PageSuccessResponse(c, importJobRowResponses(rows), total, page, size)
+		return
+	case ExportFormatCSV, ExportFormatXLSX:
+	default:
+		ErrorResponse(c, http.StatusBadRequest, "导出格式无效")
+		return
+	}
+
+	// 开始写出后无法再返回错误响应，出错时记录日志并中止，客户端收到的文件不完整
+	filename := fmt.Sprintf("import-%s-errors.%s", job.ID, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	var w reportWriter
+	if format == ExportFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w = newCSVReportWriter(c, false)
+	} else {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(http.StatusOK)
+		w = newXLSXReportWriter(c)
+	}
+
+	err := w.BeginTable("导入结果", "行号", "结果", "列", "表头", "字段", "错误信息", "题目内容")
+	lastSeq := 0
+	for err == nil {
+		var rows []models.ImportJobRow
+		if err = query().Where("seq > ?", lastSeq).Order("seq").Limit(exportFlushRows).Find(&rows).Error; err != nil || len(rows) == 0 {
+			break
+		}
+		for _, row := range importJobRowResponses(rows) {
+			if err = w.WriteRow(row.Line, importJobActionLabels[row.Action], row.Column, row.Header,
+				row.Field, row.Message, row.Content); err != nil {
+				break
+			}
+		}
+		lastSeq = rows[len(rows)-1].Seq
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		log.Printf("导出导入结果失败: %v", err)
+		c.Abort()
+	}
+}
+
+// importJobRowResponses 为每行附上题目内容摘要
+func importJobRowResponses(rows []models.ImportJobRow) []ImportJobRowResponse {
+	responses := make([]ImportJobRowResponse, len(rows))
+	for i, row := range rows {
+		var item ImportQuestionItem
+		json.Unmarshal([]byte(row.Item), &item)
+		content := strings.TrimSpace(item.Content)
+		if content == "" {
+			content = strings.TrimSpace(item.Title)
+		}
+		responses[i] = ImportJobRowResponse{ImportJobRow: row, Content: truncateRunes(content, importJobExcerptLength)}
+	}
+	return responses
+}
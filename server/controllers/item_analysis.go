@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 题目分析参数
+const (
+	// 高分组和低分组各占作答人数的比例
+	itemGroupRatio = 0.27
+	// 作答人数少于该值时只标记样本不足，不做其他判断
+	itemMinSample = 20
+	// 难度指数（正确率）高于或低于该值时视为过易或过难
+	itemTooEasyPValue = 0.9
+	itemTooHardPValue = 0.2
+	// 区分度低于该值时视为区分度不足
+	itemLowDiscrimination = 0.2
+)
+
+// 题目分析标记
+const (
+	ItemFlagInsufficientSample     = "insufficient_sample"     // 作答人数不足
+	ItemFlagTooEasy                = "too_easy"                // 过易
+	ItemFlagTooHard                = "too_hard"                // 过难
+	ItemFlagLowDiscrimination      = "low_discrimination"      // 区分度不足
+	ItemFlagNegativeDiscrimination = "negative_discrimination" // 低分组正确率高于高分组
+	ItemFlagUpperGroupMiss         = "upper_group_miss"        // 高分组正确率不足一半
+	ItemFlagUnusedOption           = "unused_option"           // 有干扰项无人选择
+	ItemFlagAttractiveDistractor   = "attractive_distractor"   // 高分组选择某干扰项的比例高于低分组
+)
+
+// itemGroupsQuery 按每位用户第一次作答划分每道题的高分组和低分组
+// 用户总分为其所有题目第一次作答的正确率，每道题的作答者按总分排序，前后各 27% 为高分组和低分组
+const itemGroupsQuery = `
+	WITH scores AS (
+		SELECT user_id, AVG(first_correct) AS score FROM answer_states GROUP BY user_id
+	),
+	ranked AS (
+		SELECT s.question_id, s.user_id, s.first_correct,
+			ROW_NUMBER() OVER (PARTITION BY s.question_id ORDER BY sc.score DESC, s.user_id) AS position,
+			COUNT(*) OVER (PARTITION BY s.question_id) AS answered
+		FROM answer_states s
+		JOIN scores sc ON sc.user_id = s.user_id
+		JOIN questions q ON q.id = s.question_id
+		WHERE %s
+	),
+	grouped AS (
+		SELECT question_id, user_id, first_correct,
+			CASE
+				WHEN position <= CEIL(answered * ?) THEN 'upper'
+				WHEN position > answered - CEIL(answered * ?) THEN 'lower'
+				ELSE 'middle'
+			END AS grp
+		FROM ranked
+	)
+`
+
+// OptionAnalysis 选项分析，比例均为 0-1
+type OptionAnalysis struct {
+	Index          int      `json:"index"`
+	Label          string   `json:"label"`
+	Text           string   `json:"text"`
+	IsCorrect      bool     `json:"is_correct"`
+	Count          int64    `json:"count"`
+	Rate           float64  `json:"rate"`
+	UpperRate      float64  `json:"upper_rate"`
+	LowerRate      float64  `json:"lower_rate"`
+	Discrimination *float64 `json:"discrimination"`
+}
+
+// ItemAnalysis 题目分析（经典测量理论），只统计每位用户的第一次作答
+// PValue 为难度指数（正确率），Discrimination 为高分组与低分组正确率之差
+type ItemAnalysis struct {
+	QuestionID     uint             `json:"question_id"`
+	QuestionTitle  string           `json:"question_title"`
+	QuestionType   string           `json:"question_type"`
+	CategoryName   string           `json:"category_name"`
+	Difficulty     string           `json:"difficulty"`
+	SampleSize     int64            `json:"sample_size"`
+	PValue         float64          `json:"p_value"`
+	UpperCount     int64            `json:"upper_count"`
+	LowerCount     int64            `json:"lower_count"`
+	UpperPValue    *float64         `json:"upper_p_value"`
+	LowerPValue    *float64         `json:"lower_p_value"`
+	Discrimination *float64         `json:"discrimination"`
+	Options        []OptionAnalysis `json:"options,omitempty"`
+	Flags          []string         `json:"flags"`
+}
+
+// itemOptionCount 按分组汇总的第一次作答选择
+type itemOptionCount struct {
+	QuestionID uint
+	UserAnswer int
+	Grp        string
+	Count      int64
+}
+
+// roundRatio 比例保留三位小数
+func roundRatio(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// ratio 计算比例，分母为 0 时返回 0
+func ratio(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// analyzeItems 对满足条件的题目做题目分析，condition 为针对题目表 q 的筛选条件
+func analyzeItems(db *gorm.DB, condition string, args ...interface{}) ([]ItemAnalysis, error) {
+	groupArgs := append(append([]interface{}{}, args...), itemGroupRatio, itemGroupRatio)
+	groups := fmt.Sprintf(itemGroupsQuery, condition)
+
+	var items []ItemAnalysis
+	if err := db.Raw(groups+`
+		SELECT question_id,
+			COUNT(*) AS sample_size,
+			AVG(first_correct) AS p_value,
+			SUM(grp = 'upper') AS upper_count,
+			SUM(grp = 'lower') AS lower_count,
+			AVG(CASE WHEN grp = 'upper' THEN first_correct END) AS upper_p_value,
+			AVG(CASE WHEN grp = 'lower' THEN first_correct END) AS lower_p_value
+		FROM grouped
+		GROUP BY question_id
+	`, groupArgs...).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	var counts []itemOptionCount
+	if err := db.Raw(groups+`
+		SELECT g.question_id, r.user_answer, g.grp, COUNT(*) AS count
+		FROM grouped g
+		JOIN answer_records r ON r.user_id = g.user_id AND r.question_id = g.question_id AND r.attempt_no = 1
+		GROUP BY g.question_id, r.user_answer, g.grp
+	`, groupArgs...).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countsByQuestion := make(map[uint][]itemOptionCount)
+	for _, count := range counts {
+		countsByQuestion[count.QuestionID] = append(countsByQuestion[count.QuestionID], count)
+	}
+
+	questionIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		questionIDs = append(questionIDs, item.QuestionID)
+	}
+	var questions []models.Question
+	if err := db.Preload("Category").Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	questionByID := make(map[uint]*models.Question, len(questions))
+	for i := range questions {
+		questionByID[questions[i].ID] = &questions[i]
+	}
+
+	for i := range items {
+		item := &items[i]
+		question := questionByID[item.QuestionID]
+		if question == nil {
+			continue
+		}
+		item.QuestionTitle = question.Title
+		item.QuestionType = question.Type
+		item.Difficulty = question.Difficulty
+		if question.Category != nil {
+			item.CategoryName = question.Category.Name
+		}
+		item.PValue = roundRatio(item.PValue)
+		if item.UpperPValue != nil && item.LowerPValue != nil {
+			discrimination := roundRatio(*item.UpperPValue - *item.LowerPValue)
+			item.Discrimination = &discrimination
+			*item.UpperPValue = roundRatio(*item.UpperPValue)
+			*item.LowerPValue = roundRatio(*item.LowerPValue)
+		}
+		if question.Type != "fill" {
+			item.Options = analyzeOptions(question, item, countsByQuestion[item.QuestionID])
+		}
+		item.Flags = itemFlags(item)
+	}
+	return items, nil
+}
+
+// analyzeOptions 统计每个选项的选择比例，多选题按位掩码分别计入所选的每个选项
+func analyzeOptions(question *models.Question, item *ItemAnalysis, counts []itemOptionCount) []OptionAnalysis {
+	options := make([]OptionAnalysis, len(question.Options))
+	upper := make([]int64, len(question.Options))
+	lower := make([]int64, len(question.Options))
+	for index, text := range question.Options {
+		options[index] = OptionAnalysis{
+			Index: index,
+			Label: string(rune('A' + index)),
+			Text:  text,
+		}
+		if question.Type == "multiple" {
+			options[index].IsCorrect = question.CorrectAnswer&(1<<index) != 0
+		} else {
+			options[index].IsCorrect = question.CorrectAnswer == index
+		}
+	}
+
+	for _, count := range counts {
+		for index := range options {
+			selected := count.UserAnswer == index
+			if question.Type == "multiple" {
+				selected = count.UserAnswer&(1<<index) != 0
+			}
+			if !selected {
+				continue
+			}
+			options[index].Count += count.Count
+			switch count.Grp {
+			case "upper":
+				upper[index] += count.Count
+			case "lower":
+				lower[index] += count.Count
+			}
+		}
+	}
+
+	for index := range options {
+		option := &options[index]
+		option.Rate = roundRatio(ratio(option.Count, item.SampleSize))
+		option.UpperRate = roundRatio(ratio(upper[index], item.UpperCount))
+		option.LowerRate = roundRatio(ratio(lower[index], item.LowerCount))
+		if item.UpperCount > 0 && item.LowerCount > 0 {
+			discrimination := roundRatio(ratio(upper[index], item.UpperCount) - ratio(lower[index], item.LowerCount))
+			option.Discrimination = &discrimination
+		}
+	}
+	return options
+}
+
+// itemFlags 按难度指数、区分度和选项分布标记需要复查的题目
+func itemFlags(item *ItemAnalysis) []string {
+	flags := []string{}
+	if item.SampleSize < itemMinSample {
+		return append(flags, ItemFlagInsufficientSample)
+	}
+
+	if item.PValue > itemTooEasyPValue {
+		flags = append(flags, ItemFlagTooEasy)
+	} else if item.PValue < itemTooHardPValue {
+		flags = append(flags, ItemFlagTooHard)
+	}
+	if item.Discrimination != nil {
+		if *item.Discrimination < 0 {
+			flags = append(flags, ItemFlagNegativeDiscrimination)
+		} else if *item.Discrimination < itemLowDiscrimination {
+			flags = append(flags, ItemFlagLowDiscrimination)
+		}
+	}
+	if item.UpperPValue != nil && *item.UpperPValue < 0.5 {
+		flags = append(flags, ItemFlagUpperGroupMiss)
+	}
+
+	unused, attractive := false, false
+	for _, option := range item.Options {
+		if option.IsCorrect {
+			continue
+		}
+		if option.Count == 0 {
+			unused = true
+		}
+		if option.Discrimination != nil && *option.Discrimination > 0 {
+			attractive = true
+		}
+	}
+	if unused {
+		flags = append(flags, ItemFlagUnusedOption)
+	}
+	if attractive {
+		flags = append(flags, ItemFlagAttractiveDistractor)
+	}
+	return flags
+}
+
+// GetQuestionItemAnalysis 获取单道题目的题目分析（管理员）
+func GetQuestionItemAnalysis(c *gin.Context) {
+	questionID, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的题目ID")
+		return
+	}
+
+	db := config.GetDB()
+	var question models.Question
+	if err := db.Preload("Category").Where("id = ?", questionID).First(&question).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "题目不存在")
+		return
+	}
+
+	items, err := analyzeItems(db, "q.id = ?", questionID)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取题目分析失败")
+		return
+	}
+	if len(items) > 0 {
+		SuccessResponse(c, items[0])
+		return
+	}
+
+	// 尚无作答记录
+	item := ItemAnalysis{
+		QuestionID:    question.ID,
+		QuestionTitle: question.Title,
+		QuestionType:  question.Type,
+		Difficulty:    question.Difficulty,
+	}
+	if question.Category != nil {
+		item.CategoryName = question.Category.Name
+	}
+	if question.Type != "fill" {
+		item.Options = analyzeOptions(&question, &item, nil)
+	}
+	item.Flags = itemFlags(&item)
+	SuccessResponse(c, item)
+}
+
+// GetItemAnalysisReport 题目分析报表（管理员）
+// 支持按分类、难度、题型、标记和最少作答人数筛选，按难度指数、区分度、作答人数或标记数排序
+func GetItemAnalysisReport(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	condition := "1 = 1"
+	args := []interface{}{}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		condition += " AND q.category_id = ?"
+		args = append(args, categoryID)
+	}
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		condition += " AND q.difficulty = ?"
+		args = append(args, difficulty)
+	}
+	if questionType := c.Query("type"); questionType != "" {
+		condition += " AND q.type = ?"
+		args = append(args, questionType)
+	}
+
+	items, err := analyzeItems(config.GetDB(), condition, args...)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取题目分析失败")
+		return
+	}
+
+	// 按标记和作答人数筛选
+	minSample, _ := strconv.ParseInt(c.Query("min_sample"), 10, 64)
+	flag := c.Query("flag")
+	filtered := items[:0]
+	for _, item := range items {
+		if item.SampleSize < minSample {
+			continue
+		}
+		if flag != "" && !slices.Contains(item.Flags, flag) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	// 默认区分度从低到高，便于优先复查；没有区分度的题目排在最后
+	sortBy := c.DefaultQuery("sort_by", "discrimination")
+	desc := c.DefaultQuery("sort_order", "ASC") == "DESC"
+	sortValue := func(item *ItemAnalysis) *float64 {
+		var value float64
+		switch sortBy {
+		case "p_value":
+			value = item.PValue
+		case "sample_size":
+			value = float64(item.SampleSize)
+		case "flag_count":
+			value = float64(len(item.Flags))
+		default:
+			return item.Discrimination
+		}
+		return &value
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := sortValue(&filtered[i]), sortValue(&filtered[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if *a == *b {
+			return filtered[i].QuestionID < filtered[j].QuestionID
+		}
+		if desc {
+			return *a > *b
+		}
+		return *a < *b
+	})
+
+	total := int64(len(filtered))
+	end := offset + size
+	if offset > len(filtered) {
+		offset = len(filtered)
+	}
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	PageSuccessResponse(c, filtered[offset:end], total, page, size)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"qaminiprogram/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// accountFailureThreshold 同一账号连续失败多少次后开始锁定
+	accountFailureThreshold = 5
+	// ipFailureThreshold 同一IP连续失败多少次后开始锁定
+	ipFailureThreshold = 20
+	// loginLockBase 首次锁定时长，之后每多失败一次翻倍
+	loginLockBase = time.Minute
+	// loginLockMax 单次锁定的最长时长
+	loginLockMax = time.Hour
+	// loginFailureWindow 超过该时长没有新的失败记录则重新计数
+	loginFailureWindow = 24 * time.Hour
+)
+
+// loginFailureThreshold 返回指定维度的失败阈值
+func loginFailureThreshold(scope string) int {
+	if scope == models.LoginScopeIP {
+		return ipFailureThreshold
+	}
+	return accountFailureThreshold
+}
+
+// loginLockDuration 根据失败次数计算递增的锁定时长
+func loginLockDuration(failCount, threshold int) time.Duration {
+	if failCount < threshold {
+		return 0
+	}
+	duration := loginLockBase
+	for i := threshold; i < failCount; i++ {
+		duration *= 2
+		if duration >= loginLockMax {
+			return loginLockMax
+		}
+	}
+	return duration
+}
+
+// getLoginLock 查询指定账号或IP当前是否处于锁定状态，返回解锁时间
+func getLoginLock(db *gorm.DB, scope, identifier string) (time.Time, bool) {
+	var failure models.LoginFailure
+	if err := db.Where("scope = ? AND identifier = ?", scope, identifier).First(&failure).Error; err != nil {
+		return time.Time{}, false
+	}
+	if failure.LockedUntil != nil && failure.LockedUntil.After(time.Now()) {
+		return *failure.LockedUntil, true
+	}
+	return time.Time{}, false
+}
+
+// recordLoginFailure 记录一次登录失败，达到阈值时返回新的解锁时间
+// 失败次数在一条语句中原子累加，上次失败已超出统计窗口时重新计数；行锁持续到事务结束，并发的失败依次累加
+func recordLoginFailure(db *gorm.DB, scope, identifier string) *time.Time {
+	now := time.Now()
+	windowStart := now.Add(-loginFailureWindow)
+	var lockedUntil *time.Time
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// MySQL 按顺序执行赋值，locked_until 和 fail_count 需在 last_failed_at 更新前判断窗口
+		if err := tx.Exec(`
+			INSERT INTO login_failures (scope, identifier, fail_count, last_failed_at, created_at, updated_at)
+			VALUES (?, ?, 1, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				locked_until = IF(last_failed_at < ?, NULL, locked_until),
+				fail_count = IF(last_failed_at < ?, 1, fail_count + 1),
+				last_failed_at = VALUES(last_failed_at),
+				updated_at = VALUES(updated_at)`,
+			scope, identifier, now, now, now, windowStart, windowStart).Error; err != nil {
+			return err
+		}
+
+		var failure models.LoginFailure
+		if err := tx.Where("scope = ? AND identifier = ?", scope, identifier).First(&failure).Error; err != nil {
+			return err
+		}
+		if duration := loginLockDuration(failure.FailCount, loginFailureThreshold(scope)); duration > 0 {
+			until := now.Add(duration)
+			lockedUntil = &until
+			return tx.Model(&failure).Update("locked_until", until).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+
+	purgeLoginFailures(db, now)
+	return lockedUntil
+}
+
+// purgeLoginFailures 删除超出统计窗口且未锁定的失败记录，IP 的失败记录不会因登录成功而清除，依靠此处过期
+func purgeLoginFailures(db *gorm.DB, now time.Time) {
+	db.Exec("DELETE FROM login_failures WHERE last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?) LIMIT 100",
+		now.Add(-loginFailureWindow), now)
+}
+
+// clearLoginFailures 登录成功后清除失败计数
+func clearLoginFailures(db *gorm.DB, scope, identifier string) {
+	db.Where("scope = ? AND identifier = ?", scope, identifier).Delete(&models.LoginFailure{})
+}
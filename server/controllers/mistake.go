@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"qaminiprogram/config"
 	"qaminiprogram/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	
@@ -65,6 +66,40 @@ func GetMistakeBooks(c *gin.Context) {
 	PageSuccessResponse(c, mistakes, total, page, size)
 }
 
+// GetDueMistakes 获取今日复习队列：未掌握且下次复习时间不晚于今天的错题，逾期最久的排在前面
+func GetDueMistakes(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	tomorrow := startOfDay(time.Now()).AddDate(0, 0, 1)
+
+	db := config.GetDB()
+	query := db.Model(&models.MistakeBook{}).
+		Where("user_id = ? AND is_mastered = ? AND next_review_at < ?", userID, false, tomorrow)
+
+	// 筛选分类
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		query = query.Joins("JOIN questions ON mistake_books.question_id = questions.id").Where("questions.category_id = ?", categoryID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var mistakes []models.MistakeBook
+	if err := query.Preload("Question").Preload("Question.Category").Order("next_review_at ASC").Offset(offset).Limit(size).Find(&mistakes).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取复习队列失败")
+		return
+	}
+
+	PageSuccessResponse(c, mistakes, total, page, size)
+}
+
 // AddToMistakeBook 添加到错题本
 func AddToMistakeBook(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -97,10 +132,7 @@ func AddToMistakeBook(c *gin.Context) {
 	}
 
 	// 添加到错题本
-	mistake := models.MistakeBook{
-		UserID:     userID,
-		QuestionID: req.QuestionID,
-	}
+	mistake := newMistakeEntry(userID, req.QuestionID, time.Now())
 
 	if err := db.Create(&mistake).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "添加到错题本失败")
@@ -169,6 +201,7 @@ func ClearMistakeBook(c *gin.Context) {
 }
 
 // MarkMistakeAsMastered 标记错题为已掌握
+// 正常情况下错题在连续答对到期复习后自动标记为掌握，这里供用户手动跳过剩余的复习
 func MarkMistakeAsMastered(c *gin.Context) {
 	userID, exists := GetUserID(c)
 	if !exists {
@@ -191,9 +224,11 @@ func MarkMistakeAsMastered(c *gin.Context) {
 		return
 	}
 
-	// 更新掌握状态
-	mistake.IsMastered = true
-	if err := db.Save(&mistake).Error; err != nil {
+	// 更新掌握状态，复习计划视为已完成
+	if err := db.Model(&mistake).Updates(map[string]interface{}{
+		"is_mastered": true,
+		"repetitions": mistakeMasteryRepetitions,
+	}).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "更新掌握状态失败")
 		return
 	}
@@ -224,9 +259,15 @@ func ResetMistakeStatus(c *gin.Context) {
 		return
 	}
 
-	// 重置掌握状态
-	mistake.IsMastered = false
-	if err := db.Save(&mistake).Error; err != nil {
+	// 重置掌握状态，复习计划从头开始并立即到期
+	now := time.Now()
+	if err := db.Model(&mistake).Updates(map[string]interface{}{
+		"is_mastered":    false,
+		"ease_factor":    defaultEaseFactor,
+		"interval_days":  0,
+		"repetitions":    0,
+		"next_review_at": now,
+	}).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "重置掌握状态失败")
 		return
 	}
@@ -252,6 +293,12 @@ func GetMistakeBookStatistics(c *gin.Context) {
 	var masteredMistakes int64
 	db.Model(&models.MistakeBook{}).Where("user_id = ? AND is_mastered = ?", userID, true).Count(&masteredMistakes)
 
+	// 今日待复习和已逾期的错题数
+	todayStart := startOfDay(time.Now())
+	var dueMistakes, overdueMistakes int64
+	db.Model(&models.MistakeBook{}).Where("user_id = ? AND is_mastered = ? AND next_review_at < ?", userID, false, todayStart.AddDate(0, 0, 1)).Count(&dueMistakes)
+	db.Model(&models.MistakeBook{}).Where("user_id = ? AND is_mastered = ? AND next_review_at < ?", userID, false, todayStart).Count(&overdueMistakes)
+
 	// 按分类统计错题数
 	type CategoryMistakeStats struct {
 		CategoryID   uint `json:"categoryId"`
@@ -294,6 +341,8 @@ func GetMistakeBookStatistics(c *gin.Context) {
 	SuccessResponse(c, gin.H{
 		"totalMistakes": totalMistakes,
 		"masteredMistakes": masteredMistakes,
+		"dueMistakes": dueMistakes,
+		"overdueMistakes": overdueMistakes,
 		"categoryStats": categoryStats,
 		"difficultyStats": difficultyStats,
 	})
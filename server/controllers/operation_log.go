@@ -105,7 +105,7 @@ func LogOperation(c *gin.Context, action, resource, description string) {
 		
 		var user models.User
 		if err := db.Where("id = ?", uid).First(&user).Error; err == nil {
-			if user.Role == "admin" {
+			if user.Role != models.RoleUser && user.Role != models.RoleGuest {
 				operator = user.Username
 			} else {
 				if user.Nickname != "" {
@@ -0,0 +1,671 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PaperQuestionRequest 手动组卷的题目
+type PaperQuestionRequest struct {
+	QuestionID uint    `json:"questionId" binding:"required"`
+	Score      float64 `json:"score"`
+}
+
+// PaperRuleRequest 规则组卷的抽题规则
+type PaperRuleRequest struct {
+	CategoryID *uint   `json:"categoryId"`
+	Type       string  `json:"type"`
+	Difficulty string  `json:"difficulty"`
+	Count      int     `json:"count"`
+	Score      float64 `json:"score"`
+}
+
+// PaperSectionRequest 试卷分节
+type PaperSectionRequest struct {
+	Title       string                 `json:"title" binding:"required"`
+	Description string                 `json:"description"`
+	Questions   []PaperQuestionRequest `json:"questions"`
+	Rules       []PaperRuleRequest     `json:"rules"`
+}
+
+// SavePaperRequest 创建或更新试卷请求
+type SavePaperRequest struct {
+	Title       string                `json:"title" binding:"required"`
+	Description string                `json:"description"`
+	Mode        string                `json:"mode" binding:"required,oneof=manual rule"`
+	PassScore   float64               `json:"passScore" binding:"min=0"`
+	TimeLimit   int                   `json:"timeLimit" binding:"min=0"`
+	Status      string                `json:"status"`
+	Sections    []PaperSectionRequest `json:"sections" binding:"required,min=1"`
+}
+
+// GeneratedPaperQuestion 组卷结果中的题目
+type GeneratedPaperQuestion struct {
+	Question models.Question `json:"question"`
+	Score    float64         `json:"score"`
+}
+
+// GeneratedPaperSection 组卷结果中的分节
+type GeneratedPaperSection struct {
+	SectionID   uint                     `json:"sectionId"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	Questions   []GeneratedPaperQuestion `json:"questions"`
+}
+
+// GetAdminPapers 获取试卷列表（管理员）
+func GetAdminPapers(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	// 获取查询参数
+	keyword := c.Query("keyword")
+	status := c.Query("status")
+	mode := c.Query("mode")
+
+	db := config.GetDB()
+	query := db.Model(&models.Paper{})
+
+	if keyword != "" {
+		query = query.Where("title LIKE ?", "%"+keyword+"%")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if mode != "" {
+		query = query.Where("mode = ?", mode)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取试卷总数失败")
+		return
+	}
+
+	var papers []models.Paper
+	if err := query.Preload("Creator").Order("created_at DESC").Offset(offset).Limit(size).Find(&papers).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取试卷列表失败")
+		return
+	}
+
+	for i := range papers {
+		papers[i].QuestionCount = countPaperQuestions(db, &papers[i])
+	}
+
+	PageSuccessResponse(c, papers, total, page, size)
+}
+
+// GetAdminPaperByID 获取试卷详情（管理员，包含题目和规则）
+func GetAdminPaperByID(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	db := config.GetDB()
+	paper, err := loadPaper(db, id)
+	if err != nil {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+
+	SuccessResponse(c, paper)
+}
+
+// CreatePaper 创建试卷（管理员）
+func CreatePaper(c *gin.Context) {
+	var req SavePaperRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	creatorID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "无法获取用户信息")
+		return
+	}
+
+	db := config.GetDB()
+	sections, totalScore, err := buildPaperSections(db, &req)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	paper := models.Paper{
+		Title:       req.Title,
+		Description: req.Description,
+		Mode:        req.Mode,
+		TotalScore:  totalScore,
+		PassScore:   req.PassScore,
+		TimeLimit:   req.TimeLimit,
+		Status:      req.Status,
+		CreatorID:   &creatorID,
+		Sections:    sections,
+	}
+	if paper.Status == "" {
+		paper.Status = models.PaperStatusDraft
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return savePaperSections(tx, &paper)
+	}); err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "创建试卷失败")
+		return
+	}
+
+	created, _ := loadPaper(db, paper.ID)
+
+	// 记录操作日志
+	LogOperation(c, "CREATE", "PAPER", fmt.Sprintf("创建试卷: %s", paper.Title))
+
+	SuccessResponse(c, created)
+}
+
+// UpdatePaper 更新试卷（管理员），分节、题目和规则整体替换
+func UpdatePaper(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	var req SavePaperRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	db := config.GetDB()
+	var paper models.Paper
+	if err := db.Where("id = ?", id).First(&paper).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+
+	sections, totalScore, err := buildPaperSections(db, &req)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	paper.Title = req.Title
+	paper.Description = req.Description
+	paper.Mode = req.Mode
+	paper.TotalScore = totalScore
+	paper.PassScore = req.PassScore
+	paper.TimeLimit = req.TimeLimit
+	if req.Status != "" {
+		paper.Status = req.Status
+	}
+	paper.Sections = sections
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := deletePaperSections(tx, paper.ID); err != nil {
+			return err
+		}
+		return savePaperSections(tx, &paper)
+	}); err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "更新试卷失败")
+		return
+	}
+
+	updated, _ := loadPaper(db, paper.ID)
+
+	// 记录操作日志
+	LogOperation(c, "UPDATE", "PAPER", fmt.Sprintf("更新试卷: %s", paper.Title))
+
+	SuccessResponse(c, updated)
+}
+
+// DeletePaper 删除试卷（管理员）
+func DeletePaper(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	db := config.GetDB()
+	var paper models.Paper
+	if err := db.Where("id = ?", id).First(&paper).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+
+	// 已有考试记录的试卷不能删除，避免成绩失去来源
+	var examCount int64
+	db.Model(&models.ExamSession{}).Where("paper_id = ?", paper.ID).Count(&examCount)
+	if examCount > 0 {
+		ErrorResponse(c, http.StatusBadRequest, "该试卷已有考试记录，无法删除")
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := deletePaperSections(tx, paper.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&paper).Error
+	}); err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "删除试卷失败")
+		return
+	}
+
+	// 记录操作日志
+	LogOperation(c, "DELETE", "PAPER", fmt.Sprintf("删除试卷: %s", paper.Title))
+
+	SuccessResponse(c, gin.H{"message": "删除成功"})
+}
+
+// PreviewPaper 按当前配置生成一份试卷用于预览（管理员）
+func PreviewPaper(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	db := config.GetDB()
+	paper, err := loadPaper(db, id)
+	if err != nil {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+
+	sections, err := generatePaper(db, paper)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"paper":    paper,
+		"sections": sections,
+	})
+}
+
+// GetPapers 获取已发布的试卷列表
+func GetPapers(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	query := db.Model(&models.Paper{}).Where("status = ?", models.PaperStatusPublished)
+
+	if keyword := c.Query("keyword"); keyword != "" {
+		query = query.Where("title LIKE ?", "%"+keyword+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var papers []models.Paper
+	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&papers).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取试卷列表失败")
+		return
+	}
+
+	for i := range papers {
+		papers[i].QuestionCount = countPaperQuestions(db, &papers[i])
+	}
+
+	PageSuccessResponse(c, papers, total, page, size)
+}
+
+// GetPaperByID 获取已发布试卷的基本信息（不含题目）
+func GetPaperByID(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的试卷ID")
+		return
+	}
+
+	db := config.GetDB()
+	var paper models.Paper
+	if err := db.Preload("Sections", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).Where("id = ? AND status = ?", id, models.PaperStatusPublished).First(&paper).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "试卷不存在")
+		return
+	}
+	paper.QuestionCount = countPaperQuestions(db, &paper)
+
+	SuccessResponse(c, paper)
+}
+
+// loadPaper 加载试卷及其分节、题目和规则
+func loadPaper(db *gorm.DB, id uint) (*models.Paper, error) {
+	var paper models.Paper
+	orderBySort := func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}
+	err := db.Preload("Sections", orderBySort).
+		Preload("Sections.Questions", orderBySort).
+		Preload("Sections.Questions.Question").
+		Preload("Sections.Rules", orderBySort).
+		Preload("Sections.Rules.Category").
+		Where("id = ?", id).First(&paper).Error
+	if err != nil {
+		return nil, err
+	}
+	paper.QuestionCount = countPaperQuestions(db, &paper)
+	return &paper, nil
+}
+
+// countPaperQuestions 统计试卷题目数，规则组卷按规则抽题数累加
+func countPaperQuestions(db *gorm.DB, paper *models.Paper) int {
+	var count int64
+	if paper.Mode == models.PaperModeRule {
+		db.Model(&models.PaperRule{}).Where("paper_id = ?", paper.ID).Select("COALESCE(SUM(count), 0)").Scan(&count)
+	} else {
+		db.Model(&models.PaperQuestion{}).Where("paper_id = ?", paper.ID).Count(&count)
+	}
+	return int(count)
+}
+
+// buildPaperSections 校验请求并构建分节数据，返回分节和试卷总分
+func buildPaperSections(db *gorm.DB, req *SavePaperRequest) ([]models.PaperSection, float64, error) {
+	if req.Status != "" && req.Status != models.PaperStatusDraft && req.Status != models.PaperStatusPublished {
+		return nil, 0, fmt.Errorf("试卷状态无效")
+	}
+
+	validTypes := map[string]bool{"single": true, "multiple": true, "judge": true, "fill": true}
+	validDifficulties := map[string]bool{"easy": true, "medium": true, "hard": true}
+
+	sections := make([]models.PaperSection, 0, len(req.Sections))
+	totalScore := 0.0
+	seen := make(map[uint]bool)
+
+	for i, sectionReq := range req.Sections {
+		section := models.PaperSection{
+			Title:       sectionReq.Title,
+			Description: sectionReq.Description,
+			Sort:        i,
+		}
+
+		if req.Mode == models.PaperModeManual {
+			if len(sectionReq.Questions) == 0 || len(sectionReq.Rules) > 0 {
+				return nil, 0, fmt.Errorf("第%d节：手动组卷需要选择题目，且不能设置抽题规则", i+1)
+			}
+			for j, questionReq := range sectionReq.Questions {
+				if questionReq.Score <= 0 {
+					return nil, 0, fmt.Errorf("第%d节第%d题：分值必须大于0", i+1, j+1)
+				}
+				if seen[questionReq.QuestionID] {
+					return nil, 0, fmt.Errorf("第%d节第%d题：题目重复", i+1, j+1)
+				}
+				seen[questionReq.QuestionID] = true
+
+				var count int64
+				db.Model(&models.Question{}).Where("id = ?", questionReq.QuestionID).Count(&count)
+				if count == 0 {
+					return nil, 0, fmt.Errorf("第%d节第%d题：题目不存在", i+1, j+1)
+				}
+
+				section.Questions = append(section.Questions, models.PaperQuestion{
+					QuestionID: questionReq.QuestionID,
+					Score:      questionReq.Score,
+					Sort:       j,
+				})
+				totalScore += questionReq.Score
+			}
+		} else {
+			if len(sectionReq.Rules) == 0 || len(sectionReq.Questions) > 0 {
+				return nil, 0, fmt.Errorf("第%d节：规则组卷需要设置抽题规则，且不能直接选择题目", i+1)
+			}
+			for j, ruleReq := range sectionReq.Rules {
+				if ruleReq.Count <= 0 || ruleReq.Score <= 0 {
+					return nil, 0, fmt.Errorf("第%d节第%d条规则：题目数量和分值必须大于0", i+1, j+1)
+				}
+				if ruleReq.Type != "" && !validTypes[ruleReq.Type] {
+					return nil, 0, fmt.Errorf("第%d节第%d条规则：题目类型无效", i+1, j+1)
+				}
+				if ruleReq.Difficulty != "" && !validDifficulties[ruleReq.Difficulty] {
+					return nil, 0, fmt.Errorf("第%d节第%d条规则：难度等级无效", i+1, j+1)
+				}
+
+				rule := models.PaperRule{
+					CategoryID: ruleReq.CategoryID,
+					Type:       ruleReq.Type,
+					Difficulty: ruleReq.Difficulty,
+					Count:      ruleReq.Count,
+					Score:      ruleReq.Score,
+					Sort:       j,
+				}
+				section.Rules = append(section.Rules, rule)
+				totalScore += float64(ruleReq.Count) * ruleReq.Score
+			}
+		}
+
+		sections = append(sections, section)
+	}
+
+	if req.Mode != models.PaperModeManual {
+		if err := checkPaperRules(db, sections); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if req.PassScore > totalScore {
+		return nil, 0, fmt.Errorf("及格分不能高于总分%.2f", totalScore)
+	}
+
+	return sections, totalScore, nil
+}
+
+// savePaperSections 保存试卷及其分节、题目和规则
+func savePaperSections(tx *gorm.DB, paper *models.Paper) error {
+	sections := paper.Sections
+	paper.Sections = nil
+	if err := tx.Save(paper).Error; err != nil {
+		return err
+	}
+
+	for i := range sections {
+		section := &sections[i]
+		questions, rules := section.Questions, section.Rules
+		section.Questions, section.Rules = nil, nil
+		section.PaperID = paper.ID
+		if err := tx.Create(section).Error; err != nil {
+			return err
+		}
+
+		for j := range questions {
+			questions[j].PaperID = paper.ID
+			questions[j].SectionID = section.ID
+		}
+		if len(questions) > 0 {
+			if err := tx.Create(&questions).Error; err != nil {
+				return err
+			}
+		}
+
+		for j := range rules {
+			rules[j].PaperID = paper.ID
+			rules[j].SectionID = section.ID
+		}
+		if len(rules) > 0 {
+			if err := tx.Create(&rules).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deletePaperSections 删除试卷的全部分节、题目和规则
+func deletePaperSections(tx *gorm.DB, paperID uint) error {
+	if err := tx.Where("paper_id = ?", paperID).Delete(&models.PaperQuestion{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("paper_id = ?", paperID).Delete(&models.PaperRule{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("paper_id = ?", paperID).Delete(&models.PaperSection{}).Error
+}
+
+// paperRuleCondition 抽题规则对应的查询条件，不限条件的规则为 1 = 1
+func paperRuleCondition(rule *models.PaperRule) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if rule.CategoryID != nil {
+		conditions = append(conditions, "category_id = ?")
+		args = append(args, *rule.CategoryID)
+	}
+	if rule.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, rule.Type)
+	}
+	if rule.Difficulty != "" {
+		conditions = append(conditions, "difficulty = ?")
+		args = append(args, rule.Difficulty)
+	}
+	if len(conditions) == 0 {
+		return "1 = 1", nil
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// paperRuleQuery 构建符合抽题规则的题目查询
+func paperRuleQuery(db *gorm.DB, rule *models.PaperRule) *gorm.DB {
+	condition, args := paperRuleCondition(rule)
+	return db.Model(&models.Question{}).Where(condition, args...)
+}
+
+// checkPaperRules 按生成试卷的顺序模拟抽题，确认每条规则在任何随机结果下都能抽满
+// 生成时前面的规则抽走的题目不能再被后面的规则抽取，因此每条规则的可用题数要扣除前面规则最多可能抽走的重叠题目：
+// 每条前面的规则最多抽走 min(抽题数, 重叠题数) 道，合计不超过与前面所有规则重叠的题数
+// 题目不足时列出每条规则还差的题数
+func checkPaperRules(db *gorm.DB, sections []models.PaperSection) error {
+	type ruleRef struct {
+		rule  *models.PaperRule
+		label string
+	}
+	var rules []ruleRef
+	for i := range sections {
+		for j := range sections[i].Rules {
+			rules = append(rules, ruleRef{&sections[i].Rules[j], fmt.Sprintf("第%d节第%d条规则", i+1, j+1)})
+		}
+	}
+
+	var shortfalls []string
+	for i, current := range rules {
+		var available int64
+		if err := paperRuleQuery(db, current.rule).Count(&available).Error; err != nil {
+			return err
+		}
+
+		var taken int64
+		if i > 0 {
+			var earlier []string
+			var earlierArgs []interface{}
+			for _, previous := range rules[:i] {
+				condition, args := paperRuleCondition(previous.rule)
+				var overlap int64
+				if err := paperRuleQuery(db, current.rule).Where(condition, args...).Count(&overlap).Error; err != nil {
+					return err
+				}
+				if overlap > int64(previous.rule.Count) {
+					overlap = int64(previous.rule.Count)
+				}
+				taken += overlap
+				earlier = append(earlier, condition)
+				earlierArgs = append(earlierArgs, args...)
+			}
+
+			var overlapAll int64
+			if err := paperRuleQuery(db, current.rule).Where("("+strings.Join(earlier, " OR ")+")", earlierArgs...).
+				Count(&overlapAll).Error; err != nil {
+				return err
+			}
+			if taken > overlapAll {
+				taken = overlapAll
+			}
+		}
+
+		remaining := available - taken
+		if remaining >= int64(current.rule.Count) {
+			continue
+		}
+		shortage := int64(current.rule.Count) - remaining
+		if taken == 0 {
+			shortfalls = append(shortfalls, fmt.Sprintf("%s需要%d道，符合条件的只有%d道，还差%d道",
+				current.label, current.rule.Count, available, shortage))
+		} else {
+			shortfalls = append(shortfalls, fmt.Sprintf("%s需要%d道，符合条件的%d道中前面的规则最多抽走%d道，还差%d道",
+				current.label, current.rule.Count, available, taken, shortage))
+		}
+	}
+
+	if len(shortfalls) > 0 {
+		return fmt.Errorf("抽题规则可用题目不足：%s", strings.Join(shortfalls, "；"))
+	}
+	return nil
+}
+
+// generatePaper 生成一份具体的试卷：手动组卷直接取已选题目，规则组卷按规则随机抽题且不重复
+func generatePaper(db *gorm.DB, paper *models.Paper) ([]GeneratedPaperSection, error) {
+	result := make([]GeneratedPaperSection, 0, len(paper.Sections))
+	picked := make(map[uint]bool)
+
+	for _, section := range paper.Sections {
+		generated := GeneratedPaperSection{
+			SectionID:   section.ID,
+			Title:       section.Title,
+			Description: section.Description,
+			Questions:   []GeneratedPaperQuestion{},
+		}
+
+		if paper.Mode == models.PaperModeManual {
+			for _, item := range section.Questions {
+				if item.Question == nil {
+					return nil, fmt.Errorf("试卷中的题目 %d 已被删除", item.QuestionID)
+				}
+				generated.Questions = append(generated.Questions, GeneratedPaperQuestion{
+					Question: *item.Question,
+					Score:    item.Score,
+				})
+			}
+		} else {
+			for i := range section.Rules {
+				rule := &section.Rules[i]
+				query := paperRuleQuery(db, rule)
+				if len(picked) > 0 {
+					excluded := make([]uint, 0, len(picked))
+					for id := range picked {
+						excluded = append(excluded, id)
+					}
+					query = query.Where("id NOT IN ?", excluded)
+				}
+
+				var questions []models.Question
+				if err := query.Order("RAND()").Limit(rule.Count).Find(&questions).Error; err != nil {
+					return nil, err
+				}
+				if len(questions) < rule.Count {
+					return nil, fmt.Errorf("分节「%s」的抽题规则可用题目不足：需要%d道，只有%d道", section.Title, rule.Count, len(questions))
+				}
+
+				for _, question := range questions {
+					picked[question.ID] = true
+					generated.Questions = append(generated.Questions, GeneratedPaperQuestion{
+						Question: question,
+						Score:    rule.Score,
+					})
+				}
+			}
+		}
+
+		result = append(result, generated)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 排行榜默认和最大返回人数
+const (
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 100
+)
+
+// pointChange 一笔积分变动
+type pointChange struct {
+	UserID     uint
+	Points     int
+	Reason     string
+	RefType    string
+	RefID      *uint
+	CategoryID *uint
+}
+
+// LeaderboardEntry 排行榜条目
+type LeaderboardEntry struct {
+	Rank     int64  `json:"rank"`
+	UserID   uint   `json:"userId"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Points   int    `json:"points"`
+}
+
+// LeaderboardRank 当前用户在排行榜中的名次，未上榜时 Rank 为 0
+type LeaderboardRank struct {
+	Rank   int64 `json:"rank"`
+	Points int   `json:"points"`
+}
+
+// addPoints 记录一笔积分变动，更新用户积分余额和排行汇总，需与触发积分的操作在同一事务中调用
+func addPoints(tx *gorm.DB, change pointChange) error {
+	if change.Points == 0 {
+		return nil
+	}
+
+	// 先更新余额再读取，行锁保证并发时余额快照连续
+	if err := tx.Model(&models.User{}).Where("id = ?", change.UserID).
+		Update("points", gorm.Expr("points + ?", change.Points)).Error; err != nil {
+		return err
+	}
+	var balance int
+	if err := tx.Model(&models.User{}).Where("id = ?", change.UserID).Select("points").Scan(&balance).Error; err != nil {
+		return err
+	}
+
+	record := models.PointRecord{
+		UserID:       change.UserID,
+		Points:       change.Points,
+		BalanceAfter: balance,
+		Reason:       change.Reason,
+		RefType:      change.RefType,
+		RefID:        change.RefID,
+		CategoryID:   change.CategoryID,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return err
+	}
+
+	// 累加到各周期的全站排行和分类排行
+	categoryIDs := []uint{0}
+	if change.CategoryID != nil && *change.CategoryID != 0 {
+		categoryIDs = append(categoryIDs, *change.CategoryID)
+	}
+	for period, key := range leaderboardPeriodKeys(record.CreatedAt) {
+		for _, categoryID := range categoryIDs {
+			if err := tx.Exec(`
+				INSERT INTO point_leaderboards (period, period_key, category_id, user_id, points, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE points = points + VALUES(points), updated_at = VALUES(updated_at)
+			`, period, key, categoryID, change.UserID, change.Points, record.CreatedAt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// leaderboardPeriodKeys 积分变动时间所属的各排行周期
+func leaderboardPeriodKeys(t time.Time) map[string]string {
+	year, week := t.ISOWeek()
+	return map[string]string{
+		models.LeaderboardDaily:  t.Format("2006-01-02"),
+		models.LeaderboardWeekly: fmt.Sprintf("%d-W%02d", year, week),
+		models.LeaderboardAll:    models.LeaderboardAll,
+	}
+}
+
+// rebuildUserPoints 按积分流水重建用户的余额快照和排行汇总，用于合并账号后
+func rebuildUserPoints(tx *gorm.DB, userID uint) error {
+	if err := tx.Exec(`
+		UPDATE point_records r
+		JOIN (
+			SELECT id, SUM(points) OVER (ORDER BY created_at, id) AS balance_after
+			FROM point_records WHERE user_id = ?
+		) b ON b.id = r.id
+		SET r.balance_after = b.balance_after
+	`, userID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.PointLeaderboard{}).Error; err != nil {
+		return err
+	}
+	// 周期键的格式与 leaderboardPeriodKeys 一致，%x-W%v 为 ISO 周
+	for _, scope := range []string{"0", "category_id"} {
+		filter := ""
+		if scope != "0" {
+			filter = "AND category_id IS NOT NULL AND category_id <> 0"
+		}
+		if err := tx.Exec(fmt.Sprintf(`
+			INSERT INTO point_leaderboards (period, period_key, category_id, user_id, points, updated_at)
+			SELECT 'daily', DATE_FORMAT(created_at, '%%Y-%%m-%%d'), %[1]s, user_id, SUM(points), NOW()
+			FROM point_records WHERE user_id = ? %[2]s GROUP BY user_id, 2, 3
+			UNION ALL
+			SELECT 'weekly', DATE_FORMAT(created_at, '%%x-W%%v'), %[1]s, user_id, SUM(points), NOW()
+			FROM point_records WHERE user_id = ? %[2]s GROUP BY user_id, 2, 3
+			UNION ALL
+			SELECT 'all', 'all', %[1]s, user_id, SUM(points), NOW()
+			FROM point_records WHERE user_id = ? %[2]s GROUP BY user_id, 3
+		`, scope, filter), userID, userID, userID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUserPoints 获取当前用户的积分余额和积分流水
+func GetUserPoints(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	var user models.User
+	if err := db.Select("id", "points").Where("id = ?", userID).First(&user).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+
+	query := db.Model(&models.PointRecord{}).Where("user_id = ?", userID)
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var records []models.PointRecord
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(size).Find(&records).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取积分记录失败")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"balance": user.Points,
+		"records": records,
+		"total":   total,
+		"page":    page,
+		"size":    size,
+	})
+}
+
+// GetLeaderboard 获取积分排行榜
+// period 为 daily/weekly/all，categoryId 为空时为全站排行；已登录时附带当前用户的名次
+func GetLeaderboard(c *gin.Context) {
+	period := c.DefaultQuery("period", models.LeaderboardWeekly)
+	periodKey, ok := leaderboardPeriodKeys(time.Now())[period]
+	if !ok {
+		ErrorResponse(c, http.StatusBadRequest, "排行周期无效")
+		return
+	}
+
+	var categoryID uint64
+	if value := c.Query("categoryId"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			ErrorResponse(c, http.StatusBadRequest, "无效的分类ID")
+			return
+		}
+		categoryID = id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLeaderboardLimit)))
+	if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+		limit = defaultLeaderboardLimit
+	}
+
+	db := config.GetDB()
+	board := db.Model(&models.PointLeaderboard{}).
+		Where("period = ? AND period_key = ? AND category_id = ?", period, periodKey, categoryID)
+
+	var rows []struct {
+		UserID   uint
+		Nickname string
+		Avatar   string
+		Points   int
+	}
+	if err := board.Session(&gorm.Session{}).
+		Select("point_leaderboards.user_id, users.nickname, users.avatar, point_leaderboards.points").
+		Joins("JOIN users ON users.id = point_leaderboards.user_id").
+		Order("point_leaderboards.points DESC, point_leaderboards.updated_at ASC").
+		Limit(limit).Scan(&rows).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取排行榜失败")
+		return
+	}
+
+	// 积分相同名次相同
+	entries := make([]LeaderboardEntry, 0, len(rows))
+	for i, row := range rows {
+		rank := int64(i + 1)
+		if i > 0 && row.Points == rows[i-1].Points {
+			rank = entries[i-1].Rank
+		}
+		entries = append(entries, LeaderboardEntry{
+			Rank:     rank,
+			UserID:   row.UserID,
+			Nickname: row.Nickname,
+			Avatar:   row.Avatar,
+			Points:   row.Points,
+		})
+	}
+
+	response := gin.H{
+		"period":     period,
+		"periodKey":  periodKey,
+		"categoryId": categoryID,
+		"entries":    entries,
+	}
+
+	if userID, exists := GetUserID(c); exists {
+		me := LeaderboardRank{}
+		var mine models.PointLeaderboard
+		if err := board.Session(&gorm.Session{}).Where("user_id = ?", userID).First(&mine).Error; err == nil {
+			var higher int64
+			board.Session(&gorm.Session{}).Where("points > ?", mine.Points).Count(&higher)
+			me = LeaderboardRank{Rank: higher + 1, Points: mine.Points}
+		}
+		response["me"] = me
+	}
+
+	SuccessResponse(c, response)
+}
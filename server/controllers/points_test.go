@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"qaminiprogram/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardPeriodKeys(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	tests := []struct {
+		name   string
+		t      time.Time
+		daily  string
+		weekly string
+	}{
+		{"周中", time.Date(2024, 6, 12, 10, 0, 0, 0, loc), "2024-06-12", "2024-W24"},
+		{"周一零点", time.Date(2024, 6, 10, 0, 0, 0, 0, loc), "2024-06-10", "2024-W24"},
+		{"周日深夜", time.Date(2024, 6, 9, 23, 59, 59, 0, loc), "2024-06-09", "2024-W23"},
+		{"年末属于下一年的第一周", time.Date(2024, 12, 30, 12, 0, 0, 0, loc), "2024-12-30", "2025-W01"},
+		{"年初属于上一年的最后一周", time.Date(2021, 1, 3, 12, 0, 0, 0, loc), "2021-01-03", "2020-W53"},
+	}
+	for _, tt := range tests {
+		want := map[string]string{
+			models.LeaderboardDaily:  tt.daily,
+			models.LeaderboardWeekly: tt.weekly,
+			models.LeaderboardAll:    models.LeaderboardAll,
+		}
+		if got := leaderboardPeriodKeys(tt.t); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: leaderboardPeriodKeys(%v) = %v, want %v", tt.name, tt.t, got, want)
+		}
+	}
+}
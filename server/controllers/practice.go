@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 顺序练习每次默认和最多返回的题数
+const (
+	defaultPracticeCount = 10
+	maxPracticeCount     = 50
+)
+
+// pendingPracticeQuery 分类中待练习的题目：从未作答或最近一次答错
+func pendingPracticeQuery(db *gorm.DB, userID, categoryID uint) *gorm.DB {
+	return db.Model(&models.Question{}).
+		Joins("LEFT JOIN answer_states s ON s.question_id = questions.id AND s.user_id = ?", userID).
+		Where("questions.category_id = ? AND (s.id IS NULL OR s.last_correct = ?)", categoryID, false)
+}
+
+// advancePracticeCursor 顺序练习作答后把进度移到该题，回到前面的题目时视为开始新一轮
+func advancePracticeCursor(tx *gorm.DB, userID, categoryID, questionID uint) error {
+	// round 需在 last_question_id 之前赋值，比较的是更新前的位置
+	return tx.Exec(`
+		INSERT INTO practice_cursors (user_id, category_id, last_question_id, round, created_at, updated_at)
+		VALUES (?, ?, ?, 1, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE
+			round = round + IF(VALUES(last_question_id) < last_question_id, 1, 0),
+			last_question_id = VALUES(last_question_id),
+			updated_at = VALUES(updated_at)
+	`, userID, categoryID, questionID).Error
+}
+
+// GetPracticeQuestions 顺序练习取题
+// 从上次练习的位置往后按题目ID顺序返回未作答或最近一次答错的题目，到末尾后从头开始
+func GetPracticeQuestions(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	categoryID, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultPracticeCount)))
+	if err != nil || count < 1 || count > maxPracticeCount {
+		count = defaultPracticeCount
+	}
+
+	db := config.GetDB()
+
+	var category models.Category
+	if err := db.Where("id = ?", categoryID).First(&category).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "分类不存在")
+		return
+	}
+
+	// 每日答题限额
+	quizSettings := getQuizSettings(db)
+	remaining := remainingDailyAnswers(db, userID, quizSettings)
+	if remaining == 0 {
+		ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("今日答题数量已达上限（%d题）", quizSettings.DailyLimit))
+		return
+	}
+	if remaining > 0 && int64(count) > remaining {
+		count = int(remaining)
+	}
+
+	cursor := models.PracticeCursor{UserID: userID, CategoryID: categoryID, Round: 1}
+	db.Where("user_id = ? AND category_id = ?", userID, categoryID).First(&cursor)
+
+	var totalQuestions, pending int64
+	db.Model(&models.Question{}).Where("category_id = ?", categoryID).Count(&totalQuestions)
+	pendingPracticeQuery(db, userID, categoryID).Count(&pending)
+
+	var questions []models.Question
+	if err := pendingPracticeQuery(db, userID, categoryID).
+		Where("questions.id > ?", cursor.LastQuestionID).
+		Preload("Category").Order("questions.id ASC").Limit(count).Find(&questions).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取练习题目失败")
+		return
+	}
+
+	// 已练到末尾，从头继续练习之前答错或跳过的题目
+	wrapped := false
+	if len(questions) == 0 && cursor.LastQuestionID > 0 && pending > 0 {
+		if err := pendingPracticeQuery(db, userID, categoryID).
+			Preload("Category").Order("questions.id ASC").Limit(count).Find(&questions).Error; err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "获取练习题目失败")
+			return
+		}
+		wrapped = true
+	}
+
+	SuccessResponse(c, gin.H{
+		"categoryId":     categoryID,
+		"lastQuestionId": cursor.LastQuestionID,
+		"round":          cursor.Round,
+		"totalQuestions": totalQuestions,
+		"pendingCount":   pending,
+		"wrapped":        wrapped,
+		"finished":       pending == 0,
+		"questions":      newPracticeQuestions(questions, quizSettings, userID, true),
+	})
+}
+
+// ResetPracticeCursor 重置顺序练习进度，从分类的第一题重新开始
+func ResetPracticeCursor(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	categoryID, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的分类ID")
+		return
+	}
+
+	db := config.GetDB()
+	if err := db.Model(&models.PracticeCursor{}).
+		Where("user_id = ? AND category_id = ? AND last_question_id > 0", userID, categoryID).
+		Updates(map[string]interface{}{
+			"last_question_id": 0,
+			"round":            gorm.Expr("round + 1"),
+		}).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "重置练习进度失败")
+		return
+	}
+
+	SuccessResponse(c, gin.H{"message": "练习进度已重置"})
+}
@@ -6,11 +6,8 @@ import (
 	"qaminiprogram/config"
 	"qaminiprogram/models"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	
 	"gorm.io/gorm"
 )
 
@@ -21,6 +18,7 @@ type CreateQuestionRequest struct {
 	Type          string    `json:"type" binding:"required"`
 	Options       []string  `json:"options"`
 	CorrectAnswer int       `json:"correctAnswer" binding:"min=0"`
+	FillAnswers   [][]string `json:"fillAnswers"`
 	Explanation   string    `json:"explanation"`
 	Difficulty    string    `json:"difficulty"`
 	CategoryID    uint `json:"categoryId" binding:"required"`
@@ -33,6 +31,7 @@ type UpdateQuestionRequest struct {
 	Type          string     `json:"type"`
 	Options       []string   `json:"options"`
 	CorrectAnswer *int       `json:"correctAnswer"`
+	FillAnswers   [][]string `json:"fillAnswers"`
 	Explanation   string     `json:"explanation"`
 	Difficulty    string     `json:"difficulty"`
 	CategoryID    *uint `json:"categoryId"`
@@ -71,12 +70,14 @@ func GetQuestions(c *gin.Context) {
 	query.Count(&total)
 
 	var questions []models.Question
-	if err := query.Preload("Category").Preload("Creator").Offset(offset).Limit(size).Find(&questions).Error; err != nil {
+	if err := query.Preload("Category").Offset(offset).Limit(size).Find(&questions).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "获取题目列表失败")
 		return
 	}
 
-	PageSuccessResponse(c, questions, total, page, size)
+	// 按解析显示设置隐藏答案和解析
+	userID, loggedIn := GetUserID(c)
+	PageSuccessResponse(c, newPracticeQuestions(questions, getQuizSettings(db), userID, loggedIn), total, page, size)
 }
 
 // GetAdminQuestions 获取管理员题目列表（带更多筛选条件）
@@ -84,60 +85,74 @@ func GetAdminQuestions(c *gin.Context) {
 	page, size := ParsePageParams(c)
 	offset := (page - 1) * size
 
-	// 获取查询参数
-	categoryID := c.Query("categoryId")
-	difficulty := c.Query("difficulty")
-	questionType := c.Query("type") // 添加题目类型参数
-	keyword := c.Query("keyword")
-	creatorID := c.Query("creatorId")
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-
 	db := config.GetDB()
-	query := db.Model(&models.Question{})
+	query := filterAdminQuestions(c, db.Model(&models.Question{}))
 
-	// 添加查询条件
-	if categoryID != "" {
+	// 获取总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取题目总数失败")
+		return
+	}
+
+	// 获取分页数据
+	var questions []models.Question
+	if err := query.Preload("Category").Preload("Creator").Order("created_at DESC").Offset(offset).Limit(size).Find(&questions).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取题目列表失败")
+		return
+	}
+
+	PageSuccessResponse(c, questions, total, page, size)
+}
+
+// filterAdminQuestions 按管理端的查询参数筛选题目，题目列表和导出共用
+// 参数：categoryId、difficulty、type、keyword（匹配标题和内容）、creatorId、startDate、endDate
+func filterAdminQuestions(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if categoryID := c.Query("categoryId"); categoryID != "" {
 		query = query.Where("category_id = ?", categoryID)
 	}
-	if difficulty != "" {
+	if difficulty := c.Query("difficulty"); difficulty != "" {
 		query = query.Where("difficulty = ?", difficulty)
 	}
-	if questionType != "" {
+	if questionType := c.Query("type"); questionType != "" {
 		query = query.Where("type = ?", questionType)
 	}
-	if keyword != "" {
-		query = query.Where("title LIKE ? OR content LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	if keyword := c.Query("keyword"); keyword != "" {
+		query = query.Where("(title LIKE ? OR content LIKE ?)", "%"+keyword+"%", "%"+keyword+"%")
 	}
-	if creatorID != "" {
+	if creatorID := c.Query("creatorId"); creatorID != "" {
 		query = query.Where("creator_id = ?", creatorID)
 	}
-	if startDate != "" {
+	if startDate := c.Query("startDate"); startDate != "" {
 		query = query.Where("created_at >= ?", startDate)
 	}
-	if endDate != "" {
+	if endDate := c.Query("endDate"); endDate != "" {
 		query = query.Where("created_at <= ?", endDate)
 	}
+	return query
+}
 
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		ErrorResponse(c, http.StatusInternalServerError, "获取题目总数失败")
+// GetQuestionByID 根据ID获取题目，按解析显示设置隐藏答案和解析
+func GetQuestionByID(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的题目ID")
 		return
 	}
 
-	// 获取分页数据
-	var questions []models.Question
-	if err := query.Preload("Category").Preload("Creator").Order("created_at DESC").Offset(offset).Limit(size).Find(&questions).Error; err != nil {
-		ErrorResponse(c, http.StatusInternalServerError, "获取题目列表失败")
+	db := config.GetDB()
+	var question models.Question
+	if err := db.Preload("Category").Where("id = ?", id).First(&question).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "题目不存在")
 		return
 	}
 
-	PageSuccessResponse(c, questions, total, page, size)
+	userID, loggedIn := GetUserID(c)
+	SuccessResponse(c, newPracticeQuestions([]models.Question{question}, getQuizSettings(db), userID, loggedIn)[0])
 }
 
-// GetQuestionByID 根据ID获取题目
-func GetQuestionByID(c *gin.Context) {
+// GetAdminQuestionByID 根据ID获取题目（管理员），包含答案和解析
+func GetAdminQuestionByID(c *gin.Context) {
 	id, err := ParseIDParam(c, "id")
 	if err != nil {
 		ErrorResponse(c, http.StatusBadRequest, "无效的题目ID")
@@ -177,13 +192,28 @@ func GetRandomQuestions(c *gin.Context) {
 		query = query.Where("difficulty = ?", difficulty)
 	}
 
+	quizSettings := getQuizSettings(db)
+
+	// 已登录用户受每日答题限额约束，最多返回今日剩余的题数
+	userID, loggedIn := GetUserID(c)
+	if loggedIn {
+		remaining := remainingDailyAnswers(db, userID, quizSettings)
+		if remaining == 0 {
+			ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("今日答题数量已达上限（%d题）", quizSettings.DailyLimit))
+			return
+		}
+		if remaining > 0 && int64(count) > remaining {
+			count = int(remaining)
+		}
+	}
+
 	var questions []models.Question
 	if err := query.Preload("Category").Order("RAND()").Limit(count).Find(&questions).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "获取随机题目失败")
 		return
 	}
 
-	SuccessResponse(c, questions)
+	SuccessResponse(c, newPracticeQuestions(questions, quizSettings, userID, loggedIn))
 }
 
 // GetQuestionsByCategory 根据分类获取题目
@@ -207,7 +237,8 @@ func GetQuestionsByCategory(c *gin.Context) {
 		return
 	}
 
-	PageSuccessResponse(c, questions, total, page, size)
+	userID, loggedIn := GetUserID(c)
+	PageSuccessResponse(c, newPracticeQuestions(questions, getQuizSettings(db), userID, loggedIn), total, page, size)
 }
 
 // CreateQuestion 创建题目（管理员）
@@ -239,11 +270,25 @@ func CreateQuestion(c *gin.Context) {
 		return
 	}
 
-	// 验证正确答案索引（仅对选择题）
-	if (req.Type == "single" || req.Type == "multiple") && req.CorrectAnswer >= len(req.Options) {
+	// 验证正确答案：单选题为选项索引，多选题为选项位掩码
+	if req.Type == "single" && req.CorrectAnswer >= len(req.Options) {
 		ErrorResponse(c, http.StatusBadRequest, "正确答案索引超出范围")
 		return
 	}
+	if req.Type == "multiple" && (req.CorrectAnswer == 0 || req.CorrectAnswer >= 1<<len(req.Options)) {
+		ErrorResponse(c, http.StatusBadRequest, "多选题答案格式错误")
+		return
+	}
+
+	// 验证填空题答案
+	var fillAnswers models.FillAnswers
+	if req.Type == "fill" {
+		var err error
+		if fillAnswers, err = cleanFillAnswers(req.FillAnswers); err != nil {
+			ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	// 验证分类是否存在
 	db := config.GetDB()
@@ -267,6 +312,7 @@ func CreateQuestion(c *gin.Context) {
 		Type:          req.Type,
 		Options:       models.JSONArray(req.Options),
 		CorrectAnswer: req.CorrectAnswer,
+		FillAnswers:   fillAnswers,
 		Explanation:   req.Explanation,
 		Difficulty:    req.Difficulty,
 		CategoryID:    req.CategoryID,
@@ -360,7 +406,7 @@ func UpdateQuestion(c *gin.Context) {
 				return
 			}
 		} else if questionType == "fill" {
-			// 填空题：答案通常为0，实际答案存储在explanation中
+			// 填空题：答案索引固定为0，实际答案存储在fillAnswers中
 			if *req.CorrectAnswer != 0 {
 				ErrorResponse(c, http.StatusBadRequest, "填空题答案索引应为0")
 				return
@@ -369,6 +415,21 @@ func UpdateQuestion(c *gin.Context) {
 		
 		question.CorrectAnswer = *req.CorrectAnswer
 	}
+	if req.FillAnswers != nil {
+		fillAnswers, err := cleanFillAnswers(req.FillAnswers)
+		if err != nil {
+			ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		question.FillAnswers = fillAnswers
+	}
+	if question.Type == "fill" && len(question.FillAnswers) == 0 {
+		ErrorResponse(c, http.StatusBadRequest, "填空题至少需要一个空的答案")
+		return
+	}
+	if question.Type != "fill" {
+		question.FillAnswers = nil
+	}
 	if req.Explanation != "" {
 		question.Explanation = req.Explanation
 	}
@@ -459,6 +520,7 @@ func BatchDeleteQuestions(c *gin.Context) {
 
 // ImportQuestionItem 导入题目项
 type ImportQuestionItem struct {
+	Title      string    `json:"title"` // 为空时截取题目内容
 	Content    string    `json:"content" binding:"required"`
 	Type       string    `json:"type" binding:"required"`
 	Difficulty string    `json:"difficulty" binding:"required"`
@@ -478,13 +540,17 @@ type ImportQuestionsRequest struct {
 type ImportOptions struct {
 	SkipDuplicates  bool `json:"skip_duplicates"`
 	UpdateExisting  bool `json:"update_existing"`
+	DryRun          bool `json:"dry_run"` // 只校验并返回结果，不写入数据
 }
 
 // ImportResult 导入结果
 type ImportResult struct {
-	Imported int      `json:"imported"`
-	Skipped  int      `json:"skipped"`
-	Errors   []string `json:"errors,omitempty"`
+	Imported  int              `json:"imported"` // 新建和更新的题目数
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Skipped   int              `json:"skipped"`
+	Errors    []string         `json:"errors,omitempty"`
+	RowErrors []ImportRowError `json:"rowErrors,omitempty"`
 }
 
 // ImportQuestions 批量导入题目（管理员）
@@ -503,395 +569,18 @@ func ImportQuestions(c *gin.Context) {
 		return
 	}
 
-	db := config.GetDB()
-	result := ImportResult{
-		Imported: 0,
-		Skipped:  0,
-		Errors:   []string{},
-	}
-
-	// 开始事务
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
+	rows := make([]importRow, len(req.Questions))
 	for i, item := range req.Questions {
-		// 验证题目类型
-		validTypes := []string{"single", "multiple", "judge", "fill"}
-		isValidType := false
-		for _, validType := range validTypes {
-			if item.Type == validType {
-				isValidType = true
-				break
-			}
-		}
-		if !isValidType {
-			result.Errors = append(result.Errors, fmt.Sprintf("第%d行：题目类型无效", i+1))
-			result.Skipped++
-			continue
-		}
-
-		// 验证难度等级
-		validDifficulties := []string{"easy", "medium", "hard"}
-		isValidDifficulty := false
-		for _, validDifficulty := range validDifficulties {
-			if item.Difficulty == validDifficulty {
-				isValidDifficulty = true
-				break
-			}
-		}
-		if !isValidDifficulty {
-			result.Errors = append(result.Errors, fmt.Sprintf("第%d行：难度等级无效", i+1))
-			result.Skipped++
-			continue
-		}
-
-		// 验证分类是否存在
-		var category models.Category
-		if err := tx.Where("id = ?", item.CategoryID).First(&category).Error; err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("第%d行：分类不存在", i+1))
-			result.Skipped++
-			continue
-		}
-
-		// 检查是否重复（基于内容）
-		if req.Options.SkipDuplicates {
-			var existingQuestion models.Question
-			if err := tx.Where("content = ? AND category_id = ?", item.Content, item.CategoryID).First(&existingQuestion).Error; err == nil {
-				if req.Options.UpdateExisting {
-					// 更新现有题目
-					if err := updateExistingQuestion(tx, &existingQuestion, item); err != nil {
-						result.Errors = append(result.Errors, fmt.Sprintf("第%d行：更新失败 - %v", i+1, err))
-						result.Skipped++
-					} else {
-						result.Imported++
-					}
-				} else {
-					result.Skipped++
-				}
-				continue
-			}
-		}
-
-		// 处理答案和选项
-		options := item.Options
-		correctAnswer := 0
-
-		switch item.Type {
-		case "single":
-			if len(options) < 2 {
-				result.Errors = append(result.Errors, fmt.Sprintf("第%d行：单选题至少需要2个选项", i+1))
-				result.Skipped++
-				continue
-			}
-			// 查找答案索引
-			answerIndex := -1
-			for j := range options {
-				if strings.ToUpper(item.Answer) == string(rune('A'+j)) {
-					answerIndex = j
-					break
-				}
-			}
-			if answerIndex == -1 {
-				result.Errors = append(result.Errors, fmt.Sprintf("第%d行：单选题答案格式错误", i+1))
-				result.Skipped++
-				continue
-			}
-			correctAnswer = answerIndex
-
-		case "multiple":
-			if len(options) < 2 {
-				result.Errors = append(result.Errors, fmt.Sprintf("第%d行：多选题至少需要2个选项", i+1))
-				result.Skipped++
-				continue
-			}
-			// 多选题答案存储为位掩码
-			answers := strings.Split(strings.ToUpper(item.Answer), ",")
-			mask := 0
-			for _, ans := range answers {
-				ans = strings.TrimSpace(ans)
-				if len(ans) == 1 && ans[0] >= 'A' && ans[0] <= 'Z' {
-					index := int(ans[0] - 'A')
-					if index < len(options) {
-						mask |= (1 << index)
-					}
-				}
-			}
-			if mask == 0 {
-				result.Errors = append(result.Errors, fmt.Sprintf("第%d行：多选题答案格式错误", i+1))
-				result.Skipped++
-				continue
-			}
-			correctAnswer = mask
-
-		case "judge":
-			options = []string{"正确", "错误"}
-			if strings.ToLower(item.Answer) == "true" || item.Answer == "正确" {
-				correctAnswer = 0
-			} else if strings.ToLower(item.Answer) == "false" || item.Answer == "错误" {
-				correctAnswer = 1
-			} else {
-				result.Errors = append(result.Errors, fmt.Sprintf("第%d行：判断题答案格式错误", i+1))
-				result.Skipped++
-				continue
-			}
-
-		case "fill":
-			options = []string{} // 填空题没有选项
-			correctAnswer = 0    // 填空题答案存储在explanation中
-		}
-
-		// 创建题目
-		question := models.Question{
-			Title:         item.Content[:min(len(item.Content), 100)], // 截取前100字符作为标题
-			Content:       item.Content,
-			Options:       models.JSONArray(options),
-			CorrectAnswer: correctAnswer,
-			Explanation:   item.Explanation,
-			Difficulty:    item.Difficulty,
-			CategoryID:    item.CategoryID,
-			CreatorID:     &creatorID,
-		}
-
-		// 填空题的答案存储在explanation中
-		if item.Type == "fill" {
-			if question.Explanation == "" {
-				question.Explanation = item.Answer
-			} else {
-				question.Explanation = item.Answer + "\n\n" + question.Explanation
-			}
-		}
-
-		if err := tx.Create(&question).Error; err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("第%d行：创建失败 - %v", i+1, err))
-			result.Skipped++
-			continue
-		}
-
-		result.Imported++
+		rows[i] = importRow{Row: i + 1, Item: item}
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
+	result, _, err := importRows(config.GetDB(), rows, req.Options, creatorID, func(row int, fieldErr *importFieldError) ImportRowError {
+		return describeImportError(nil, row, fieldErr)
+	})
+	if err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "导入失败")
 		return
 	}
 
 	SuccessResponse(c, result)
 }
-
-// updateExistingQuestion 更新现有题目
-func updateExistingQuestion(tx *gorm.DB, question *models.Question, item ImportQuestionItem) error {
-	// 处理选项和答案
-	options := item.Options
-	correctAnswer := 0
-
-	switch item.Type {
-	case "single":
-		for j := range options {
-			if strings.ToUpper(item.Answer) == string(rune('A'+j)) {
-				correctAnswer = j
-				break
-			}
-		}
-	case "multiple":
-		answers := strings.Split(strings.ToUpper(item.Answer), ",")
-		mask := 0
-		for _, ans := range answers {
-			ans = strings.TrimSpace(ans)
-			if len(ans) == 1 && ans[0] >= 'A' && ans[0] <= 'Z' {
-				index := int(ans[0] - 'A')
-				if index < len(options) {
-					mask |= (1 << index)
-				}
-			}
-		}
-		correctAnswer = mask
-	case "judge":
-		options = []string{"正确", "错误"}
-		if strings.ToLower(item.Answer) == "true" || item.Answer == "正确" {
-			correctAnswer = 0
-		} else {
-			correctAnswer = 1
-		}
-	case "fill":
-		options = []string{}
-		correctAnswer = 0
-	}
-
-	// 更新字段
-	question.Title = item.Content[:min(len(item.Content), 100)]
-	question.Content = item.Content
-	question.Options = models.JSONArray(options)
-	question.CorrectAnswer = correctAnswer
-	question.Explanation = item.Explanation
-	question.Difficulty = item.Difficulty
-	question.CategoryID = item.CategoryID
-
-	// 填空题的答案存储在explanation中
-	if item.Type == "fill" {
-		if question.Explanation == "" {
-			question.Explanation = item.Answer
-		} else {
-			question.Explanation = item.Answer + "\n\n" + question.Explanation
-		}
-	}
-
-	return tx.Save(question).Error
-}
-
-// min 返回两个整数中的较小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// ExportQuestions 导出题目（管理员）
-func ExportQuestions(c *gin.Context) {
-	// 获取查询参数
-	categoryID := c.Query("category_id")
-	difficulty := c.Query("difficulty")
-	keyword := c.Query("keyword")
-	creatorID := c.Query("creator_id")
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-
-	db := config.GetDB()
-	query := db.Model(&models.Question{})
-
-	// 添加查询条件
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
-	}
-	if difficulty != "" {
-		query = query.Where("difficulty = ?", difficulty)
-	}
-	if keyword != "" {
-		query = query.Where("title LIKE ? OR content LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
-	}
-	if creatorID != "" {
-		query = query.Where("creator_id = ?", creatorID)
-	}
-	if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
-	}
-
-	// 获取题目数据
-	var questions []models.Question
-	if err := query.Preload("Category").Preload("Creator").Order("created_at DESC").Find(&questions).Error; err != nil {
-		ErrorResponse(c, http.StatusInternalServerError, "获取题目数据失败")
-		return
-	}
-
-	// 转换为导出格式
-	exportData := make([]map[string]interface{}, 0, len(questions))
-	for _, question := range questions {
-		// 处理题目类型
-		questionType := "single"
-		if len(question.Options) == 0 {
-			questionType = "fill"
-		} else if len(question.Options) == 2 && question.Options[0] == "正确" && question.Options[1] == "错误" {
-			questionType = "judge"
-		} else {
-			// 检查是否为多选题（通过答案是否为位掩码判断）
-			if question.CorrectAnswer > len(question.Options)-1 {
-				questionType = "multiple"
-			}
-		}
-
-		// 处理答案格式
-		var answer string
-		switch questionType {
-		case "single":
-			if question.CorrectAnswer < len(question.Options) {
-				answer = string(rune('A' + question.CorrectAnswer))
-			}
-		case "multiple":
-			var answers []string
-			mask := question.CorrectAnswer
-			for i := 0; i < len(question.Options); i++ {
-				if mask&(1<<i) != 0 {
-					answers = append(answers, string(rune('A'+i)))
-				}
-			}
-			answer = strings.Join(answers, ",")
-		case "judge":
-			if question.CorrectAnswer == 0 {
-				answer = "true"
-			} else {
-				answer = "false"
-			}
-		case "fill":
-			// 填空题答案从explanation中提取
-			lines := strings.Split(question.Explanation, "\n")
-			if len(lines) > 0 {
-				answer = lines[0]
-			}
-		}
-
-		// 处理选项
-		optionMap := make(map[string]string)
-		optionKeys := []string{"选项A", "选项B", "选项C", "选项D", "选项E", "选项F"}
-		for i, key := range optionKeys {
-			if i < len(question.Options) {
-				optionMap[key] = question.Options[i]
-			} else {
-				optionMap[key] = ""
-			}
-		}
-
-		// 获取分类名称
-		categoryName := ""
-		if question.Category != nil {
-			categoryName = question.Category.Name
-		}
-
-		// 处理解析（移除答案部分）
-		explanation := question.Explanation
-		if questionType == "fill" && explanation != "" {
-			lines := strings.Split(explanation, "\n")
-			if len(lines) > 2 {
-				explanation = strings.Join(lines[2:], "\n")
-			} else {
-				explanation = ""
-			}
-		}
-
-		exportItem := map[string]interface{}{
-			"题目内容": question.Content,
-			"题目类型": questionType,
-			"难度等级": question.Difficulty,
-			"分类ID":  question.CategoryID,
-			"分类名称": categoryName,
-			"选项A":   optionMap["选项A"],
-			"选项B":   optionMap["选项B"],
-			"选项C":   optionMap["选项C"],
-			"选项D":   optionMap["选项D"],
-			"选项E":   optionMap["选项E"],
-			"选项F":   optionMap["选项F"],
-			"正确答案": answer,
-			"题目解析": explanation,
-			"创建时间": question.CreatedAt.Format("2006-01-02 15:04:05"),
-		}
-		exportData = append(exportData, exportItem)
-	}
-
-	// 设置响应头
-	c.Header("Content-Type", "application/json")
-	c.Header("Content-Disposition", "attachment; filename=questions.json")
-
-	// 返回JSON格式的数据
-	SuccessResponse(c, gin.H{
-		"data":  exportData,
-		"total": len(exportData),
-		"exported_at": fmt.Sprintf("%d", time.Now().Unix()),
-	})
-}
\ No newline at end of file
@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"qaminiprogram/xlsx"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 题目导出额外支持的格式
+const (
+	ExportFormatJSONL    = "jsonl"
+	ExportFormatMarkdown = "md"
+)
+
+// 题目类型和难度的中文名称，用于可打印的 Markdown
+var (
+	questionTypeLabels = map[string]string{
+		"single": "单选题", "multiple": "多选题", "judge": "判断题", "fill": "填空题",
+	}
+	questionDifficultyLabels = map[string]string{
+		"easy": "简单", "medium": "中等", "hard": "困难",
+	}
+)
+
+// QuestionExportRecord JSON Lines 导出的一行，题目字段与 ImportQuestionItem 一致，可直接用于 JSON 导入
+type QuestionExportRecord struct {
+	ID uint `json:"id"`
+	ImportQuestionItem
+	Category  string    `json:"category"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportQuestionHeader 表格导出的表头，选项列按 optionCount 生成（选项A、选项B……）
+// 表格导入按这些表头自动识别各列
+func exportQuestionHeader(optionCount int) []string {
+	header := []string{"题目ID", "题目标题", "题目内容", "题目类型", "难度等级", "分类ID", "分类名称"}
+	for i := 0; i < optionCount; i++ {
+		header = append(header, "选项"+xlsx.ColumnName(i))
+	}
+	return append(header, "正确答案", "题目解析", "创建时间")
+}
+
+// formatQuestionAnswer 按题目类型把答案格式化为导入时可解析的文本
+// 单选题为选项字母，多选题为逗号分隔的字母，判断题为正确/错误，填空题见 formatFillAnswers
+func formatQuestionAnswer(question models.Question) string {
+	switch question.Type {
+	case "multiple":
+		var letters []string
+		for i := range question.Options {
+			if question.CorrectAnswer&(1<<i) != 0 {
+				letters = append(letters, xlsx.ColumnName(i))
+			}
+		}
+		return strings.Join(letters, ",")
+	case "judge":
+		if question.CorrectAnswer == 0 {
+			return "正确"
+		}
+		return "错误"
+	case "fill":
+		return formatFillAnswers(question.FillAnswers)
+	}
+	if question.CorrectAnswer >= 0 && question.CorrectAnswer < len(question.Options) {
+		return xlsx.ColumnName(question.CorrectAnswer)
+	}
+	return ""
+}
+
+func questionCategoryName(question models.Question) string {
+	if question.Category != nil {
+		return question.Category.Name
+	}
+	return ""
+}
+
+// eachExportQuestion 按ID倒序分批读取题目，每批读取后调用 flush，避免一次载入全部题目
+func eachExportQuestion(query func() *gorm.DB, fn func(models.Question) error, flush func()) error {
+	var lastID uint
+	for {
+		batch := query().Preload("Category").Order("id DESC").Limit(exportFlushRows)
+		if lastID > 0 {
+			batch = batch.Where("id < ?", lastID)
+		}
+		var questions []models.Question
+		if err := batch.Find(&questions).Error; err != nil {
+			return err
+		}
+		for _, question := range questions {
+			if err := fn(question); err != nil {
+				return err
+			}
+		}
+		flush()
+		if len(questions) < exportFlushRows {
+			return nil
+		}
+		lastID = questions[len(questions)-1].ID
+	}
+}
+
+/**
+ * 导出题目（管理员）
+ * 筛选参数同 GetAdminQuestions；format 为 xlsx（默认）、csv、jsonl 或 md，结果边查询边写出
+ * md 为可打印的题目清单，answers=false 时不包含答案和解析
+ */
+func ExportQuestions(c *gin.Context) {
+	format := c.DefaultQuery("format", ExportFormatXLSX)
+	switch format {
+	case ExportFormatXLSX, ExportFormatCSV, ExportFormatJSONL, ExportFormatMarkdown:
+	default:
+		ErrorResponse(c, http.StatusBadRequest, "导出格式无效")
+		return
+	}
+
+	db := config.GetDB()
+	query := func() *gorm.DB {
+		return filterAdminQuestions(c, db.Model(&models.Question{}))
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取题目数据失败")
+		return
+	}
+
+	// 表格的选项列数取决于筛选出的题目中最多的选项数
+	var optionCount int
+	if format == ExportFormatXLSX || format == ExportFormatCSV {
+		if err := query().Select("COALESCE(MAX(JSON_LENGTH(options)), 0)").Scan(&optionCount).Error; err != nil {
+			ErrorResponse(c, http.StatusInternalServerError, "获取题目数据失败")
+			return
+		}
+	}
+
+	// 开始写出后无法再返回错误响应，出错时记录日志并中止，客户端收到的文件不完整
+	now := time.Now().In(config.Location())
+	filename := fmt.Sprintf("questions-%s.%s", now.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var err error
+	switch format {
+	case ExportFormatXLSX, ExportFormatCSV:
+		var w reportWriter
+		if format == ExportFormatCSV {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Status(http.StatusOK)
+			w = newCSVReportWriter(c, false)
+		} else {
+			c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			c.Status(http.StatusOK)
+			w = newXLSXReportWriter(c)
+		}
+		err = writeQuestionTable(w, query, optionCount)
+	case ExportFormatJSONL:
+		c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+		c.Status(http.StatusOK)
+		err = writeQuestionJSONL(c, query)
+	case ExportFormatMarkdown:
+		c.Header("Content-Type", "text/markdown; charset=utf-8")
+		c.Status(http.StatusOK)
+		err = writeQuestionMarkdown(c, query, total, now, c.Query("answers") != "false")
+	}
+	if err != nil {
+		log.Printf("导出题目失败: %v", err)
+		c.Abort()
+		return
+	}
+
+	LogOperation(c, "EXPORT", "QUESTION", fmt.Sprintf("导出题目 %d 道（%s）", total, format))
+}
+
+// writeQuestionTable 以表格导出，每道题一行
+func writeQuestionTable(w reportWriter, query func() *gorm.DB, optionCount int) error {
+	if err := w.BeginTable("题目", exportQuestionHeader(optionCount)...); err != nil {
+		return err
+	}
+	err := eachExportQuestion(query, func(question models.Question) error {
+		return w.WriteRow(exportQuestionRow(question, optionCount)...)
+	}, func() {})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// exportQuestionRow 表格导出的一行，各列与 exportQuestionHeader 对应
+func exportQuestionRow(question models.Question, optionCount int) []interface{} {
+	values := []interface{}{
+		question.ID, question.Title, question.Content, question.Type, question.Difficulty,
+		question.CategoryID, questionCategoryName(question),
+	}
+	for i := 0; i < optionCount; i++ {
+		option := ""
+		if i < len(question.Options) {
+			option = question.Options[i]
+		}
+		values = append(values, option)
+	}
+	return append(values, formatQuestionAnswer(question), question.Explanation,
+		question.CreatedAt.In(config.Location()))
+}
+
+// writeQuestionJSONL 以 JSON Lines 导出，每道题一行 QuestionExportRecord
+func writeQuestionJSONL(c *gin.Context, query func() *gorm.DB) error {
+	buf := bufio.NewWriter(c.Writer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	var flushErr error
+	err := eachExportQuestion(query, func(question models.Question) error {
+		options := question.Options
+		if options == nil {
+			options = models.JSONArray{}
+		}
+		return encoder.Encode(QuestionExportRecord{
+			ID: question.ID,
+			ImportQuestionItem: ImportQuestionItem{
+				Title:       question.Title,
+				Content:     question.Content,
+				Type:        question.Type,
+				Difficulty:  question.Difficulty,
+				CategoryID:  question.CategoryID,
+				Options:     options,
+				Answer:      formatQuestionAnswer(question),
+				Explanation: question.Explanation,
+			},
+			Category:  questionCategoryName(question),
+			CreatedAt: question.CreatedAt,
+		})
+	}, func() {
+		if flushErr == nil {
+			flushErr = buf.Flush()
+			c.Writer.Flush()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return flushErr
+	}
+	return buf.Flush()
+}
+
+// writeQuestionMarkdown 导出可打印的 Markdown 题目清单，includeAnswers 为 false 时只输出题目和选项
+func writeQuestionMarkdown(c *gin.Context, query func() *gorm.DB, total int64, now time.Time, includeAnswers bool) error {
+	buf := bufio.NewWriter(c.Writer)
+	fmt.Fprintf(buf, "# 题目清单\n\n共 %d 道题，导出时间 %s\n\n", total, now.Format("2006-01-02 15:04"))
+
+	number := 0
+	var flushErr error
+	err := eachExportQuestion(query, func(question models.Question) error {
+		number++
+		typeLabel := questionTypeLabels[question.Type]
+		if typeLabel == "" {
+			typeLabel = question.Type
+		}
+		fmt.Fprintf(buf, "---\n\n**%d.** [%s] %s\n\n", number, typeLabel, strings.TrimSpace(question.Content))
+
+		// 判断题的选项固定为正确/错误，不再列出
+		if question.Type != "judge" && len(question.Options) > 0 {
+			for i, option := range question.Options {
+				// 行尾两个空格为 Markdown 的换行
+				fmt.Fprintf(buf, "%s. %s  \n", xlsx.ColumnName(i), strings.Join(strings.Fields(option), " "))
+			}
+			buf.WriteString("\n")
+		}
+
+		difficulty := questionDifficultyLabels[question.Difficulty]
+		if difficulty == "" {
+			difficulty = question.Difficulty
+		}
+		fmt.Fprintf(buf, "分类：%s ｜ 难度：%s\n\n", questionCategoryName(question), difficulty)
+
+		if includeAnswers {
+			fmt.Fprintf(buf, "> **答案**：%s\n", formatQuestionAnswer(question))
+			if explanation := strings.TrimSpace(question.Explanation); explanation != "" {
+				fmt.Fprintf(buf, ">\n> **解析**：%s\n", strings.ReplaceAll(explanation, "\n", "\n> "))
+			}
+			buf.WriteString("\n")
+		}
+		return nil
+	}, func() {
+		if flushErr == nil {
+			flushErr = buf.Flush()
+			c.Writer.Flush()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return flushErr
+	}
+	return buf.Flush()
+}
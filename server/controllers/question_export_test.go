@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"qaminiprogram/models"
+	"qaminiprogram/xlsx"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func exportTestQuestions() []models.Question {
+	created := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	roads := &models.Category{ID: 3, Name: "道路"}
+	tunnels := &models.Category{ID: 4, Name: "桥隧"}
+	return []models.Question{
+		{
+			ID: 1, Title: "线形要素", Content: "公路纵断面的线形要素有()。", Type: "single", Difficulty: "easy",
+			Options: models.JSONArray{"缓和曲线", "缓和坡段", "回旋曲线", "竖曲线"}, CorrectAnswer: 3,
+			Explanation: "纵断面由直坡段和竖曲线组成", CategoryID: 3, Category: roads, CreatedAt: created,
+		},
+		{
+			ID: 2, Title: "封层作用", Content: "封层的作用有()\n（多选）", Type: "multiple", Difficulty: "hard",
+			Options:       models.JSONArray{"保水", "增加强度", "过渡联结", "表层联结", "防止水毁"},
+			CorrectAnswer: 0b10101, CategoryID: 3, Category: roads, CreatedAt: created,
+		},
+		{
+			ID: 3, Title: "基层", Content: "水泥稳定碎石常用作高级沥青路面的基层。()", Type: "judge", Difficulty: "medium",
+			Options: models.JSONArray{"正确", "错误"}, CorrectAnswer: 1, CategoryID: 4, Category: tunnels, CreatedAt: created,
+		},
+		{
+			ID: 4, Title: "长度", Content: "隧道长度超过____米为特长隧道，____为中隧道", Type: "fill", Difficulty: "medium",
+			Options: models.JSONArray{}, FillAnswers: models.FillAnswers{{"3000", "三千"}, {"500-1000米"}},
+			Explanation: "见规范", CategoryID: 4, Category: tunnels, CreatedAt: created,
+		},
+	}
+}
+
+// TestExportImportRoundTrip 导出的表格直接导入时得到相同的题目
+func TestExportImportRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	questions := exportTestQuestions()
+	const optionCount = 5
+
+	read := map[string]func(data []byte) ([][]string, error){
+		ExportFormatXLSX: func(data []byte) ([][]string, error) {
+			return xlsx.ReadFirstSheet(bytes.NewReader(data), int64(len(data)), 0)
+		},
+		ExportFormatCSV: func(data []byte) ([][]string, error) {
+			return readImportCSV(data, 100)
+		},
+	}
+
+	for format, readTable := range read {
+		t.Run(format, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			var w reportWriter
+			if format == ExportFormatCSV {
+				w = newCSVReportWriter(c, false)
+			} else {
+				w = newXLSXReportWriter(c)
+			}
+			if err := w.BeginTable("题目", exportQuestionHeader(optionCount)...); err != nil {
+				t.Fatal(err)
+			}
+			for _, question := range questions {
+				if err := w.WriteRow(exportQuestionRow(question, optionCount)...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := readTable(recorder.Body.Bytes())
+			if err != nil {
+				t.Fatalf("read exported table: %v", err)
+			}
+			if len(rows) != len(questions)+1 {
+				t.Fatalf("exported %d rows, want %d", len(rows), len(questions)+1)
+			}
+
+			columns := detectImportColumns(rows[0])
+			if len(columns.options) != optionCount {
+				t.Fatalf("detected %d option columns, want %d", len(columns.options), optionCount)
+			}
+			resolver := newImportCategoryResolver([]models.Category{{ID: 3, Name: "道路"}, {ID: 4, Name: "桥隧"}})
+			for i, row := range rows[1:] {
+				item, fieldErr := importRowItem(row, columns, resolver, 0)
+				if fieldErr != nil {
+					t.Fatalf("row %d: importRowItem() error = %+v", i+2, fieldErr)
+				}
+				got, fieldErr := buildImportedQuestion(item)
+				if fieldErr != nil {
+					t.Fatalf("row %d: buildImportedQuestion() error = %+v", i+2, fieldErr)
+				}
+
+				want := questions[i]
+				want.ID, want.Category, want.CreatedAt = 0, nil, time.Time{}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("row %d round-trip =\n%+v\nwant\n%+v", i+2, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatQuestionAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		question models.Question
+		want     string
+	}{
+		{"单选", models.Question{Type: "single", Options: models.JSONArray{"a", "b", "c"}, CorrectAnswer: 1}, "B"},
+		{"单选越界", models.Question{Type: "single", Options: models.JSONArray{"a"}, CorrectAnswer: 3}, ""},
+		{"多选", models.Question{Type: "multiple", Options: models.JSONArray{"a", "b", "c", "d"}, CorrectAnswer: 0b1011}, "A,B,D"},
+		{"判断正确", models.Question{Type: "judge", CorrectAnswer: 0}, "正确"},
+		{"判断错误", models.Question{Type: "judge", CorrectAnswer: 1}, "错误"},
+		{"填空", models.Question{Type: "fill", FillAnswers: models.FillAnswers{{"a", "b"}, {"c"}}}, "a|b;c"},
+	}
+	for _, tt := range tests {
+		if got := formatQuestionAnswer(tt.question); got != tt.want {
+			t.Errorf("%s: formatQuestionAnswer() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"qaminiprogram/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 导入单道题目的结果
+const (
+	importActionCreated = "created"
+	importActionUpdated = "updated"
+	importActionSkipped = "skipped"
+)
+
+// 题目标题最多截取内容的字符数
+const importTitleLength = 100
+
+// 预览时返回的行数
+const importPreviewRowCount = 20
+
+// ImportRowError 导入时某一行的错误，Row 从1开始
+// 表格导入时 Column 为列名（如 C）、Header 为表头，JSON 导入时两者为空
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Header  string `json:"header,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportPreviewRow 预览时的一行，Action 为 created/updated/skipped，出错时为空
+type ImportPreviewRow struct {
+	Row    int                `json:"row"`
+	Action string             `json:"action"`
+	Item   ImportQuestionItem `json:"item"`
+}
+
+// importRow 从文件中解析出的一道题目，Err 为解析时的错误
+type importRow struct {
+	Row  int
+	Item ImportQuestionItem
+	Err  *importFieldError
+}
+
+// 导入来源
+const (
+	importSourceJSON = "json"
+	importSourceFile = "file"
+	importSourceText = "text"
+)
+
+// importSource 从请求中解析出的待导入题目
+// 表格导入时 Headers、Mapping 为识别出的表头和列映射，Columns 为各字段所在的列；文本导入时 Warnings 为被忽略的行
+type importSource struct {
+	Source   string
+	Filename string
+	Rows     []importRow
+	Headers  []ImportColumn
+	Mapping  *ImportColumnMapping
+	Columns  map[string]ImportColumn
+	Warnings []ImportRowError
+}
+
+// describe 把字段错误转换为行错误，表格导入时标注所在的列
+func (s *importSource) describe(row int, fieldErr *importFieldError) ImportRowError {
+	return describeImportError(s.Columns, row, fieldErr)
+}
+
+func describeImportError(columns map[string]ImportColumn, row int, fieldErr *importFieldError) ImportRowError {
+	rowErr := ImportRowError{Row: row, Field: fieldErr.Field, Message: fieldErr.Message}
+	if column, ok := columns[fieldErr.Field]; ok {
+		rowErr.Column, rowErr.Header = column.Column, column.Header
+		if rowErr.Column != "" {
+			rowErr.Message = fmt.Sprintf("%s列（%s）：%s", rowErr.Column, rowErr.Header, rowErr.Message)
+		}
+	}
+	return rowErr
+}
+
+// importInputError 上传的内容或参数有误，作为 400 错误返回
+type importInputError string
+
+func (e importInputError) Error() string {
+	return string(e)
+}
+
+func inputErrorf(format string, args ...interface{}) error {
+	return importInputError(fmt.Sprintf(format, args...))
+}
+
+// respondImportError 上传内容有误时返回 400 和具体原因，其他错误返回 500
+func respondImportError(c *gin.Context, err error) {
+	var inputErr importInputError
+	if errors.As(err, &inputErr) {
+		ErrorResponse(c, http.StatusBadRequest, inputErr.Error())
+		return
+	}
+	ErrorResponse(c, http.StatusInternalServerError, "读取导入内容失败")
+}
+
+// parseFormBool 解析表单中的布尔值，未填写时为 false
+func parseFormBool(c *gin.Context, name string) (bool, error) {
+	value := c.PostForm(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// parseImportForm 解析表单中的 skipDuplicates、updateExisting 和 dryRun
+func parseImportForm(c *gin.Context) (ImportOptions, error) {
+	var options ImportOptions
+	for _, field := range []struct {
+		name   string
+		target *bool
+	}{
+		{"skipDuplicates", &options.SkipDuplicates},
+		{"updateExisting", &options.UpdateExisting},
+		{"dryRun", &options.DryRun},
+	} {
+		value, err := parseFormBool(c, field.name)
+		if err != nil {
+			return options, fmt.Errorf("参数 %s 无效", field.name)
+		}
+		*field.target = value
+	}
+	return options, nil
+}
+
+// importFieldError 题目某个字段的错误，Field 为导入字段名
+type importFieldError struct {
+	Field   string
+	Message string
+}
+
+func fieldError(field, format string, args ...interface{}) *importFieldError {
+	return &importFieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// addError 记录一行错误，该行计为跳过
+func (r *ImportResult) addError(rowErr ImportRowError) {
+	r.Errors = append(r.Errors, fmt.Sprintf("第%d行：%s", rowErr.Row, rowErr.Message))
+	r.RowErrors = append(r.RowErrors, rowErr)
+	r.Skipped++
+}
+
+// addAction 按导入结果计数
+func (r *ImportResult) addAction(action string) {
+	switch action {
+	case importActionCreated:
+		r.Created++
+		r.Imported++
+	case importActionUpdated:
+		r.Updated++
+		r.Imported++
+	default:
+		r.Skipped++
+	}
+}
+
+// 题目类型和难度的中文写法
+var (
+	importTypeAliases = map[string]string{
+		"single": "single", "单选": "single", "单选题": "single", "单项选择题": "single",
+		"multiple": "multiple", "多选": "multiple", "多选题": "multiple", "多项选择题": "multiple", "不定项选择题": "multiple",
+		"judge": "judge", "判断": "judge", "判断题": "judge", "是非题": "judge",
+		"fill": "fill", "填空": "fill", "填空题": "fill",
+	}
+	importDifficultyAliases = map[string]string{
+		"easy": "easy", "简单": "easy", "容易": "easy",
+		"medium": "medium", "中等": "medium", "一般": "medium",
+		"hard": "hard", "困难": "hard", "难": "hard",
+	}
+	importJudgeAnswers = map[string]int{
+		"true": 0, "t": 0, "正确": 0, "对": 0, "是": 0, "√": 0, "✓": 0,
+		"false": 1, "f": 1, "错误": 1, "错": 1, "否": 1, "×": 1, "✗": 1,
+	}
+)
+
+// buildImportedQuestion 校验导入的题目并转换为题目模型，不检查分类是否存在
+// 末尾的空选项会被去掉，导出文件中固定的选项列因此可以直接导入
+func buildImportedQuestion(item ImportQuestionItem) (models.Question, *importFieldError) {
+	var question models.Question
+
+	content := strings.TrimSpace(item.Content)
+	if content == "" {
+		return question, fieldError("content", "题目内容不能为空")
+	}
+
+	options := make([]string, 0, len(item.Options))
+	for _, option := range item.Options {
+		options = append(options, strings.TrimSpace(option))
+	}
+	for len(options) > 0 && options[len(options)-1] == "" {
+		options = options[:len(options)-1]
+	}
+
+	questionType := strings.ToLower(strings.TrimSpace(item.Type))
+	if questionType == "" {
+		questionType = inferImportType(options, item.Answer)
+	}
+	questionType, ok := importTypeAliases[questionType]
+	if !ok {
+		return question, fieldError("type", "题目类型无效")
+	}
+
+	difficulty := strings.ToLower(strings.TrimSpace(item.Difficulty))
+	if difficulty == "" {
+		difficulty = "medium"
+	}
+	if difficulty, ok = importDifficultyAliases[difficulty]; !ok {
+		return question, fieldError("difficulty", "难度等级无效")
+	}
+
+	answer := strings.TrimSpace(item.Answer)
+	correctAnswer := 0
+	var fillAnswers models.FillAnswers
+
+	switch questionType {
+	case "single", "multiple":
+		label := "单选题"
+		if questionType == "multiple" {
+			label = "多选题"
+		}
+		if len(options) < 2 {
+			return question, fieldError("options", "%s至少需要2个选项", label)
+		}
+		for i, option := range options {
+			if option == "" {
+				return question, fieldError("options", "选项%s为空", string(rune('A'+i)))
+			}
+		}
+		indexes, err := parseAnswerLetters(answer, len(options))
+		if err != nil {
+			return question, fieldError("answer", "%s答案格式错误 - %v", label, err)
+		}
+		if questionType == "single" {
+			if len(indexes) != 1 {
+				return question, fieldError("answer", "单选题答案格式错误 - 只能有一个答案")
+			}
+			correctAnswer = indexes[0]
+		} else {
+			for _, index := range indexes {
+				correctAnswer |= 1 << index
+			}
+		}
+
+	case "judge":
+		options = []string{"正确", "错误"}
+		index, ok := importJudgeAnswers[strings.ToLower(answer)]
+		if !ok {
+			return question, fieldError("answer", "判断题答案格式错误")
+		}
+		correctAnswer = index
+
+	case "fill":
+		options = []string{} // 填空题没有选项
+		parsed, err := parseFillAnswers(answer)
+		if err != nil {
+			return question, fieldError("answer", "填空题答案格式错误 - %v", err)
+		}
+		fillAnswers = parsed
+	}
+
+	title := strings.TrimSpace(item.Title)
+	if title == "" {
+		title = truncateRunes(content, importTitleLength)
+	}
+
+	question = models.Question{
+		Title:         title,
+		Content:       content,
+		Type:          questionType,
+		Options:       models.JSONArray(options),
+		CorrectAnswer: correctAnswer,
+		FillAnswers:   fillAnswers,
+		Explanation:   strings.TrimSpace(item.Explanation),
+		Difficulty:    difficulty,
+		CategoryID:    item.CategoryID,
+	}
+	return question, nil
+}
+
+// inferImportType 未填写题目类型时按选项和答案推断
+func inferImportType(options []string, answer string) string {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if len(options) == 0 {
+		if _, ok := importJudgeAnswers[answer]; ok {
+			return "judge"
+		}
+		return "fill"
+	}
+	if indexes, err := parseAnswerLetters(answer, len(options)); err == nil && len(indexes) > 1 {
+		return "multiple"
+	}
+	return "single"
+}
+
+// parseAnswerLetters 解析字母形式的答案，如 "A"、"ABD"、"A,B"、"A、B"，返回去重后的选项下标
+func parseAnswerLetters(answer string, optionCount int) ([]int, error) {
+	var indexes []int
+	seen := make(map[int]bool)
+	for _, r := range strings.ToUpper(answer) {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			index := int(r - 'A')
+			if index >= optionCount {
+				return nil, fmt.Errorf("答案%c超出选项范围", r)
+			}
+			if !seen[index] {
+				seen[index] = true
+				indexes = append(indexes, index)
+			}
+		case strings.ContainsRune(" ,，、;；|.", r):
+		default:
+			return nil, fmt.Errorf("答案应为选项字母")
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("答案为空")
+	}
+	return indexes, nil
+}
+
+// truncateRunes 按字符截取前 n 个字符
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// questionImporter 在一个事务中逐道导入题目
+type questionImporter struct {
+	tx         *gorm.DB
+	options    ImportOptions
+	creatorID  uint
+	categories map[uint]bool
+
+	// 分批只校验时前面批次“新增”的题目已随保存点回滚，查重时按内容和分类在这里补查，为空时不记录
+	dryRunCreated map[importQuestionKey]bool
+}
+
+// importQuestionKey 查重使用的题目内容和分类
+type importQuestionKey struct {
+	Content    string
+	CategoryID uint
+}
+
+func newQuestionImporter(tx *gorm.DB, options ImportOptions, creatorID uint) *questionImporter {
+	return &questionImporter{tx: tx, options: options, creatorID: creatorID, categories: make(map[uint]bool)}
+}
+
+// categoryExists 检查分类是否存在，结果按分类缓存
+func (im *questionImporter) categoryExists(id uint) bool {
+	exists, ok := im.categories[id]
+	if !ok {
+		var count int64
+		im.tx.Model(&models.Category{}).Where("id = ?", id).Count(&count)
+		exists = count > 0
+		im.categories[id] = exists
+	}
+	return exists
+}
+
+// importItem 导入一道题目
+// 开启 SkipDuplicates 时，同一分类下内容相同的题目视为重复：开启 UpdateExisting 则更新该题，否则跳过
+func (im *questionImporter) importItem(item ImportQuestionItem) (string, *importFieldError) {
+	question, fieldErr := buildImportedQuestion(item)
+	if fieldErr != nil {
+		return "", fieldErr
+	}
+
+	if question.CategoryID == 0 || !im.categoryExists(question.CategoryID) {
+		return "", fieldError("category", "分类不存在")
+	}
+
+	if im.options.SkipDuplicates {
+		var existing models.Question
+		err := im.tx.Where("content = ? AND category_id = ?", question.Content, question.CategoryID).First(&existing).Error
+		if err == nil {
+			if !im.options.UpdateExisting {
+				return importActionSkipped, nil
+			}
+			if err := updateExistingQuestion(im.tx, &existing, question); err != nil {
+				return "", fieldError("", "更新失败 - %v", err)
+			}
+			return importActionUpdated, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fieldError("", "检查重复失败 - %v", err)
+		}
+		// 实际导入时这道题会更新前面批次新增的题目
+		if im.dryRunCreated[importQuestionKey{question.Content, question.CategoryID}] {
+			if !im.options.UpdateExisting {
+				return importActionSkipped, nil
+			}
+			return importActionUpdated, nil
+		}
+	}
+
+	question.CreatorID = &im.creatorID
+	if err := im.tx.Create(&question).Error; err != nil {
+		return "", fieldError("", "创建失败 - %v", err)
+	}
+	if im.dryRunCreated != nil {
+		im.dryRunCreated[importQuestionKey{question.Content, question.CategoryID}] = true
+	}
+	return importActionCreated, nil
+}
+
+// updateExistingQuestion 用导入的内容更新现有题目
+func updateExistingQuestion(tx *gorm.DB, existing *models.Question, imported models.Question) error {
+	existing.Title = imported.Title
+	existing.Content = imported.Content
+	existing.Type = imported.Type
+	existing.Options = imported.Options
+	existing.CorrectAnswer = imported.CorrectAnswer
+	existing.FillAnswers = imported.FillAnswers
+	existing.Explanation = imported.Explanation
+	existing.Difficulty = imported.Difficulty
+	existing.CategoryID = imported.CategoryID
+
+	return tx.Save(existing).Error
+}
+
+// importRows 在一个事务中逐行导入，DryRun 时只校验并回滚，同时返回前若干行的预览
+// describe 把字段错误转换为带位置信息的行错误
+func importRows(db *gorm.DB, rows []importRow, options ImportOptions, creatorID uint,
+	describe func(row int, fieldErr *importFieldError) ImportRowError) (ImportResult, []ImportPreviewRow, error) {
+	result := ImportResult{Errors: []string{}}
+	var preview []ImportPreviewRow
+
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	importer := newQuestionImporter(tx, options, creatorID)
+	for _, row := range rows {
+		action, fieldErr := "", row.Err
+		if fieldErr == nil {
+			action, fieldErr = importer.importItem(row.Item)
+		}
+		if fieldErr != nil {
+			result.addError(describe(row.Row, fieldErr))
+		} else {
+			result.addAction(action)
+		}
+
+		if options.DryRun && len(preview) < importPreviewRowCount {
+			preview = append(preview, ImportPreviewRow{Row: row.Row, Action: action, Item: row.Item})
+		}
+	}
+
+	if options.DryRun {
+		return result, preview, tx.Rollback().Error
+	}
+	return result, nil, tx.Commit().Error
+}
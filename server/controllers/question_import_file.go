@@ -0,0 +1,629 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"qaminiprogram/xlsx"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 表格导入的限制
+const (
+	importMaxFileSize = 10 << 20
+	importMaxRows     = 2000
+)
+
+// 导入字段，与 ImportColumnMapping 的 JSON 字段名一致
+const (
+	importFieldTitle       = "title"
+	importFieldContent     = "content"
+	importFieldType        = "type"
+	importFieldDifficulty  = "difficulty"
+	importFieldCategoryID  = "categoryId"
+	importFieldCategory    = "category"
+	importFieldAnswer      = "answer"
+	importFieldExplanation = "explanation"
+	importFieldOptions     = "options"
+)
+
+// 自动识别时每个字段可用的表头，比较时忽略大小写和空白；第一组与 ExportQuestions 的字段一致
+var importHeaderAliases = map[string][]string{
+	importFieldTitle:       {"题目标题", "标题", "title"},
+	importFieldContent:     {"题目内容", "题目", "题干", "内容", "content", "question"},
+	importFieldType:        {"题目类型", "题型", "类型", "type"},
+	importFieldDifficulty:  {"难度等级", "难度", "difficulty"},
+	importFieldCategoryID:  {"分类ID", "分类编号", "category_id", "categoryid"},
+	importFieldCategory:    {"分类名称", "分类", "分类路径", "category"},
+	importFieldAnswer:      {"正确答案", "答案", "answer"},
+	importFieldExplanation: {"题目解析", "解析", "答案解析", "explanation"},
+}
+
+// 选项列的表头，如 "选项A"、"A"、"Option B"
+var importOptionHeader = regexp.MustCompile(`^(?:选项|option)?([a-z])$`)
+
+// ImportColumnMapping 字段到列的映射，值为表头或列名（如 C），"-" 表示不导入该字段
+// 请求中只需填写要覆盖的字段，其余字段按表头自动识别；响应中的值均为列名
+type ImportColumnMapping struct {
+	Title       string   `json:"title,omitempty"`
+	Content     string   `json:"content,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Difficulty  string   `json:"difficulty,omitempty"`
+	CategoryID  string   `json:"categoryId,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Answer      string   `json:"answer,omitempty"`
+	Explanation string   `json:"explanation,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// ImportColumn 表格中的一列
+type ImportColumn struct {
+	Column string `json:"column"`
+	Header string `json:"header"`
+}
+
+// ImportFileResponse 表格导入结果
+type ImportFileResponse struct {
+	DryRun  bool                `json:"dryRun"`
+	Rows    int                 `json:"rows"`
+	Headers []ImportColumn      `json:"headers"`
+	Mapping ImportColumnMapping `json:"mapping"`
+	Result  ImportResult        `json:"result"`
+	Preview []ImportPreviewRow  `json:"preview,omitempty"`
+}
+
+// importColumns 解析后的列映射，值为从0开始的列号，-1 表示不导入
+type importColumns struct {
+	fields  map[string]int
+	options []int
+}
+
+func (m *ImportColumnMapping) fields() []struct {
+	name   string
+	column *string
+} {
+	return []struct {
+		name   string
+		column *string
+	}{
+		{importFieldTitle, &m.Title},
+		{importFieldContent, &m.Content},
+		{importFieldType, &m.Type},
+		{importFieldDifficulty, &m.Difficulty},
+		{importFieldCategoryID, &m.CategoryID},
+		{importFieldCategory, &m.Category},
+		{importFieldAnswer, &m.Answer},
+		{importFieldExplanation, &m.Explanation},
+	}
+}
+
+// normalizeImportHeader 去掉表头中的空白并转为小写
+func normalizeImportHeader(header string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '\ufeff' {
+			return -1
+		}
+		return r
+	}, header))
+}
+
+// detectImportColumns 按表头自动识别各字段所在的列，同一字段有多列匹配时取别名靠前的一列
+func detectImportColumns(headers []string) importColumns {
+	columns := importColumns{fields: make(map[string]int)}
+	normalized := make([]string, len(headers))
+	for i, header := range headers {
+		normalized[i] = normalizeImportHeader(header)
+	}
+
+	for field, aliases := range importHeaderAliases {
+		columns.fields[field] = -1
+	detect:
+		for _, alias := range aliases {
+			alias = normalizeImportHeader(alias)
+			for i, header := range normalized {
+				if header == alias {
+					columns.fields[field] = i
+					break detect
+				}
+			}
+		}
+	}
+
+	// 选项列按字母排序，缺少的字母之后的列不再识别
+	letters := make(map[int]int)
+	for i, header := range normalized {
+		if match := importOptionHeader.FindStringSubmatch(header); match != nil {
+			if _, ok := letters[int(match[1][0]-'a')]; !ok {
+				letters[int(match[1][0]-'a')] = i
+			}
+		}
+	}
+	for index := 0; ; index++ {
+		column, ok := letters[index]
+		if !ok {
+			break
+		}
+		columns.options = append(columns.options, column)
+	}
+	return columns
+}
+
+// resolveImportColumn 把表头或列名解析为列号，表头优先
+func resolveImportColumn(headers []string, value string) (int, bool) {
+	if value == "-" {
+		return -1, true
+	}
+	normalized := normalizeImportHeader(value)
+	for i, header := range headers {
+		if normalizeImportHeader(header) == normalized {
+			return i, true
+		}
+	}
+	if index, ok := xlsx.ColumnIndex(strings.TrimSpace(value)); ok {
+		return index, true
+	}
+	return 0, false
+}
+
+// applyImportMapping 用请求中的映射覆盖自动识别的结果
+func applyImportMapping(columns importColumns, headers []string, mapping ImportColumnMapping) (importColumns, error) {
+	for _, field := range mapping.fields() {
+		if *field.column == "" {
+			continue
+		}
+		index, ok := resolveImportColumn(headers, *field.column)
+		if !ok {
+			return columns, fmt.Errorf("字段 %s 映射的列 %s 不存在", field.name, *field.column)
+		}
+		columns.fields[field.name] = index
+	}
+	if len(mapping.Options) > 0 {
+		columns.options = nil
+		for _, value := range mapping.Options {
+			index, ok := resolveImportColumn(headers, value)
+			if !ok || index < 0 {
+				return columns, fmt.Errorf("选项映射的列 %s 不存在", value)
+			}
+			columns.options = append(columns.options, index)
+		}
+	}
+	return columns, nil
+}
+
+// mapping 以列名表示的映射，用于返回给前端
+func (columns importColumns) mapping() ImportColumnMapping {
+	var mapping ImportColumnMapping
+	for _, field := range mapping.fields() {
+		if index := columns.fields[field.name]; index >= 0 {
+			*field.column = xlsx.ColumnName(index)
+		}
+	}
+	for _, index := range columns.options {
+		mapping.Options = append(mapping.Options, xlsx.ColumnName(index))
+	}
+	return mapping
+}
+
+// fieldColumns 各字段所在列的列名和表头，用于在错误中标注位置
+// 选项分布在多列，只标注表头；分类名称列未映射时分类错误标注在分类ID列
+func (columns importColumns) fieldColumns(headers []string) map[string]ImportColumn {
+	result := make(map[string]ImportColumn)
+	for field, index := range columns.fields {
+		if index < 0 {
+			continue
+		}
+		header := ""
+		if index < len(headers) {
+			header = headers[index]
+		}
+		result[field] = ImportColumn{Column: xlsx.ColumnName(index), Header: header}
+	}
+	if _, ok := result[importFieldCategory]; !ok {
+		if column, ok := result[importFieldCategoryID]; ok {
+			result[importFieldCategory] = column
+		}
+	}
+	if len(columns.options) > 0 {
+		result[importFieldOptions] = ImportColumn{Header: "选项"}
+	}
+	return result
+}
+
+// readImportTable 读取上传的表格，返回的行号与表格一致，rows[i] 为第 i+1 行
+func readImportTable(file *multipart.FileHeader, maxRows int) ([][]string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".xlsx":
+		// 表头前可能有说明行，多留一些余量
+		rows, err := xlsx.ReadFirstSheet(f, file.Size, maxRows+10)
+		if errors.Is(err, xlsx.ErrTooManyRows) {
+			return nil, fmt.Errorf("一次最多导入%d行", maxRows)
+		}
+		return rows, err
+	case ".csv":
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return readImportCSV(data, maxRows)
+	case ".json":
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return readImportJSON(data, maxRows)
+	}
+	return nil, errors.New("只支持 xlsx、csv 和 json 文件")
+}
+
+// readImportCSV 读取 CSV，支持带 BOM 的 UTF-8、UTF-16 和 Excel 默认保存的 GBK/GB18030 编码
+// 每条记录计为一行，与表格软件打开后的行号一致
+func readImportCSV(data []byte, maxRows int) ([][]string, error) {
+	data, err := decodeImportText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV 格式错误: %v", err)
+		}
+		if len(rows) > maxRows+10 {
+			return nil, fmt.Errorf("一次最多导入%d行", maxRows)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// readImportJSON 读取 JSON 格式的题目数组（如早期版本导出的 JSON），接受完整响应、其中的 data 或题目数组
+// 转换为表格，第一行为表头
+func readImportJSON(data []byte, maxRows int) ([][]string, error) {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.New("JSON 格式错误")
+	}
+	for {
+		object, ok := payload.(map[string]interface{})
+		if !ok {
+			break
+		}
+		payload = object["data"]
+	}
+	items, ok := payload.([]interface{})
+	if !ok {
+		return nil, errors.New("JSON 中没有题目数组")
+	}
+	if len(items) > maxRows {
+		return nil, fmt.Errorf("一次最多导入%d行", maxRows)
+	}
+
+	// 已知字段按导出表格的列顺序排列，其余字段按名称排序
+	columns := exportQuestionHeader(26)
+	known := make(map[string]bool)
+	for _, key := range columns {
+		known[key] = true
+	}
+	present := make(map[string]bool)
+	var extra []string
+	objects := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("JSON 中的题目应为对象")
+		}
+		for key := range object {
+			if !present[key] && !known[key] {
+				extra = append(extra, key)
+			}
+			present[key] = true
+		}
+		objects = append(objects, object)
+	}
+	sort.Strings(extra)
+	var headers []string
+	for _, key := range columns {
+		if present[key] {
+			headers = append(headers, key)
+		}
+	}
+	headers = append(headers, extra...)
+
+	rows := [][]string{headers}
+	for _, object := range objects {
+		row := make([]string, len(headers))
+		for i, key := range headers {
+			row[i] = formatReportValue(object[key])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importCategoryResolver 按ID、名称或路径查找分类，路径用 "/" 或 ">" 分隔，如 "数学/代数"
+type importCategoryResolver struct {
+	byID   map[uint]bool
+	byName map[string][]uint
+	byPath map[string]uint
+}
+
+func newImportCategoryResolver(categories []models.Category) *importCategoryResolver {
+	resolver := &importCategoryResolver{
+		byID:   make(map[uint]bool, len(categories)),
+		byName: make(map[string][]uint),
+		byPath: make(map[string]uint, len(categories)),
+	}
+	parents := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		parents[categories[i].ID] = &categories[i]
+	}
+	for _, category := range categories {
+		resolver.byID[category.ID] = true
+		name := strings.TrimSpace(category.Name)
+		resolver.byName[name] = append(resolver.byName[name], category.ID)
+
+		// 沿父分类拼出完整路径，深度受限以防数据中存在循环
+		names := []string{name}
+		parentID := category.ParentID
+		for depth := 0; parentID != nil && depth < 20; depth++ {
+			parent, ok := parents[*parentID]
+			if !ok {
+				break
+			}
+			names = append([]string{strings.TrimSpace(parent.Name)}, names...)
+			parentID = parent.ParentID
+		}
+		resolver.byPath[strings.Join(names, "/")] = category.ID
+	}
+	return resolver
+}
+
+// resolveName 按名称或路径查找分类
+func (r *importCategoryResolver) resolveName(value string) (uint, error) {
+	if strings.ContainsAny(value, "/>") {
+		parts := strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '>' })
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if id, ok := r.byPath[strings.Join(parts, "/")]; ok {
+			return id, nil
+		}
+		return 0, fmt.Errorf("分类路径 %s 不存在", value)
+	}
+	ids := r.byName[value]
+	switch len(ids) {
+	case 0:
+		return 0, fmt.Errorf("分类 %s 不存在", value)
+	case 1:
+		return ids[0], nil
+	}
+	return 0, fmt.Errorf("分类名称 %s 不唯一，请填写分类路径或分类ID", value)
+}
+
+// resolveID 按ID查找分类
+func (r *importCategoryResolver) resolveID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || !r.byID[uint(id)] {
+		return 0, fmt.Errorf("分类ID %s 不存在", value)
+	}
+	return uint(id), nil
+}
+
+// isSpreadsheetFile 按扩展名判断是否为表格导入的文件
+func isSpreadsheetFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx", ".csv", ".json":
+		return true
+	}
+	return false
+}
+
+// readSpreadsheetSource 读取表格并按列映射转换为待导入的题目
+// 表单字段：mapping 列映射（JSON，见 ImportColumnMapping）；categoryId 未填写分类时使用的分类
+func readSpreadsheetSource(c *gin.Context, file *multipart.FileHeader, maxRows int) (*importSource, error) {
+	if file.Size > importMaxFileSize {
+		return nil, inputErrorf("文件不能超过%dMB", importMaxFileSize>>20)
+	}
+
+	var mapping ImportColumnMapping
+	if value := c.PostForm("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return nil, inputErrorf("列映射格式错误")
+		}
+	}
+
+	rows, err := readImportTable(file, maxRows)
+	if err != nil {
+		return nil, importInputError(err.Error())
+	}
+
+	// 第一个非空行为表头
+	headerIndex := -1
+	for i, row := range rows {
+		if !isBlankRow(row) {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, inputErrorf("文件中没有数据")
+	}
+	headers := rows[headerIndex]
+
+	columns, err := applyImportMapping(detectImportColumns(headers), headers, mapping)
+	if err != nil {
+		return nil, importInputError(err.Error())
+	}
+	if columns.fields[importFieldContent] < 0 {
+		return nil, inputErrorf("未找到题目内容列，请指定列映射")
+	}
+	if columns.fields[importFieldAnswer] < 0 {
+		return nil, inputErrorf("未找到正确答案列，请指定列映射")
+	}
+
+	var categories []models.Category
+	if err := config.GetDB().Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	resolver := newImportCategoryResolver(categories)
+	var defaultCategoryID uint
+	if value := strings.TrimSpace(c.PostForm("categoryId")); value != "" {
+		if defaultCategoryID, err = resolver.resolveID(value); err != nil {
+			return nil, inputErrorf("默认分类不存在")
+		}
+	}
+
+	mapped := columns.mapping()
+	source := &importSource{
+		Source:   importSourceFile,
+		Filename: file.Filename,
+		Mapping:  &mapped,
+		Columns:  columns.fieldColumns(headers),
+	}
+	for i, header := range headers {
+		source.Headers = append(source.Headers, ImportColumn{Column: xlsx.ColumnName(i), Header: header})
+	}
+	for i := headerIndex + 1; i < len(rows); i++ {
+		if isBlankRow(rows[i]) {
+			continue
+		}
+		if len(source.Rows) >= maxRows {
+			return nil, inputErrorf("一次最多导入%d行", maxRows)
+		}
+		item, fieldErr := importRowItem(rows[i], columns, resolver, defaultCategoryID)
+		source.Rows = append(source.Rows, importRow{Row: i + 1, Item: item, Err: fieldErr})
+	}
+	return source, nil
+}
+
+/**
+ * 从 XLSX、CSV 或导出的 JSON 文件导入题目（管理员）
+ * 表单字段：file 文件；mapping 列映射（JSON，见 ImportColumnMapping）；categoryId 未填写分类时使用的分类；
+ * skipDuplicates、updateExisting 同 ImportQuestions；dryRun 为 true 时只校验并返回预览，不写入数据
+ * 第一个非空行为表头，错误中的行号与表格中的行号一致
+ */
+func ImportQuestionsFromFile(c *gin.Context) {
+	options, err := parseImportForm(c)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	creatorID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "无法获取用户信息")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "请上传文件")
+		return
+	}
+	source, err := readSpreadsheetSource(c, file, importMaxRows)
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+
+	response := ImportFileResponse{
+		DryRun:  options.DryRun,
+		Rows:    len(source.Rows),
+		Headers: source.Headers,
+		Mapping: *source.Mapping,
+	}
+	response.Result, response.Preview, err = importRows(config.GetDB(), source.Rows, options, creatorID, source.describe)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "导入失败")
+		return
+	}
+	if options.DryRun {
+		SuccessResponse(c, response)
+		return
+	}
+
+	LogOperation(c, "IMPORT", "QUESTION", fmt.Sprintf("从文件 %s 导入题目：新增 %d 道，更新 %d 道，跳过 %d 行",
+		file.Filename, response.Result.Created, response.Result.Updated, response.Result.Skipped))
+
+	SuccessResponse(c, response)
+}
+
+// importRowItem 按列映射把一行转换为导入题目项，分类优先按分类ID查找，未填写时按名称或路径，都未填写时使用默认分类
+func importRowItem(row []string, columns importColumns, resolver *importCategoryResolver, defaultCategoryID uint) (ImportQuestionItem, *importFieldError) {
+	cell := func(field string) string {
+		index := columns.fields[field]
+		if index < 0 || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+
+	item := ImportQuestionItem{
+		Title:       cell(importFieldTitle),
+		Content:     cell(importFieldContent),
+		Type:        cell(importFieldType),
+		Difficulty:  cell(importFieldDifficulty),
+		Answer:      cell(importFieldAnswer),
+		Explanation: cell(importFieldExplanation),
+	}
+	for _, index := range columns.options {
+		option := ""
+		if index < len(row) {
+			option = row[index]
+		}
+		item.Options = append(item.Options, option)
+	}
+
+	// 分类ID能精确定位，优先于可能重名的分类名称
+	var err error
+	if id := cell(importFieldCategoryID); id != "" {
+		if item.CategoryID, err = resolver.resolveID(id); err != nil {
+			return item, fieldError(importFieldCategoryID, "%v", err)
+		}
+	} else if name := cell(importFieldCategory); name != "" {
+		if item.CategoryID, err = resolver.resolveName(name); err != nil {
+			return item, fieldError(importFieldCategory, "%v", err)
+		}
+	} else if defaultCategoryID != 0 {
+		item.CategoryID = defaultCategoryID
+	} else {
+		return item, fieldError(importFieldCategory, "未填写分类")
+	}
+	return item, nil
+}
+
+// isBlankRow 判断一行是否全部为空
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,515 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"qaminiprogram/config"
+	"qaminiprogram/doctext"
+	"qaminiprogram/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"gorm.io/gorm"
+)
+
+// 解析文本时最多返回的警告数
+const textImportMaxWarnings = 100
+
+var (
+	// 分节标题，如 "一、单项选择题（每题1分）"
+	textSectionPattern = regexp.MustCompile(`^(?:[一二三四五六七八九十]+\s*[、.．]\s*|第[一二三四五六七八九十]+部分\s*)?(单项选择题|单选题|多项选择题|多选题|不定项选择题|判断题|是非题|填空题)(?:\s*[(（].*[)）])?\s*[:：]?$`)
+	// 题号开头的题干，如 "1. "、"2、"、"3[单选题]"
+	textQuestionPattern = regexp.MustCompile(`^(\d{1,5})\s*([.．、)）])?\s*(?:[\[【(（](单选题|多选题|判断题|填空题|单项选择题|多项选择题|不定项选择题|是非题)[\]】)）])?\s*(.*)$`)
+	// 行内的方括号标记，解析前拆到单独的行
+	textInlineMarkerPattern = regexp.MustCompile(`[\[【](?:选项|正确答案|参考答案|答案|标签|难度|答案解析|解析)[\]】]`)
+	// 行首的标记，如 "[答案] B"、"答案：B"、"【解析】..."
+	textMarkerPattern = regexp.MustCompile(`^(?:[\[【](选项|正确答案|参考答案|答案|标签|难度|答案解析|解析)[\]】]\s*[:：]?|(正确答案|参考答案|答案|难度|答案解析|解析)\s*[:：])\s*(.*)$`)
+	// 选项字母，如 "A."、"B、"、"(C)"
+	textOptionLabelPattern = regexp.MustCompile(`(?:([A-Z])\s*[.．、)）:：]|[(（]([A-Z])[)）])`)
+	// 写在题干括号中的答案，如 "（ B ）"
+	textStemAnswerPattern = regexp.MustCompile(`[(（]\s*([A-H]{1,8}|√|×|对|错|正确|错误)\s*[)）]`)
+)
+
+// 文本中标记的种类
+const (
+	textMarkerOptions     = "options"
+	textMarkerAnswer      = "answer"
+	textMarkerTag         = "tag"
+	textMarkerExplanation = "explanation"
+)
+
+var textMarkerKinds = map[string]string{
+	"选项":   textMarkerOptions,
+	"正确答案": textMarkerAnswer, "参考答案": textMarkerAnswer, "答案": textMarkerAnswer,
+	"标签": textMarkerTag, "难度": textMarkerTag,
+	"答案解析": textMarkerExplanation, "解析": textMarkerExplanation,
+}
+
+// 解析到题目的哪一部分
+const (
+	textPartStem = iota
+	textPartOptions
+	textPartAnswer
+	textPartExplanation
+)
+
+// ImportTextResponse 文本导入结果，Warnings 为无法归入任何题目而被忽略的行
+type ImportTextResponse struct {
+	DryRun    bool               `json:"dryRun"`
+	Questions int                `json:"questions"`
+	Result    ImportResult       `json:"result"`
+	Preview   []ImportPreviewRow `json:"preview,omitempty"`
+	Warnings  []ImportRowError   `json:"warnings,omitempty"`
+}
+
+// textQuestion 解析中的一道题目
+type textQuestion struct {
+	line            int
+	questionType    string
+	stem            []string
+	options         []string
+	optionBlock     bool // [选项] 标记之后，不带字母的每一行都是一个选项（Word 自动编号的选项）
+	answer          string
+	hasAnswer       bool
+	difficulty      string
+	explanation     []string
+	explanationLine int
+	part            int
+}
+
+// textQuestionParser 按常见的试题文本格式逐行解析题目
+type textQuestionParser struct {
+	section  string
+	current  *textQuestion
+	rows     []importRow
+	warnings []ImportRowError
+}
+
+/**
+ * parseQuestionText 解析题库文本，返回的行号为题干所在的行
+ * 支持的格式：
+ *   一、单项选择题                      分节标题，决定之后题目的类型
+ *   1. 题干（  ）  或  1[单选题]题干     题号开头的题干，方括号中的类型优先于分节标题
+ *   A. 选项  B. 选项                    一行一个或多个选项
+ *   [选项]                              之后不带字母的每一行是一个选项
+ *   答案：B  /  [答案] B                判断题可写作 正确/错误、对/错、√/×，或对应选项的字母
+ *   [标签] 简单  /  难度：困难          难度
+ *   解析：...  /  [解析] ...            解析，可跨多行
+ * 没有答案行时，题干括号中的答案（如 "（B）"）作为答案
+ */
+func parseQuestionText(text string) ([]importRow, []ImportRowError) {
+	parser := &textQuestionParser{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\u3000", " "))
+		if line == "" {
+			continue
+		}
+		// 行内的多个标记拆到单独的行，如 "[答案]BD[标签]简单[解析]"
+		for _, part := range splitTextMarkers(line) {
+			parser.parseLine(i+1, part)
+		}
+	}
+	parser.finish()
+	return parser.rows, parser.warnings
+}
+
+// splitTextMarkers 在行内每个方括号标记前断开
+func splitTextMarkers(line string) []string {
+	indexes := textInlineMarkerPattern.FindAllStringIndex(line, -1)
+	var parts []string
+	start := 0
+	for _, index := range indexes {
+		if index[0] > start {
+			if part := strings.TrimSpace(line[start:index[0]]); part != "" {
+				parts = append(parts, part)
+			}
+			start = index[0]
+		}
+	}
+	if part := strings.TrimSpace(line[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func (p *textQuestionParser) parseLine(lineNo int, line string) {
+	if match := textSectionPattern.FindStringSubmatch(line); match != nil {
+		p.finish()
+		p.section = match[1]
+		return
+	}
+
+	if match := textMarkerPattern.FindStringSubmatch(line); match != nil {
+		name := match[1]
+		if name == "" {
+			name = match[2]
+		}
+		p.parseMarker(lineNo, textMarkerKinds[name], strings.TrimSpace(match[3]))
+		return
+	}
+
+	q := p.current
+	// 自动编号的选项块中，以数字开头的行是选项而不是新题目，除非带有题型标记
+	if match := textQuestionPattern.FindStringSubmatch(line); match != nil && isTextQuestionStart(match) &&
+		!(q != nil && q.part == textPartOptions && q.optionBlock && match[3] == "") {
+		p.finish()
+		p.current = &textQuestion{line: lineNo, questionType: match[3], part: textPartStem}
+		if stem := strings.TrimSpace(match[4]); stem != "" {
+			p.current.stem = append(p.current.stem, stem)
+		}
+		return
+	}
+
+	if q == nil {
+		p.warn(lineNo, "", "无法识别的内容，已忽略")
+		return
+	}
+
+	switch q.part {
+	case textPartStem:
+		if label := textOptionLabel(line); label == "A" {
+			q.part = textPartOptions
+			q.addOptions(line)
+		} else {
+			q.stem = append(q.stem, line)
+		}
+	case textPartOptions:
+		if label := textOptionLabel(line); label != "" && label == string(rune('A'+len(q.options))) {
+			q.addOptions(line)
+		} else if q.optionBlock || len(q.options) == 0 {
+			q.options = append(q.options, line)
+		} else {
+			q.options[len(q.options)-1] += " " + line
+		}
+	case textPartAnswer, textPartExplanation:
+		// 答案之后没有标记的内容计入解析
+		if len(q.explanation) == 0 {
+			q.explanationLine = lineNo
+		}
+		q.explanation = append(q.explanation, line)
+		q.part = textPartExplanation
+	}
+}
+
+func (p *textQuestionParser) parseMarker(lineNo int, kind, value string) {
+	q := p.current
+	if q == nil {
+		p.warn(lineNo, kind, "标记前没有题目，已忽略")
+		return
+	}
+
+	switch kind {
+	case textMarkerOptions:
+		// 已有答案的题目又出现选项，说明解析中混入了一道没有题号的题目，把解析作为新题目的题干
+		if q.hasAnswer {
+			if len(q.explanation) == 0 {
+				p.warn(lineNo, kind, "选项前没有题干，已忽略")
+				return
+			}
+			stem, line := q.explanation, q.explanationLine
+			q.explanation = nil
+			p.finish()
+			q = &textQuestion{line: line, stem: stem}
+			p.current = q
+		}
+		q.part = textPartOptions
+		q.optionBlock = true
+		if value != "" {
+			q.addOptions(value)
+		}
+	case textMarkerAnswer:
+		q.answer = value
+		q.hasAnswer = true
+		q.part = textPartAnswer
+	case textMarkerTag:
+		if difficulty, ok := importDifficultyAliases[strings.ToLower(value)]; ok {
+			q.difficulty = difficulty
+		}
+		q.part = textPartAnswer
+	case textMarkerExplanation:
+		if value != "" {
+			if len(q.explanation) == 0 {
+				q.explanationLine = lineNo
+			}
+			q.explanation = append(q.explanation, value)
+		}
+		q.part = textPartExplanation
+	}
+}
+
+// finish 结束当前题目并转换为导入题目项
+func (p *textQuestionParser) finish() {
+	q := p.current
+	if q == nil {
+		return
+	}
+	p.current = nil
+
+	item := ImportQuestionItem{
+		Type:        q.questionType,
+		Difficulty:  q.difficulty,
+		Options:     q.options,
+		Answer:      strings.TrimSpace(q.answer),
+		Explanation: strings.Join(q.explanation, "\n"),
+	}
+	if item.Type == "" {
+		item.Type = p.section
+	}
+	if questionType, ok := importTypeAliases[item.Type]; ok {
+		item.Type = questionType
+	}
+	stem := strings.Join(q.stem, "\n")
+
+	// 没有答案行时取题干括号中的答案，并把括号留空
+	if !q.hasAnswer {
+		if match := textStemAnswerPattern.FindStringSubmatchIndex(stem); match != nil {
+			item.Answer = stem[match[2]:match[3]]
+			stem = stem[:match[0]] + "（  ）" + stem[match[1]:]
+		}
+	}
+	item.Content = stem
+
+	if item.Type == "" && isJudgeOptions(item.Options) {
+		item.Type = "judge"
+	}
+	// 判断题的答案写作选项字母时，换成对应的选项（如 A → 对）
+	if item.Type == "judge" && len(item.Options) > 0 {
+		if indexes, err := parseAnswerLetters(item.Answer, len(item.Options)); err == nil && len(indexes) == 1 {
+			item.Answer = item.Options[indexes[0]]
+		}
+	}
+
+	row := importRow{Row: q.line, Item: item}
+	if item.Answer == "" {
+		row.Err = fieldError(importFieldAnswer, "未找到答案")
+	}
+	p.rows = append(p.rows, row)
+}
+
+func (p *textQuestionParser) warn(lineNo int, field, message string) {
+	if len(p.warnings) < textImportMaxWarnings {
+		p.warnings = append(p.warnings, ImportRowError{Row: lineNo, Field: field, Message: message})
+	}
+}
+
+// addOptions 解析一行中按字母顺序排列的一个或多个选项，如 "A.北京 B.上海"
+// 行首没有字母时整行作为一个选项
+func (q *textQuestion) addOptions(line string) {
+	matches := textOptionLabelPattern.FindAllStringSubmatchIndex(line, -1)
+	expected := 'A' + rune(len(q.options))
+	var starts [][2]int // 选项字母的开始位置和选项内容的开始位置
+	for _, match := range matches {
+		letter := optionLabelLetter(line, match)
+		if letter != expected {
+			continue
+		}
+		// 字母前是字母或数字时不是选项，如 "B.O.T"
+		if match[0] > 0 {
+			if r, _ := utf8.DecodeLastRuneInString(line[:match[0]]); r < utf8.RuneSelf && (r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+				continue
+			}
+		} else if len(starts) > 0 {
+			continue
+		}
+		if len(starts) == 0 && strings.TrimSpace(line[:match[0]]) != "" {
+			break
+		}
+		starts = append(starts, [2]int{match[0], match[1]})
+		expected++
+	}
+
+	if len(starts) == 0 {
+		q.options = append(q.options, line)
+		return
+	}
+	for i, start := range starts {
+		end := len(line)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		q.options = append(q.options, strings.TrimSpace(line[start[1]:end]))
+	}
+}
+
+// textOptionLabel 行首的选项字母，没有时返回空
+func textOptionLabel(line string) string {
+	match := textOptionLabelPattern.FindStringSubmatchIndex(line)
+	if match == nil || match[0] != 0 {
+		return ""
+	}
+	return string(optionLabelLetter(line, match))
+}
+
+func optionLabelLetter(line string, match []int) rune {
+	if match[2] >= 0 {
+		return rune(line[match[2]])
+	}
+	return rune(line[match[4]])
+}
+
+// isTextQuestionStart 题号后需要有分隔符或题型标记，"1.5米" 这样的数字不是题号
+func isTextQuestionStart(match []string) bool {
+	if match[2] == "" && match[3] == "" {
+		return false
+	}
+	if (match[2] == "." || match[2] == "．") && match[3] == "" && match[4] != "" && match[4][0] >= '0' && match[4][0] <= '9' {
+		return false
+	}
+	return true
+}
+
+// isJudgeOptions 选项是否为判断题的两个选项，如 对/错、正确/错误
+func isJudgeOptions(options []string) bool {
+	if len(options) != 2 {
+		return false
+	}
+	first, ok1 := importJudgeAnswers[strings.ToLower(options[0])]
+	second, ok2 := importJudgeAnswers[strings.ToLower(options[1])]
+	return ok1 && ok2 && first != second
+}
+
+// decodeImportText 把上传的文本解码为 UTF-8，支持带 BOM 的 UTF-8/UTF-16 和 GBK/GB18030
+func decodeImportText(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		decoded, err := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, errors.New("无法识别文件编码，请保存为 UTF-8 编码")
+		}
+		return decoded, nil
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, errors.New("无法识别文件编码，请保存为 UTF-8 编码")
+		}
+		data = decoded
+	}
+	return data, nil
+}
+
+// readImportText 读取上传的文本、.docx 或 RTF 格式的文档（包括另存为 RTF 的 .doc）
+func readImportText(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext == ".docx" {
+		return doctext.Docx(f, file.Size)
+	}
+	if ext != ".txt" && ext != ".doc" && ext != ".rtf" {
+		return "", errors.New("只支持 txt、docx、doc 和 rtf 文件")
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case doctext.IsRTF(data):
+		return doctext.RTF(data)
+	case doctext.IsLegacyDoc(data):
+		return "", doctext.ErrLegacyDoc
+	}
+	decoded, err := decodeImportText(data)
+	return string(decoded), err
+}
+
+// readTextSource 读取上传的文档或 text 字段中的文本并解析为待导入的题目，全部归入 categoryId 指定的分类
+func readTextSource(c *gin.Context, maxRows int) (*importSource, error) {
+	source := &importSource{Source: importSourceText, Filename: "粘贴的文本"}
+	var text string
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > importMaxFileSize {
+			return nil, inputErrorf("文件不能超过%dMB", importMaxFileSize>>20)
+		}
+		if text, err = readImportText(file); err != nil {
+			return nil, importInputError(err.Error())
+		}
+		source.Filename = file.Filename
+	} else {
+		text = c.PostForm("text")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, inputErrorf("请上传文件或填写题目文本")
+	}
+
+	categoryID, err := strconv.ParseUint(c.PostForm("categoryId"), 10, 64)
+	if err != nil {
+		return nil, inputErrorf("请选择题目分类")
+	}
+	var category models.Category
+	if err := config.GetDB().First(&category, categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, inputErrorf("分类不存在")
+		}
+		return nil, err
+	}
+
+	source.Rows, source.Warnings = parseQuestionText(text)
+	if len(source.Rows) == 0 {
+		return nil, inputErrorf("没有解析到题目，请检查文本格式")
+	}
+	if len(source.Rows) > maxRows {
+		return nil, inputErrorf("一次最多导入%d道题", maxRows)
+	}
+	for i := range source.Rows {
+		source.Rows[i].Item.CategoryID = category.ID
+	}
+	return source, nil
+}
+
+/**
+ * 从文本或 Word 文档导入题目（管理员）
+ * 表单字段：file 为 .txt/.docx/.doc/.rtf 文件，或 text 为粘贴的文本；categoryId 题目所属分类（必填）；
+ * skipDuplicates、updateExisting 同 ImportQuestions；dryRun 为 true 时只解析并返回预览，不写入数据
+ * 错误和警告中的行号为文本中的行号（.docx 为段落序号）
+ */
+func ImportQuestionsFromText(c *gin.Context) {
+	options, err := parseImportForm(c)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	creatorID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "无法获取用户信息")
+		return
+	}
+
+	source, err := readTextSource(c, importMaxRows)
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+
+	response := ImportTextResponse{DryRun: options.DryRun, Questions: len(source.Rows), Warnings: source.Warnings}
+	response.Result, response.Preview, err = importRows(config.GetDB(), source.Rows, options, creatorID, source.describe)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "导入失败")
+		return
+	}
+	if options.DryRun {
+		SuccessResponse(c, response)
+		return
+	}
+
+	LogOperation(c, "IMPORT", "QUESTION", fmt.Sprintf("从 %s 导入题目：新增 %d 道，更新 %d 道，跳过 %d 道",
+		source.Filename, response.Result.Created, response.Result.Updated, response.Result.Skipped))
+
+	SuccessResponse(c, response)
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"os"
+	"qaminiprogram/doctext"
+	"reflect"
+	"testing"
+)
+
+// 随仓库提供的公示题库，Word 另存的 RTF 格式 .doc
+const sampleQuestionBankPath = "../../2024年道路机场与桥隧工程公示题库.doc"
+
+type parsedTextQuestion struct {
+	Row        int
+	Type       string
+	Content    string
+	Options    []string
+	Answer     string
+	Difficulty string
+	Explain    string
+	HasErr     bool
+}
+
+func parseTextForTest(text string) ([]parsedTextQuestion, []ImportRowError) {
+	rows, warnings := parseQuestionText(text)
+	parsed := make([]parsedTextQuestion, 0, len(rows))
+	for _, row := range rows {
+		parsed = append(parsed, parsedTextQuestion{
+			Row:        row.Row,
+			Type:       row.Item.Type,
+			Content:    row.Item.Content,
+			Options:    row.Item.Options,
+			Answer:     row.Item.Answer,
+			Difficulty: row.Item.Difficulty,
+			Explain:    row.Item.Explanation,
+			HasErr:     row.Err != nil,
+		})
+	}
+	return parsed, warnings
+}
+
+func TestParseQuestionText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     []parsedTextQuestion
+		warnings int
+	}{
+		{
+			name: "公示题库格式",
+			text: "1[单选题]公路纵断面的线形要素有()。\n[选项]\n缓和曲线\n缓和坡段\n回旋曲线\n竖曲线\n[答案] D\n[标签] 简单\n[解析]\n\n\n" +
+				"2[判断题]水泥稳定碎石常用作高级沥青路面的基层。()\n[选项]\n对\n错\n[答案] A\n[标签] 困难\n[解析]\n",
+			want: []parsedTextQuestion{
+				{Row: 1, Type: "single", Content: "公路纵断面的线形要素有()。", Options: []string{"缓和曲线", "缓和坡段", "回旋曲线", "竖曲线"}, Answer: "D", Difficulty: "easy"},
+				{Row: 12, Type: "judge", Content: "水泥稳定碎石常用作高级沥青路面的基层。()", Options: []string{"对", "错"}, Answer: "对", Difficulty: "hard"},
+			},
+		},
+		{
+			name: "解析中混入没有题号的题目",
+			text: "1[单选题]题干一\n[选项]\n甲\n乙\n[答案] B\n[标签] 简单\n" +
+				"[解析] 关于质量的说法,正确的是()。[选项]A.说法一 B.说法二 C.说法三[答案]BC[标签]简单[解析]\n",
+			want: []parsedTextQuestion{
+				{Row: 1, Type: "single", Content: "题干一", Options: []string{"甲", "乙"}, Answer: "B", Difficulty: "easy"},
+				{Row: 7, Content: "关于质量的说法,正确的是()。", Options: []string{"说法一", "说法二", "说法三"}, Answer: "BC", Difficulty: "easy"},
+			},
+		},
+		{
+			name: "分节标题和行内选项",
+			text: "一、单项选择题（每题1分）\n1. 中国的首都是（  ）\nA. 北京 B. 上海\nC. 广州  D. 深圳\n答案：A\n解析：北京是首都。\n补充说明\n" +
+				"二、多项选择题\n2、属于长江流域的城市有\nA.武汉\nB.重庆\nC.哈尔滨\n参考答案：AB\n",
+			want: []parsedTextQuestion{
+				{Row: 2, Type: "single", Content: "中国的首都是（  ）", Options: []string{"北京", "上海", "广州", "深圳"}, Answer: "A", Explain: "北京是首都。\n补充说明"},
+				{Row: 9, Type: "multiple", Content: "属于长江流域的城市有", Options: []string{"武汉", "重庆", "哈尔滨"}, Answer: "AB"},
+			},
+		},
+		{
+			name: "题干括号中的答案",
+			text: "三、判断题\n1. 地球是圆的。（√）\n2．太阳从西边升起（ 错 ）\n四、填空题\n3. 长江全长约 6300 公里\n答案：6300\n",
+			want: []parsedTextQuestion{
+				{Row: 2, Type: "judge", Content: "地球是圆的。（  ）", Answer: "√"},
+				{Row: 3, Type: "judge", Content: "太阳从西边升起（  ）", Answer: "错"},
+				{Row: 5, Type: "fill", Content: "长江全长约 6300 公里", Answer: "6300"},
+			},
+		},
+		{
+			name: "数字开头的内容不是题号",
+			text: "1. 钢筋的直径\n1.5米以上时\nA. 对\nB. 错\n答案：A\n",
+			want: []parsedTextQuestion{
+				{Row: 1, Type: "judge", Content: "钢筋的直径\n1.5米以上时", Options: []string{"对", "错"}, Answer: "对"},
+			},
+		},
+		{
+			name: "自动编号的选项块中数字开头的选项",
+			text: "1[单选题]标准跨径为\n[选项]\n1. 10米\n2. 20米\n[答案] B\n",
+			want: []parsedTextQuestion{
+				{Row: 1, Type: "single", Content: "标准跨径为", Options: []string{"1. 10米", "2. 20米"}, Answer: "B"},
+			},
+		},
+		{
+			name: "缺少答案和无法归入题目的内容",
+			text: "前言\n[答案] A\n1. 没有答案的题目\nA. 甲\nB. 乙\n",
+			want: []parsedTextQuestion{
+				{Row: 3, Content: "没有答案的题目", Options: []string{"甲", "乙"}, HasErr: true},
+			},
+			warnings: 2,
+		},
+		{
+			name: "选项字母前是字母或数字时不拆分",
+			text: "1. 应选用的型号是\nA. 型号AB.1 B. 型号CD.2\n答案：A\n",
+			want: []parsedTextQuestion{
+				{Row: 1, Content: "应选用的型号是", Options: []string{"型号AB.1", "型号CD.2"}, Answer: "A"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := parseTextForTest(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQuestionText() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("warnings = %+v, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestSplitTextMarkers(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"[答案]BD[标签]简单[解析]", []string{"[答案]BD", "[标签]简单", "[解析]"}},
+		{"题干[选项]A.甲", []string{"题干", "[选项]A.甲"}},
+		{"没有标记", []string{"没有标记"}},
+		{"【答案】 A 【解析】 说明", []string{"【答案】 A", "【解析】 说明"}},
+	}
+	for _, tt := range tests {
+		if got := splitTextMarkers(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitTextMarkers(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestParseSampleQuestionBank 解析随仓库提供的公示题库，全部题目都应能通过导入校验
+func TestParseSampleQuestionBank(t *testing.T) {
+	data, err := os.ReadFile(sampleQuestionBankPath)
+	if err != nil {
+		t.Skipf("读取公示题库失败: %v", err)
+	}
+	if !doctext.IsRTF(data) {
+		t.Fatalf("公示题库应为 RTF 格式")
+	}
+	text, err := doctext.RTF(data)
+	if err != nil {
+		t.Fatalf("doctext.RTF() error = %v", err)
+	}
+
+	rows, warnings := parseQuestionText(text)
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %+v, want none", warnings)
+	}
+
+	types := make(map[string]int)
+	for _, row := range rows {
+		if row.Err != nil {
+			t.Errorf("第%d行: %s", row.Row, row.Err.Message)
+			continue
+		}
+		question, fieldErr := buildImportedQuestion(row.Item)
+		if fieldErr != nil {
+			t.Errorf("第%d行: %s", row.Row, fieldErr.Message)
+			continue
+		}
+		types[question.Type]++
+	}
+
+	const wantQuestions = 1295
+	if len(rows) != wantQuestions {
+		t.Errorf("parsed %d questions, want %d", len(rows), wantQuestions)
+	}
+	wantTypes := map[string]int{"single": 507, "multiple": 251, "judge": 537}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("question types = %v, want %v", types, wantTypes)
+	}
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"qaminiprogram/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 解析显示方式
+const (
+	ShowExplanationAlways      = "always"       // 取题时即返回答案和解析
+	ShowExplanationAfterAnswer = "after_answer" // 提交答案后返回
+	ShowExplanationNever       = "never"        // 从不返回
+)
+
+// 答题设置缓存默认有效期，多实例部署时其他实例最迟在有效期后读到新设置
+const defaultQuizSettingsCacheTTL = 60 * time.Second
+
+// answerTicketGrace 按答题凭证计时时允许的网络延迟
+const answerTicketGrace = 5 * time.Second
+
+var quizSettingsCache struct {
+	sync.RWMutex
+	settings  QuizSettingsRequest
+	expiresAt time.Time
+}
+
+// quizSettingsCacheTTL 答题设置缓存有效期，可通过QUIZ_SETTINGS_CACHE_TTL（秒）配置
+func quizSettingsCacheTTL() time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv("QUIZ_SETTINGS_CACHE_TTL")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultQuizSettingsCacheTTL
+}
+
+// getQuizSettings 读取答题设置，优先使用缓存
+func getQuizSettings(db *gorm.DB) QuizSettingsRequest {
+	quizSettingsCache.RLock()
+	if time.Now().Before(quizSettingsCache.expiresAt) {
+		settings := quizSettingsCache.settings
+		quizSettingsCache.RUnlock()
+		return settings
+	}
+	quizSettingsCache.RUnlock()
+
+	// 解析失败时使用默认值，同样缓存，避免每次请求都读库
+	settings, _ := loadQuizSettings(db)
+	setQuizSettingsCache(settings)
+	return settings
+}
+
+// setQuizSettingsCache 更新答题设置缓存
+func setQuizSettingsCache(settings QuizSettingsRequest) {
+	quizSettingsCache.Lock()
+	quizSettingsCache.settings = settings
+	quizSettingsCache.expiresAt = time.Now().Add(quizSettingsCacheTTL())
+	quizSettingsCache.Unlock()
+}
+
+// normalizeShowExplanation 统一解析显示方式，兼容管理端的 afterAnswer 写法
+func normalizeShowExplanation(value string) (string, bool) {
+	switch value {
+	case ShowExplanationAlways, ShowExplanationNever, ShowExplanationAfterAnswer:
+		return value, true
+	case "afterAnswer", "":
+		return ShowExplanationAfterAnswer, true
+	}
+	return "", false
+}
+
+// countTodayAnswers 统计用户今日的练习作答次数，考试作答不计入每日限额
+func countTodayAnswers(db *gorm.DB, userID uint) int64 {
+	var count int64
+	db.Model(&models.AnswerRecord{}).
+		Where("user_id = ? AND answered_at >= ? AND mode <> ?", userID, startOfDay(time.Now()), models.AnswerModeExam).
+		Count(&count)
+	return count
+}
+
+// remainingDailyAnswers 今日剩余可答题数，未设置每日限额时返回 -1
+func remainingDailyAnswers(db *gorm.DB, userID uint, settings QuizSettingsRequest) int64 {
+	if settings.DailyLimit <= 0 {
+		return -1
+	}
+	remaining := int64(settings.DailyLimit) - countTodayAnswers(db, userID)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
+// answerTicketSignature 答题凭证签名
+func answerTicketSignature(userID, questionID uint, issuedAt int64) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	fmt.Fprintf(mac, "answer:%d:%d:%d", userID, questionID, issuedAt)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// issueAnswerTicket 签发答题凭证，记录服务端发题时间，提交答案时据此计算用时
+func issueAnswerTicket(userID, questionID uint, issuedAt time.Time) string {
+	unix := issuedAt.Unix()
+	return fmt.Sprintf("%d.%d.%s", questionID, unix, answerTicketSignature(userID, questionID, unix))
+}
+
+// parseAnswerTicket 校验答题凭证并返回发题时间
+func parseAnswerTicket(ticket string, userID, questionID uint) (time.Time, error) {
+	parts := strings.Split(ticket, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("答题凭证格式错误")
+	}
+	if parts[0] != strconv.FormatUint(uint64(questionID), 10) {
+		return time.Time{}, errors.New("答题凭证与题目不符")
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, errors.New("答题凭证格式错误")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(answerTicketSignature(userID, questionID, unix))) {
+		return time.Time{}, errors.New("答题凭证无效")
+	}
+
+	issuedAt := time.Unix(unix, 0)
+	if issuedAt.After(time.Now().Add(answerTicketGrace)) {
+		return time.Time{}, errors.New("答题凭证无效")
+	}
+	return issuedAt, nil
+}
+
+// isAnswerTimedOut 是否超过每题答题时间限制
+func isAnswerTimedOut(settings QuizSettingsRequest, elapsed time.Duration) bool {
+	return settings.TimeLimit > 0 && elapsed > time.Duration(settings.TimeLimit)*time.Second+answerTicketGrace
+}
+
+// answerPoints 按作答结果计算积分变动，每道题只在第一次答对时加分，答错每次都按设置扣分
+func answerPoints(settings QuizSettingsRequest, isCorrect, firstCorrect bool) (int, string) {
+	if !settings.EnablePoints {
+		return 0, ""
+	}
+	if isCorrect {
+		if !firstCorrect {
+			return 0, ""
+		}
+		return settings.CorrectPoints, models.PointReasonAnswerCorrect
+	}
+	return settings.WrongPoints, models.PointReasonAnswerWrong
+}
+
+// PracticeQuestion 练习取题时返回的题目，按解析显示设置隐藏答案和解析
+type PracticeQuestion struct {
+	models.Question
+	CorrectAnswer *int               `json:"correctAnswer,omitempty"`
+	FillAnswers   models.FillAnswers `json:"fillAnswers,omitempty"`
+	Explanation   *string            `json:"explanation,omitempty"`
+	AnswerTicket  string             `json:"answerTicket,omitempty"`
+}
+
+// newPracticeQuestion 构造练习题目，showAnswers 为 false 时不返回答案和解析
+func newPracticeQuestion(question models.Question, showAnswers bool, ticket string) PracticeQuestion {
+	view := PracticeQuestion{Question: question, AnswerTicket: ticket}
+	if showAnswers {
+		view.CorrectAnswer = &question.CorrectAnswer
+		view.FillAnswers = question.FillAnswers
+		view.Explanation = &question.Explanation
+	}
+	return view
+}
+
+// newPracticeQuestions 构造练习题目列表
+// 只有设置为总是显示时才随题目返回答案和解析；已登录用户附带答题凭证用于计时
+func newPracticeQuestions(questions []models.Question, settings QuizSettingsRequest, userID uint, loggedIn bool) []PracticeQuestion {
+	showExplanation, _ := normalizeShowExplanation(settings.ShowExplanation)
+	now := time.Now()
+	result := make([]PracticeQuestion, 0, len(questions))
+	for _, question := range questions {
+		ticket := ""
+		if loggedIn {
+			ticket = issueAnswerTicket(userID, question.ID, now)
+		}
+		result = append(result, newPracticeQuestion(question, showExplanation == ShowExplanationAlways, ticket))
+	}
+	return result
+}
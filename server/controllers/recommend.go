@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 推荐练习参数
+const (
+	defaultRecommendCount = 10
+	maxRecommendCount     = 50
+
+	// 每次从题库随机抽取的候选题数，推荐在候选中按权重挑选
+	recommendCandidateLimit = 500
+	// 非当前水平难度的探索题比例
+	recommendExplorationRatio = 0.3
+	// 作答过的题目间隔达到该天数后不再降权
+	recommendRecencyDays = 7
+	// 最近一次答错的题目权重加成
+	recommendWrongBoost = 1.5
+	// 没有作答记录的分类或难度按此正确率估计
+	recommendPriorAccuracy = 0.5
+)
+
+// 按整体正确率估计的用户水平
+var recommendLevels = []struct {
+	MaxAccuracy float64
+	Difficulty  string
+}{
+	{0.6, "easy"},
+	{0.85, "medium"},
+	{math.Inf(1), "hard"},
+}
+
+// difficultyNames 难度的中文名称
+var difficultyNames = map[string]string{
+	"easy":   "简单",
+	"medium": "中等",
+	"hard":   "困难",
+}
+
+// AccuracyGroup 按分类或难度汇总的作答正确率
+type AccuracyGroup struct {
+	CategoryID   uint    `json:"categoryId,omitempty"`
+	CategoryName string  `json:"categoryName,omitempty"`
+	Difficulty   string  `json:"difficulty,omitempty"`
+	Answered     int64   `json:"answered"`
+	Correct      int64   `json:"correct"`
+	Accuracy     float64 `json:"accuracy"`
+}
+
+// RecommendedQuestion 推荐题目，附带权重和推荐理由
+type RecommendedQuestion struct {
+	PracticeQuestion
+	Weight      float64  `json:"weight"`
+	Exploration bool     `json:"exploration"`
+	Reasons     []string `json:"reasons"`
+}
+
+// recommendCandidate 候选题目及其作答状态
+type recommendCandidate struct {
+	models.Question
+	LastAnsweredAt *time.Time
+	LastCorrect    *bool
+}
+
+// smoothedAccuracy 平滑后的正确率，作答较少时向先验值靠拢，避免一两次作答决定权重
+func smoothedAccuracy(correct, answered int64) float64 {
+	return (float64(correct) + 2*recommendPriorAccuracy) / (float64(answered) + 2)
+}
+
+// estimateLevel 按整体正确率估计用户当前适合的难度
+func estimateLevel(accuracy float64) string {
+	for _, level := range recommendLevels {
+		if accuracy < level.MaxAccuracy {
+			return level.Difficulty
+		}
+	}
+	return "medium"
+}
+
+// recencyFactor 按距上次作答的时间降权，最近一次答错的题加权
+func recencyFactor(candidate *recommendCandidate, now time.Time) (float64, string) {
+	if candidate.LastAnsweredAt == nil {
+		return 1, "从未作答"
+	}
+
+	days := now.Sub(*candidate.LastAnsweredAt).Hours() / 24
+	factor := math.Max(0.05, math.Min(1, days/recommendRecencyDays))
+	reason := fmt.Sprintf("距上次作答 %.0f 天", math.Floor(days))
+	if candidate.LastCorrect != nil && !*candidate.LastCorrect {
+		factor *= recommendWrongBoost
+		reason += "，上次答错"
+	}
+	return factor, reason
+}
+
+// weightedSample 按权重不放回抽样（Efraimidis-Spirakis），返回选中的下标
+func weightedSample(weights []float64, count int) []int {
+	type keyed struct {
+		index int
+		key   float64
+	}
+	keys := make([]keyed, 0, len(weights))
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		keys = append(keys, keyed{i, math.Pow(rand.Float64(), 1/weight)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	if count > len(keys) {
+		count = len(keys)
+	}
+	picked := make([]int, 0, count)
+	for _, k := range keys[:count] {
+		picked = append(picked, k.index)
+	}
+	return picked
+}
+
+// GetRecommendedQuestions 推荐练习
+// 偏向正确率低的分类和难度、较久未练的题目；大部分题目为估计水平的难度，其余为其他难度的探索题
+func GetRecommendedQuestions(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		ErrorResponse(c, http.StatusUnauthorized, "未授权")
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultRecommendCount)))
+	if err != nil || count < 1 || count > maxRecommendCount {
+		count = defaultRecommendCount
+	}
+
+	db := config.GetDB()
+
+	// 每日答题限额
+	quizSettings := getQuizSettings(db)
+	remaining := remainingDailyAnswers(db, userID, quizSettings)
+	if remaining == 0 {
+		ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("今日答题数量已达上限（%d题）", quizSettings.DailyLimit))
+		return
+	}
+	if remaining > 0 && int64(count) > remaining {
+		count = int(remaining)
+	}
+
+	// 分类和难度的正确率
+	var categoryGroups []AccuracyGroup
+	db.Raw(`
+		SELECT q.category_id, c.name AS category_name,
+			SUM(s.attempt_count) AS answered, SUM(s.correct_count) AS correct
+		FROM answer_states s
+		JOIN questions q ON q.id = s.question_id
+		JOIN categories c ON c.id = q.category_id
+		WHERE s.user_id = ?
+		GROUP BY q.category_id, c.name
+	`, userID).Scan(&categoryGroups)
+
+	var difficultyGroups []AccuracyGroup
+	db.Raw(`
+		SELECT q.difficulty, SUM(s.attempt_count) AS answered, SUM(s.correct_count) AS correct
+		FROM answer_states s
+		JOIN questions q ON q.id = s.question_id
+		WHERE s.user_id = ?
+		GROUP BY q.difficulty
+	`, userID).Scan(&difficultyGroups)
+
+	var totalAnswered, totalCorrect int64
+	categoryAccuracy := make(map[uint]AccuracyGroup, len(categoryGroups))
+	for i := range categoryGroups {
+		group := &categoryGroups[i]
+		group.Accuracy = float64(group.Correct) / float64(group.Answered) * 100
+		categoryAccuracy[group.CategoryID] = *group
+		totalAnswered += group.Answered
+		totalCorrect += group.Correct
+	}
+	difficultyAccuracy := make(map[string]AccuracyGroup, len(difficultyGroups))
+	for i := range difficultyGroups {
+		group := &difficultyGroups[i]
+		group.Accuracy = float64(group.Correct) / float64(group.Answered) * 100
+		difficultyAccuracy[group.Difficulty] = *group
+	}
+
+	overallAccuracy := smoothedAccuracy(totalCorrect, totalAnswered)
+	level := estimateLevel(overallAccuracy)
+
+	// 候选题目
+	query := db.Model(&models.Question{}).
+		Select("questions.*, s.last_answered_at, s.last_correct").
+		Joins("LEFT JOIN answer_states s ON s.question_id = questions.id AND s.user_id = ?", userID)
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		query = query.Where("questions.category_id = ?", categoryID)
+	}
+	var candidates []recommendCandidate
+	if err := query.Order("RAND()").Limit(recommendCandidateLimit).Scan(&candidates).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取推荐题目失败")
+		return
+	}
+
+	// 计算每道候选题的权重和理由
+	now := time.Now()
+	weights := make([]float64, len(candidates))
+	reasons := make([][]string, len(candidates))
+	var levelIndexes, exploreIndexes []int
+	for i := range candidates {
+		candidate := &candidates[i]
+
+		categoryWeight := 1 - recommendPriorAccuracy
+		if group, ok := categoryAccuracy[candidate.CategoryID]; ok {
+			categoryWeight = 1 - smoothedAccuracy(group.Correct, group.Answered)
+			reasons[i] = append(reasons[i], fmt.Sprintf("分类「%s」正确率 %.0f%%", group.CategoryName, group.Accuracy))
+		} else {
+			reasons[i] = append(reasons[i], "尚未练习该分类")
+		}
+
+		difficultyWeight := 1 - recommendPriorAccuracy
+		difficultyName := difficultyNames[candidate.Difficulty]
+		if difficultyName == "" {
+			difficultyName = candidate.Difficulty
+		}
+		if group, ok := difficultyAccuracy[candidate.Difficulty]; ok {
+			difficultyWeight = 1 - smoothedAccuracy(group.Correct, group.Answered)
+			reasons[i] = append(reasons[i], fmt.Sprintf("%s题正确率 %.0f%%", difficultyName, group.Accuracy))
+		}
+
+		recency, recencyReason := recencyFactor(candidate, now)
+		reasons[i] = append(reasons[i], recencyReason)
+
+		// 正确率再高的分类和难度也保留少量权重
+		weights[i] = math.Max(0.05, categoryWeight) * math.Max(0.05, difficultyWeight) * recency
+
+		if candidate.Difficulty == level {
+			levelIndexes = append(levelIndexes, i)
+		} else {
+			exploreIndexes = append(exploreIndexes, i)
+		}
+	}
+
+	// 大部分题目取自当前水平，其余为探索题；任一部分不足时由另一部分补足
+	exploreCount := int(math.Round(float64(count) * recommendExplorationRatio))
+	if exploreCount > len(exploreIndexes) {
+		exploreCount = len(exploreIndexes)
+	}
+	levelCount := count - exploreCount
+	if levelCount > len(levelIndexes) {
+		levelCount = len(levelIndexes)
+		exploreCount = int(math.Min(float64(count-levelCount), float64(len(exploreIndexes))))
+	}
+
+	pick := func(indexes []int, n int) []int {
+		subset := make([]float64, len(indexes))
+		for j, index := range indexes {
+			subset[j] = weights[index]
+		}
+		picked := make([]int, 0, n)
+		for _, j := range weightedSample(subset, n) {
+			picked = append(picked, indexes[j])
+		}
+		return picked
+	}
+
+	levelName := difficultyNames[level]
+	var picked []int
+	exploration := make(map[int]bool)
+	for _, index := range pick(levelIndexes, levelCount) {
+		reasons[index] = append([]string{fmt.Sprintf("符合当前水平（%s）", levelName)}, reasons[index]...)
+		picked = append(picked, index)
+	}
+	for _, index := range pick(exploreIndexes, exploreCount) {
+		reasons[index] = append([]string{"探索其他难度"}, reasons[index]...)
+		exploration[index] = true
+		picked = append(picked, index)
+	}
+
+	// 按权重从高到低返回
+	sort.SliceStable(picked, func(i, j int) bool { return weights[picked[i]] > weights[picked[j]] })
+
+	questions := make([]models.Question, 0, len(picked))
+	categoryIDs := make([]uint, 0, len(picked))
+	for _, index := range picked {
+		questions = append(questions, candidates[index].Question)
+		categoryIDs = append(categoryIDs, candidates[index].CategoryID)
+	}
+
+	// 补充分类信息
+	var categories []models.Category
+	db.Where("id IN ?", categoryIDs).Find(&categories)
+	categoryByID := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		categoryByID[categories[i].ID] = &categories[i]
+	}
+	for i := range questions {
+		questions[i].Category = categoryByID[questions[i].CategoryID]
+	}
+	views := newPracticeQuestions(questions, quizSettings, userID, true)
+
+	result := make([]RecommendedQuestion, 0, len(picked))
+	for i, index := range picked {
+		result = append(result, RecommendedQuestion{
+			PracticeQuestion: views[i],
+			Weight:           math.Round(weights[index]*1000) / 1000,
+			Exploration:      exploration[index],
+			Reasons:          reasons[index],
+		})
+	}
+
+	SuccessResponse(c, gin.H{
+		"level":              level,
+		"overallAccuracy":    math.Round(overallAccuracy*1000) / 10,
+		"categoryAccuracy":   categoryGroups,
+		"difficultyAccuracy": difficultyGroups,
+		"questions":          result,
+	})
+}
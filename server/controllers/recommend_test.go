@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestWeightedSample(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		count   int
+		want    int   // 抽中的数量
+		allowed []int // 可能抽中的下标
+	}{
+		{name: "全部抽中", weights: []float64{1, 2, 3}, count: 3, want: 3, allowed: []int{0, 1, 2}},
+		{name: "数量超过候选", weights: []float64{1, 2}, count: 5, want: 2, allowed: []int{0, 1}},
+		{name: "跳过非正权重", weights: []float64{0, 1, -1, 2}, count: 4, want: 2, allowed: []int{1, 3}},
+		{name: "部分抽取", weights: []float64{1, 1, 1, 1, 1}, count: 2, want: 2, allowed: []int{0, 1, 2, 3, 4}},
+		{name: "没有候选", weights: nil, count: 3, want: 0},
+		{name: "数量为0", weights: []float64{1}, count: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for trial := 0; trial < 20; trial++ {
+				got := weightedSample(tt.weights, tt.count)
+				if len(got) != tt.want {
+					t.Fatalf("weightedSample() = %v, want %d indexes", got, tt.want)
+				}
+				seen := make(map[int]bool)
+				for _, index := range got {
+					i := sort.SearchInts(tt.allowed, index)
+					if i == len(tt.allowed) || tt.allowed[i] != index {
+						t.Fatalf("weightedSample() = %v, index %d not allowed", got, index)
+					}
+					if seen[index] {
+						t.Fatalf("weightedSample() = %v, index %d picked twice", got, index)
+					}
+					seen[index] = true
+				}
+			}
+		})
+	}
+}
+
+// TestWeightedSampleBias 权重越大越容易被抽中：权重 1:9 时只抽一个，约九成抽中后者
+func TestWeightedSampleBias(t *testing.T) {
+	const trials = 4000
+	heavy := 0
+	for i := 0; i < trials; i++ {
+		if weightedSample([]float64{1, 9}, 1)[0] == 1 {
+			heavy++
+		}
+	}
+	if ratio := float64(heavy) / trials; ratio < 0.85 || ratio > 0.95 {
+		t.Errorf("heavy item picked %.3f of the time, want about 0.9", ratio)
+	}
+}
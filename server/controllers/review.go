@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"math"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 错题本间隔复习参数（SM-2）
+const (
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+
+	// 作答只有对错两种结果，分别按 SM-2 的评分 4（答对）和 2（答错）更新难易系数
+	reviewQualityCorrect = 4
+	reviewQualityWrong   = 2
+
+	// 连续答对的复习次数达到该值即视为已掌握
+	mistakeMasteryRepetitions = 3
+)
+
+// newMistakeEntry 新加入错题本的记录，第二天开始复习
+func newMistakeEntry(userID, questionID uint, now time.Time) models.MistakeBook {
+	next := now.AddDate(0, 0, 1)
+	return models.MistakeBook{
+		UserID:       userID,
+		QuestionID:   questionID,
+		AddedAt:      now,
+		EaseFactor:   defaultEaseFactor,
+		IntervalDays: 1,
+		NextReviewAt: &next,
+	}
+}
+
+// startOfDay 返回now在业务时区所在日期的零点，复习和每日限额都按自然日计算
+func startOfDay(now time.Time) time.Time {
+	now = now.In(config.Location())
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// isMistakeDue 错题是否在今日的复习队列中
+func isMistakeDue(entry *models.MistakeBook, now time.Time) bool {
+	return entry.NextReviewAt == nil || entry.NextReviewAt.Before(startOfDay(now).AddDate(0, 0, 1))
+}
+
+// scheduleMistakeReview 按一次作答结果更新错题的复习计划
+// 答错时重新从第一天开始复习；答对时间隔按 1 天、6 天、上次间隔×难易系数 递增，连续答对达到次数后标记为已掌握
+func scheduleMistakeReview(entry *models.MistakeBook, isCorrect bool, now time.Time) {
+	quality := reviewQualityWrong
+	if isCorrect {
+		quality = reviewQualityCorrect
+	}
+
+	entry.EaseFactor += 0.1 - float64(5-quality)*(0.08+float64(5-quality)*0.02)
+	if entry.EaseFactor < minEaseFactor {
+		entry.EaseFactor = minEaseFactor
+	}
+
+	if isCorrect {
+		entry.Repetitions++
+		switch entry.Repetitions {
+		case 1:
+			entry.IntervalDays = 1
+		case 2:
+			entry.IntervalDays = 6
+		default:
+			entry.IntervalDays = int(math.Round(float64(entry.IntervalDays) * entry.EaseFactor))
+		}
+	} else {
+		entry.Repetitions = 0
+		entry.IntervalDays = 1
+	}
+
+	entry.IsMastered = entry.Repetitions >= mistakeMasteryRepetitions
+	entry.ReviewCount++
+	next := now.AddDate(0, 0, entry.IntervalDays)
+	entry.NextReviewAt = &next
+	entry.LastReviewedAt = &now
+}
+
+// updateMistakeSchedule 按作答结果更新错题本
+// 答错的题加入错题本或重新安排复习；答对时只有到期的错题才计为一次复习，避免答错后立刻重做就被判为掌握
+func updateMistakeSchedule(tx *gorm.DB, userID, questionID uint, isCorrect bool, now time.Time) error {
+	var entry models.MistakeBook
+	err := tx.Where("user_id = ? AND question_id = ?", userID, questionID).First(&entry).Error
+	if err == gorm.ErrRecordNotFound {
+		if isCorrect {
+			return nil
+		}
+		entry = newMistakeEntry(userID, questionID, now)
+		return tx.Create(&entry).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if isCorrect && (entry.IsMastered || !isMistakeDue(&entry, now)) {
+		return nil
+	}
+
+	scheduleMistakeReview(&entry, isCorrect, now)
+	return tx.Model(&entry).Updates(map[string]interface{}{
+		"is_mastered":      entry.IsMastered,
+		"ease_factor":      entry.EaseFactor,
+		"interval_days":    entry.IntervalDays,
+		"repetitions":      entry.Repetitions,
+		"review_count":     entry.ReviewCount,
+		"next_review_at":   entry.NextReviewAt,
+		"last_reviewed_at": entry.LastReviewedAt,
+	}).Error
+}
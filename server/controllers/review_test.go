@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"math"
+	"qaminiprogram/config"
+	"testing"
+	"time"
+)
+
+func TestScheduleMistakeReview(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, config.Location())
+
+	type step struct {
+		correct     bool
+		interval    int
+		repetitions int
+		easeFactor  float64
+		mastered    bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "连续答对后掌握",
+			steps: []step{
+				{correct: true, interval: 1, repetitions: 1, easeFactor: 2.5},
+				{correct: true, interval: 6, repetitions: 2, easeFactor: 2.5},
+				{correct: true, interval: 15, repetitions: 3, easeFactor: 2.5, mastered: true},
+				{correct: true, interval: 38, repetitions: 4, easeFactor: 2.5, mastered: true},
+			},
+		},
+		{
+			name: "答错后重新开始",
+			steps: []step{
+				{correct: true, interval: 1, repetitions: 1, easeFactor: 2.5},
+				{correct: true, interval: 6, repetitions: 2, easeFactor: 2.5},
+				{correct: false, interval: 1, repetitions: 0, easeFactor: 2.18},
+				{correct: true, interval: 1, repetitions: 1, easeFactor: 2.18},
+			},
+		},
+		{
+			name: "难易系数不低于下限",
+			steps: []step{
+				{correct: false, interval: 1, easeFactor: 2.18},
+				{correct: false, interval: 1, easeFactor: 1.86},
+				{correct: false, interval: 1, easeFactor: 1.54},
+				{correct: false, interval: 1, easeFactor: 1.3},
+				{correct: false, interval: 1, easeFactor: 1.3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := newMistakeEntry(1, 2, now)
+			reviewedAt := now
+			for i, s := range tt.steps {
+				reviewedAt = reviewedAt.AddDate(0, 0, 1)
+				scheduleMistakeReview(&entry, s.correct, reviewedAt)
+
+				if entry.IntervalDays != s.interval || entry.Repetitions != s.repetitions ||
+					math.Abs(entry.EaseFactor-s.easeFactor) > 1e-9 || entry.IsMastered != s.mastered {
+					t.Fatalf("step %d: interval=%d repetitions=%d ease=%.2f mastered=%v, want %d %d %.2f %v",
+						i+1, entry.IntervalDays, entry.Repetitions, entry.EaseFactor, entry.IsMastered,
+						s.interval, s.repetitions, s.easeFactor, s.mastered)
+				}
+				if entry.ReviewCount != i+1 {
+					t.Errorf("step %d: ReviewCount = %d", i+1, entry.ReviewCount)
+				}
+				if want := reviewedAt.AddDate(0, 0, s.interval); !entry.NextReviewAt.Equal(want) {
+					t.Errorf("step %d: NextReviewAt = %v, want %v", i+1, entry.NextReviewAt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsMistakeDue(t *testing.T) {
+	loc := config.Location()
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, loc)
+	at := func(day, hour int) *time.Time {
+		v := time.Date(2024, 6, day, hour, 0, 0, 0, loc)
+		return &v
+	}
+
+	entry := newMistakeEntry(1, 2, now)
+	if isMistakeDue(&entry, now) {
+		t.Errorf("new mistake is due on the day it was added")
+	}
+
+	tests := []struct {
+		name string
+		next *time.Time
+		want bool
+	}{
+		{"未安排", nil, true},
+		{"已过期", at(1, 8), true},
+		{"今天稍后", at(1, 23), true},
+		{"明天零点", at(2, 0), false},
+	}
+	for _, tt := range tests {
+		entry.NextReviewAt = tt.next
+		if got := isMistakeDue(&entry, now); got != tt.want {
+			t.Errorf("%s: isMistakeDue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"qaminiprogram/config"
+	"qaminiprogram/middleware"
+	"qaminiprogram/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	DisplayName string   `json:"displayName"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest 更新角色请求
+type UpdateRoleRequest struct {
+	DisplayName string   `json:"displayName"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetPermissions 获取全部权限（管理员）
+func GetPermissions(c *gin.Context) {
+	db := config.GetDB()
+	var permissions []models.Permission
+	if err := db.Order("code ASC").Find(&permissions).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取权限列表失败")
+		return
+	}
+
+	SuccessResponse(c, permissions)
+}
+
+// GetRoles 获取角色列表（管理员）
+func GetRoles(c *gin.Context) {
+	db := config.GetDB()
+	var roles []models.Role
+	if err := db.Preload("Permissions").Order("id ASC").Find(&roles).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取角色列表失败")
+		return
+	}
+
+	// 统计每个角色下的用户数
+	for i := range roles {
+		db.Model(&models.User{}).Where("role = ?", roles[i].Name).Count(&roles[i].UserCount)
+	}
+
+	SuccessResponse(c, roles)
+}
+
+// CreateRole 创建角色（管理员）
+func CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	db := config.GetDB()
+
+	// 检查角色名是否已存在
+	if roleExists(db, req.Name) {
+		ErrorResponse(c, http.StatusBadRequest, "角色名已存在")
+		return
+	}
+
+	// 角色的权限不能超出自己的权限，否则可创建高权限角色再分配给自己
+	if !permissionsWithinCaller(c, req.Permissions) {
+		ErrorResponse(c, http.StatusForbidden, "不能授予自己没有的权限")
+		return
+	}
+
+	permissions, err := findPermissionsByCodes(db, req.Permissions)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	role := models.Role{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := db.Create(&role).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "创建角色失败")
+		return
+	}
+
+	// 记录操作日志
+	LogOperation(c, "CREATE", "ROLE", fmt.Sprintf("创建角色: %s", role.Name))
+
+	SuccessResponse(c, role)
+}
+
+// UpdateRole 更新角色及其权限（管理员）
+func UpdateRole(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的角色ID")
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "参数错误")
+		return
+	}
+
+	db := config.GetDB()
+	var role models.Role
+	if err := db.Where("id = ?", id).First(&role).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "角色不存在")
+		return
+	}
+
+	// 超级管理员角色的权限不允许修改，避免后台被锁死
+	if role.Name == models.RoleAdmin && req.Permissions != nil {
+		ErrorResponse(c, http.StatusBadRequest, "不能修改超级管理员角色的权限")
+		return
+	}
+
+	// 不能修改自己当前的角色，也不能授予自己没有的权限
+	if callerRole, _ := GetRole(c); role.Name == callerRole {
+		ErrorResponse(c, http.StatusForbidden, "不能修改自己所属的角色")
+		return
+	}
+	if req.Permissions != nil && !permissionsWithinCaller(c, req.Permissions) {
+		ErrorResponse(c, http.StatusForbidden, "不能授予自己没有的权限")
+		return
+	}
+
+	if req.DisplayName != "" {
+		role.DisplayName = req.DisplayName
+	}
+	if req.Description != "" {
+		role.Description = req.Description
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&role).Error; err != nil {
+			return err
+		}
+		if req.Permissions == nil {
+			return nil
+		}
+		permissions, err := findPermissionsByCodes(tx, req.Permissions)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&role).Association("Permissions").Replace(permissions)
+	})
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "更新角色失败："+err.Error())
+		return
+	}
+
+	db.Preload("Permissions").First(&role, role.ID)
+
+	// 记录操作日志
+	LogOperation(c, "UPDATE", "ROLE", fmt.Sprintf("更新角色: %s", role.Name))
+
+	SuccessResponse(c, role)
+}
+
+// DeleteRole 删除角色（管理员）
+func DeleteRole(c *gin.Context) {
+	id, err := ParseIDParam(c, "id")
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, "无效的角色ID")
+		return
+	}
+
+	db := config.GetDB()
+	var role models.Role
+	if err := db.Where("id = ?", id).First(&role).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "角色不存在")
+		return
+	}
+
+	if role.IsSystem {
+		ErrorResponse(c, http.StatusBadRequest, "内置角色不能删除")
+		return
+	}
+
+	// 检查是否还有用户使用该角色
+	var userCount int64
+	db.Model(&models.User{}).Where("role = ?", role.Name).Count(&userCount)
+	if userCount > 0 {
+		ErrorResponse(c, http.StatusBadRequest, "该角色下还有用户，无法删除")
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "删除角色失败")
+		return
+	}
+
+	// 记录操作日志
+	LogOperation(c, "DELETE", "ROLE", fmt.Sprintf("删除角色: %s", role.Name))
+
+	SuccessResponse(c, gin.H{"message": "删除成功"})
+}
+
+// roleExists 检查角色是否存在
+func roleExists(db *gorm.DB, name string) bool {
+	var count int64
+	db.Model(&models.Role{}).Where("name = ?", name).Count(&count)
+	return count > 0
+}
+
+// roleWithinCaller 判断角色的权限是否都在当前用户的权限之内
+func roleWithinCaller(c *gin.Context, role string) bool {
+	return permissionsWithinCaller(c, middleware.GetRolePermissions(role))
+}
+
+// permissionsWithinCaller 判断权限编码是否都在当前用户的权限之内，超级管理员不受限制
+func permissionsWithinCaller(c *gin.Context, codes []string) bool {
+	callerRole, _ := GetRole(c)
+	if callerRole == models.RoleAdmin {
+		return true
+	}
+	granted := make(map[string]bool)
+	for _, code := range middleware.GetRolePermissions(callerRole) {
+		granted[code] = true
+	}
+	for _, code := range codes {
+		if !granted[code] {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeRoleAssignment 检查当前用户能否给用户分配角色，不能时返回错误响应
+// 分配角色需要 role:manage 权限，且角色的权限不能超出当前用户自己的权限，防止通过用户管理提升权限
+func authorizeRoleAssignment(c *gin.Context, db *gorm.DB, role string) bool {
+	if !roleExists(db, role) {
+		ErrorResponse(c, http.StatusBadRequest, "角色不存在")
+		return false
+	}
+	callerRole, _ := GetRole(c)
+	if !middleware.HasPermission(callerRole, models.PermRoleManage) {
+		ErrorResponse(c, http.StatusForbidden, "权限不足：分配角色需要 "+models.PermRoleManage)
+		return false
+	}
+	if !roleWithinCaller(c, role) {
+		ErrorResponse(c, http.StatusForbidden, "不能分配权限超出自己的角色")
+		return false
+	}
+	return true
+}
+
+// findPermissionsByCodes 根据权限编码查询权限，存在未知编码时返回错误
+func findPermissionsByCodes(db *gorm.DB, codes []string) ([]models.Permission, error) {
+	if len(codes) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	var permissions []models.Permission
+	if err := db.Where("code IN ?", codes).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		found[permission.Code] = true
+	}
+	for _, code := range codes {
+		if !found[code] {
+			return nil, fmt.Errorf("权限不存在: %s", code)
+		}
+	}
+	return permissions, nil
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"qaminiprogram/middleware"
+	"qaminiprogram/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 会话注销原因
+const (
+	revokeReasonLogout       = "logout"
+	revokeReasonLogoutAll    = "logout_all"
+	revokeReasonReuse        = "reuse_detected"
+	revokeReasonStatusChange = "status_changed"
+)
+
+// sessionTokens 登录或刷新后签发的令牌
+type sessionTokens struct {
+	AccessToken     string
+	AccessExpireAt  time.Time
+	RefreshToken    string
+	RefreshExpireAt time.Time
+}
+
+// hashRefreshToken 计算刷新令牌的哈希，数据库中只保存哈希值
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRefreshToken 生成不透明的随机刷新令牌
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// issueRefreshToken 为会话签发新的刷新令牌并顺延会话有效期
+func issueRefreshToken(tx *gorm.DB, session *models.UserSession) (string, time.Time, error) {
+	token, err := generateRefreshToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(middleware.RefreshTokenTTL())
+	record := models.RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	session.ExpiresAt = expiresAt
+	session.LastUsedAt = &now
+	if err := tx.Save(session).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// createUserSession 为用户创建新的登录会话并签发访问令牌和刷新令牌
+func createUserSession(db *gorm.DB, c *gin.Context, user *models.User) (*sessionTokens, error) {
+	var tokens sessionTokens
+	err := db.Transaction(func(tx *gorm.DB) error {
+		session := models.UserSession{
+			UserID:    user.ID,
+			IP:        c.ClientIP(),
+			UserAgent: c.GetHeader("User-Agent"),
+			ExpiresAt: time.Now().Add(middleware.RefreshTokenTTL()),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		refreshToken, refreshExpireAt, err := issueRefreshToken(tx, &session)
+		if err != nil {
+			return err
+		}
+
+		accessToken, accessExpireAt, err := middleware.GenerateJWT(user.ID, user.OpenID, user.Role, session.ID)
+		if err != nil {
+			return err
+		}
+
+		tokens = sessionTokens{
+			AccessToken:     accessToken,
+			AccessExpireAt:  accessExpireAt,
+			RefreshToken:    refreshToken,
+			RefreshExpireAt: refreshExpireAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// revokeSession 注销单个会话
+func revokeSession(db *gorm.DB, sessionID uint, reason string) error {
+	return db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+}
+
+// revokeUserSessions 注销用户的全部会话，返回被注销的会话数
+func revokeUserSessions(db *gorm.DB, userID uint, reason string) (int64, error) {
+	result := db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason})
+	return result.RowsAffected, result.Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // OverviewStatistics 概览统计
@@ -46,7 +47,11 @@ type UserStatistics struct {
 
 // GetOverviewStatistics 获取概览统计（管理员）
 func GetOverviewStatistics(c *gin.Context) {
-	db := config.GetDB()
+	SuccessResponse(c, overviewStatistics(config.GetDB()))
+}
+
+// overviewStatistics 汇总概览统计，概览接口和导出共用
+func overviewStatistics(db *gorm.DB) OverviewStatistics {
 	var stats OverviewStatistics
 
 	// 总用户数
@@ -64,51 +69,41 @@ func GetOverviewStatistics(c *gin.Context) {
 	// 今日数据
 	today := time.Now().Format("2006-01-02")
 	db.Model(&models.User{}).Where("DATE(created_at) = ?", today).Count(&stats.TodayUsers)
-	db.Model(&models.AnswerRecord{}).Where("DATE(created_at) = ?", today).Count(&stats.TodayAnswers)
+	db.Model(&models.AnswerRecord{}).Where("DATE(answered_at) = ?", today).Count(&stats.TodayAnswers)
 
 	// 本周数据
 	weekStart := time.Now().AddDate(0, 0, -int(time.Now().Weekday())).Format("2006-01-02")
 	db.Model(&models.User{}).Where("DATE(created_at) >= ?", weekStart).Count(&stats.WeekUsers)
-	db.Model(&models.AnswerRecord{}).Where("DATE(created_at) >= ?", weekStart).Count(&stats.WeekAnswers)
+	db.Model(&models.AnswerRecord{}).Where("DATE(answered_at) >= ?", weekStart).Count(&stats.WeekAnswers)
 
 	// 本月数据
 	monthStart := time.Now().AddDate(0, 0, -time.Now().Day()+1).Format("2006-01-02")
 	db.Model(&models.User{}).Where("DATE(created_at) >= ?", monthStart).Count(&stats.MonthUsers)
-	db.Model(&models.AnswerRecord{}).Where("DATE(created_at) >= ?", monthStart).Count(&stats.MonthAnswers)
+	db.Model(&models.AnswerRecord{}).Where("DATE(answered_at) >= ?", monthStart).Count(&stats.MonthAnswers)
 
-	SuccessResponse(c, stats)
+	return stats
 }
 
-// GetQuestionStatistics 获取题目统计（管理员）
-func GetQuestionStatistics(c *gin.Context) {
-	page, size := ParsePageParams(c)
-	offset := (page - 1) * size
-
+// questionStatisticsQuery 按请求参数构建题目统计查询（不含分页），题目统计和导出共用
+func questionStatisticsQuery(c *gin.Context) (string, []interface{}) {
 	// 获取查询参数
 	categoryID := c.Query("category_id")
 	difficulty := c.Query("difficulty")
 	sortBy := c.DefaultQuery("sort_by", "total_answered")
 	sortOrder := c.DefaultQuery("sort_order", "DESC")
 
-	db := config.GetDB()
-
-	// 构建查询
+	// 构建查询，答题数和正确率直接读取题目上的计数
 	query := `
 		SELECT 
 			q.id as question_id,
 			q.title as question_title,
 			c.name as category_name,
 			q.difficulty,
-			COUNT(ar.id) as total_answered,
-			SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) as correct_answered,
-			CASE 
-				WHEN COUNT(ar.id) > 0 THEN 
-					SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) * 100.0 / COUNT(ar.id)
-				ELSE 0 
-			END as accuracy_rate
+			q.total_answered,
+			q.total_correct as correct_answered,
+			q.accuracy_rate
 		FROM questions q
 		LEFT JOIN categories c ON q.category_id = c.id
-		LEFT JOIN answer_records ar ON q.id = ar.question_id
 		WHERE 1=1
 	`
 
@@ -124,8 +119,6 @@ func GetQuestionStatistics(c *gin.Context) {
 		args = append(args, difficulty)
 	}
 
-	query += " GROUP BY q.id, q.title, c.name, q.difficulty"
-
 	// 添加排序
 	validSortFields := map[string]bool{
 		"total_answered":   true,
@@ -139,6 +132,20 @@ func GetQuestionStatistics(c *gin.Context) {
 		query += " ORDER BY total_answered DESC"
 	}
 
+	return query, args
+}
+
+// GetQuestionStatistics 获取题目统计（管理员）
+func GetQuestionStatistics(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	categoryID := c.Query("category_id")
+	difficulty := c.Query("difficulty")
+
+	db := config.GetDB()
+	query, args := questionStatisticsQuery(c)
+
 	// 获取总数
 	countQuery := `
 		SELECT COUNT(*) FROM (
@@ -172,35 +179,24 @@ func GetQuestionStatistics(c *gin.Context) {
 	PageSuccessResponse(c, stats, total, page, size)
 }
 
-// GetUserStatistics 获取用户统计（管理员）
-func GetUserStatistics(c *gin.Context) {
-	page, size := ParsePageParams(c)
-	offset := (page - 1) * size
-
+// userStatisticsQuery 按请求参数构建用户统计查询（不含分页），用户统计和导出共用
+func userStatisticsQuery(c *gin.Context) string {
 	// 获取查询参数
 	sortBy := c.DefaultQuery("sort_by", "total_answered")
 	sortOrder := c.DefaultQuery("sort_order", "DESC")
 
-	db := config.GetDB()
-
-	// 构建查询
+	// 构建查询，答题数和正确率直接读取用户上的计数
 	query := `
 		SELECT 
 			u.id as user_id,
 			u.nickname,
-			COUNT(ar.id) as total_answered,
-			SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) as correct_answered,
-			CASE 
-				WHEN COUNT(ar.id) > 0 THEN 
-					SUM(CASE WHEN ar.is_correct = 1 THEN 1 ELSE 0 END) * 100.0 / COUNT(ar.id)
-				ELSE 0 
-			END as accuracy_rate,
-			COALESCE(SUM(ar.time_spent), 0) as total_time_spent,
-			MAX(ar.created_at) as last_active_time
+			u.total_answered,
+			u.total_correct as correct_answered,
+			u.accuracy_rate,
+			(SELECT COALESCE(SUM(ar.time_spent), 0) FROM answer_records ar WHERE ar.user_id = u.id) as total_time_spent,
+			u.last_active_time
 		FROM users u
-		LEFT JOIN answer_records ar ON u.id = ar.user_id
 		WHERE u.role = 'user'
-		GROUP BY u.id, u.nickname
 	`
 
 	// 添加排序
@@ -218,6 +214,17 @@ func GetUserStatistics(c *gin.Context) {
 		query += " ORDER BY total_answered DESC"
 	}
 
+	return query
+}
+
+// GetUserStatistics 获取用户统计（管理员）
+func GetUserStatistics(c *gin.Context) {
+	page, size := ParsePageParams(c)
+	offset := (page - 1) * size
+
+	db := config.GetDB()
+	query := userStatisticsQuery(c)
+
 	// 获取总数
 	var total int64
 	db.Model(&models.User{}).Where("role = ?", "user").Count(&total)
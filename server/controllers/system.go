@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"qaminiprogram/config"
 	"qaminiprogram/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // BasicSettingsRequest 基础设置请求
@@ -27,6 +29,7 @@ type QuizSettingsRequest struct {
 	WrongPoints      int      `json:"wrong_points"`
 	QuizModes        []string `json:"quiz_modes"`
 	ShowExplanation  string   `json:"show_explanation"`
+	PartialCredit    bool     `json:"partial_credit"` // 多选题少选按比例得分
 }
 
 // SystemStatistics 系统统计数据
@@ -48,9 +51,10 @@ type UserStatItem struct {
 
 // AnswerStatItem 答题统计项
 type AnswerStatItem struct {
-	Date         string `json:"date"`
-	TotalAnswers int64  `json:"total_answers"`
-	CorrectRate  string `json:"correct_rate"`
+	Date           string `json:"date"`
+	TotalAnswers   int64  `json:"total_answers"`
+	CorrectAnswers int64  `json:"correct_answers"`
+	CorrectRate    string `json:"correct_rate"`
 }
 
 /**
@@ -134,34 +138,40 @@ func UpdateBasicSettings(c *gin.Context) {
  * 获取答题设置
  */
 func GetQuizSettings(c *gin.Context) {
-	db := config.GetDB()
+	quizSettings, err := loadQuizSettings(config.GetDB())
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "解析设置失败")
+		return
+	}
+
+	SuccessResponse(c, quizSettings)
+}
+
+// loadQuizSettings 读取答题设置，未设置时返回默认值
+func loadQuizSettings(db *gorm.DB) (QuizSettingsRequest, error) {
+	// 默认值
+	quizSettings := QuizSettingsRequest{
+		DailyLimit:      0,
+		TimeLimit:       0,
+		EnablePoints:    true,
+		CorrectPoints:   1,
+		WrongPoints:     0,
+		QuizModes:       []string{"random", "category"},
+		ShowExplanation: "after_answer",
+		PartialCredit:   false,
+	}
 
 	// 从数据库获取答题设置
 	var settings models.SystemSetting
-	err := db.Where("key = ?", "quiz").First(&settings).Error
-	if err != nil {
-		// 如果没有设置，返回默认值
-		defaultSettings := QuizSettingsRequest{
-			DailyLimit:      0,
-			TimeLimit:       0,
-			EnablePoints:    true,
-			CorrectPoints:   1,
-			WrongPoints:     0,
-			QuizModes:       []string{"random", "category"},
-			ShowExplanation: "after_answer",
-		}
-		SuccessResponse(c, defaultSettings)
-		return
+	if err := db.Where("`key` = ?", "quiz").First(&settings).Error; err != nil {
+		return quizSettings, nil
 	}
 
 	// 解析设置值
-	var quizSettings QuizSettingsRequest
 	if err := parseJSONValue(settings.Value, &quizSettings); err != nil {
-		ErrorResponse(c, http.StatusInternalServerError, "解析设置失败")
-		return
+		return quizSettings, err
 	}
-
-	SuccessResponse(c, quizSettings)
+	return quizSettings, nil
 }
 
 /**
@@ -174,6 +184,17 @@ func UpdateQuizSettings(c *gin.Context) {
 		return
 	}
 
+	if req.DailyLimit < 0 || req.TimeLimit < 0 {
+		ErrorResponse(c, http.StatusBadRequest, "答题限制不能为负数")
+		return
+	}
+	showExplanation, ok := normalizeShowExplanation(req.ShowExplanation)
+	if !ok {
+		ErrorResponse(c, http.StatusBadRequest, "解析显示方式无效")
+		return
+	}
+	req.ShowExplanation = showExplanation
+
 	db := config.GetDB()
 
 	// 将设置保存到数据库
@@ -185,7 +206,7 @@ func UpdateQuizSettings(c *gin.Context) {
 
 	// 更新或创建设置
 	var settings models.SystemSetting
-	err = db.Where("key = ?", "quiz").First(&settings).Error
+	err = db.Where("`key` = ?", "quiz").First(&settings).Error
 	if err != nil {
 		// 创建新设置
 		settings = models.SystemSetting{
@@ -205,13 +226,23 @@ func UpdateQuizSettings(c *gin.Context) {
 		}
 	}
 
+	// 刷新本实例的设置缓存
+	setQuizSettingsCache(req)
+
 	SuccessResponse(c, gin.H{"message": "答题设置保存成功"})
 }
 
 /**
  * 获取系统统计数据
+ * 每日数据读取 daily_stats，startDate、endDate 为业务时区的日期，默认最近30天
  */
 func GetSystemStatistics(c *gin.Context) {
+	start, end, err := parseStatsDateRange(c)
+	if err != nil {
+		ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	db := config.GetDB()
 
 	// 获取总用户数
@@ -228,29 +259,26 @@ func GetSystemStatistics(c *gin.Context) {
 
 	// 获取活跃用户数（最近7天有答题记录的用户）
 	var activeUsers int64
-	db.Model(&models.AnswerRecord{}).
-		Where("created_at >= NOW() - INTERVAL '7 days'").
-		Distinct("user_id").
+	db.Model(&models.User{}).
+		Where("last_active_time >= ?", time.Now().AddDate(0, 0, -7)).
 		Count(&activeUsers)
 
+	// 每日新增用户、活跃用户、答题数和正确率
+	userStats, answerStats, err := dailyStatsSeries(db, start, end)
+	if err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "获取每日统计失败")
+		return
+	}
+
 	// 构造统计数据
 	stats := SystemStatistics{
 		TotalUsers:     totalUsers,
 		TotalQuestions: totalQuestions,
 		TotalAnswers:   totalAnswers,
 		ActiveUsers:    activeUsers,
-		UserStats:      []UserStatItem{},
-		AnswerStats:    []AnswerStatItem{},
+		UserStats:      userStats,
+		AnswerStats:    answerStats,
 	}
 
 	SuccessResponse(c, stats)
 }
-
-/**
- * 导出统计报表
- */
-func ExportStatistics(c *gin.Context) {
-	// 这里可以实现Excel导出功能
-	// 暂时返回成功响应
-	SuccessResponse(c, gin.H{"message": "导出功能开发中"})
-}
\ No newline at end of file
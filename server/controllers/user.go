@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"qaminiprogram/config"
 	"qaminiprogram/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -18,7 +19,9 @@ type UpdateUserProfileRequest struct {
 
 // UpdateUserStatusRequest 更新用户状态请求
 type UpdateUserStatusRequest struct {
-	Status string `json:"status"`
+	Status       string     `json:"status" binding:"required"`
+	BanReason    string     `json:"banReason"`
+	BanExpiresAt *time.Time `json:"banExpiresAt"`
 }
 
 // AdminUpdateUserRequest 管理员更新用户请求
@@ -189,6 +192,12 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// 不能修改权限超出自己的用户，否则可通过重置密码登录该账号
+	if !roleWithinCaller(c, user.Role) {
+		ErrorResponse(c, http.StatusForbidden, "不能修改权限高于自己的用户")
+		return
+	}
+
 	// 更新用户信息
 	if req.Username != "" {
 		user.Username = req.Username
@@ -196,11 +205,22 @@ func UpdateUser(c *gin.Context) {
 	if req.Email != "" {
 		user.Email = req.Email
 	}
-	if req.Role != "" {
+	if req.Role != "" && req.Role != user.Role {
+		if !authorizeRoleAssignment(c, db, req.Role) {
+			return
+		}
 		user.Role = req.Role
 	}
 	if req.Status != "" {
+		if !models.IsValidUserStatus(req.Status) {
+			ErrorResponse(c, http.StatusBadRequest, "用户状态无效")
+			return
+		}
 		user.Status = req.Status
+		if req.Status != models.UserStatusBanned {
+			user.BanReason = ""
+			user.BanExpiresAt = nil
+		}
 	}
 	user.IsVerified = req.IsVerified
 
@@ -219,6 +239,11 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// 非正常状态立即注销该用户的全部会话
+	if user.Status != models.UserStatusActive {
+		revokeUserSessions(db, user.ID, revokeReasonStatusChange)
+	}
+
 	SuccessResponse(c, user)
 }
 
@@ -231,9 +256,14 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	db := config.GetDB()
-	// 获取用户名用于日志记录
 	var user models.User
-	db.Where("id = ?", id).First(&user)
+	if err := db.Where("id = ?", id).First(&user).Error; err != nil {
+		ErrorResponse(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	if !authorizeUserTarget(c, &user, "删除") {
+		return
+	}
 	username := user.Username
 
 	if err := db.Delete(&models.User{}, id).Error; err != nil {
@@ -263,10 +293,16 @@ func BatchDeleteUsers(c *gin.Context) {
 	db := config.GetDB()
 	// 获取要删除的用户名列表用于日志记录
 	var users []models.User
-	db.Where("id IN ?", req.IDs).Find(&users)
+	if err := db.Where("id IN ?", req.IDs).Find(&users).Error; err != nil {
+		ErrorResponse(c, http.StatusInternalServerError, "批量删除失败")
+		return
+	}
 	usernames := make([]string, len(users))
-	for i, user := range users {
-		usernames[i] = user.Username
+	for i := range users {
+		if !authorizeUserTarget(c, &users[i], "删除") {
+			return
+		}
+		usernames[i] = users[i].Username
 	}
 
 	if err := db.Where("id IN ?", req.IDs).Delete(&models.User{}).Error; err != nil {
@@ -294,20 +330,45 @@ func UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
+	if !models.IsValidUserStatus(req.Status) {
+		ErrorResponse(c, http.StatusBadRequest, "用户状态无效")
+		return
+	}
+	if req.BanExpiresAt != nil && req.BanExpiresAt.Before(time.Now()) {
+		ErrorResponse(c, http.StatusBadRequest, "解封时间必须晚于当前时间")
+		return
+	}
+
 	db := config.GetDB()
 	var user models.User
 	if err := db.Where("id = ?", id).First(&user).Error; err != nil {
 		ErrorResponse(c, http.StatusNotFound, "用户不存在")
 		return
 	}
+	if !authorizeUserTarget(c, &user, "修改") {
+		return
+	}
 
 	oldStatus := user.Status
 	user.Status = req.Status
+	// 封禁原因和解封时间只对封禁状态有效
+	if req.Status == models.UserStatusBanned {
+		user.BanReason = req.BanReason
+		user.BanExpiresAt = req.BanExpiresAt
+	} else {
+		user.BanReason = ""
+		user.BanExpiresAt = nil
+	}
 	if err := db.Save(&user).Error; err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "更新状态失败")
 		return
 	}
 
+	// 非正常状态立即注销该用户的全部会话
+	if user.Status != models.UserStatusActive {
+		revokeUserSessions(db, user.ID, revokeReasonStatusChange)
+	}
+
 	// 记录操作日志
 	LogOperation(c, "UPDATE_STATUS", "USER", fmt.Sprintf("将用户 %s 状态从 %s 更新为 %s", user.Username, oldStatus, req.Status))
 
@@ -328,8 +389,23 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// 验证用户状态
+	if req.Status == "" {
+		req.Status = models.UserStatusActive
+	} else if !models.IsValidUserStatus(req.Status) {
+		ErrorResponse(c, http.StatusBadRequest, "用户状态无效")
+		return
+	}
+
 	db := config.GetDB()
 
+	// 验证角色，默认角色之外的角色需要分配权限
+	if req.Role == "" {
+		req.Role = models.RoleUser
+	} else if req.Role != models.RoleUser && !authorizeRoleAssignment(c, db, req.Role) {
+		return
+	}
+
 	// 检查用户名是否已存在
 	var existingUser models.User
 	if err := db.Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser).Error; err == nil {
@@ -356,7 +432,7 @@ func CreateUser(c *gin.Context) {
 		IsVerified: req.IsVerified,
 	}
 
-	if err := db.Create(&user).Error; err != nil {
+	if err := createUser(db, &user); err != nil {
 		ErrorResponse(c, http.StatusInternalServerError, "创建用户失败")
 		return
 	}
@@ -365,4 +441,18 @@ func CreateUser(c *gin.Context) {
 	LogOperation(c, "CREATE", "USER", fmt.Sprintf("创建用户: %s", user.Username))
 
 	SuccessResponse(c, user)
-}
\ No newline at end of file
+}
+
+// authorizeUserTarget 检查当前用户能否对目标用户执行操作，不能时返回错误响应
+// 不能操作自己的账号，也不能操作权限超出自己的用户，防止封禁或删除更高权限的管理员
+func authorizeUserTarget(c *gin.Context, user *models.User, action string) bool {
+	if callerID, _ := GetUserID(c); user.ID == callerID {
+		ErrorResponse(c, http.StatusForbidden, "不能"+action+"自己的账号")
+		return false
+	}
+	if !roleWithinCaller(c, user.Role) {
+		ErrorResponse(c, http.StatusForbidden, "不能"+action+"权限高于自己的用户")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WechatSessionClient 微信登录凭证校验（code2session）客户端
+type WechatSessionClient interface {
+	Code2Session(ctx context.Context, code string) (*WechatSessionResponse, error)
+}
+
+// 微信客户端配置默认值
+const (
+	defaultWechatBaseURL = "https://api.weixin.qq.com"
+	defaultWechatTimeout = 5 * time.Second
+)
+
+var (
+	wechatClient     WechatSessionClient
+	wechatClientOnce sync.Once
+)
+
+// getWechatSessionClient 根据环境变量创建微信客户端
+// WX_SESSION_CLIENT=mock 时使用内置的模拟实现，否则请求 WX_API_BASE_URL（默认微信官方接口）
+func getWechatSessionClient() WechatSessionClient {
+	wechatClientOnce.Do(func() {
+		if os.Getenv("WX_SESSION_CLIENT") == "mock" {
+			wechatClient = mockWechatSessionClient{}
+			return
+		}
+		wechatClient = newHTTPWechatSessionClient()
+	})
+	return wechatClient
+}
+
+// SetWechatSessionClient 替换微信客户端，用于测试或接入其他实现
+func SetWechatSessionClient(client WechatSessionClient) {
+	wechatClientOnce.Do(func() {})
+	wechatClient = client
+}
+
+// httpWechatSessionClient 通过HTTP调用code2session接口
+type httpWechatSessionClient struct {
+	baseURL    string
+	appID      string
+	appSecret  string
+	httpClient *http.Client
+}
+
+// newHTTPWechatSessionClient 从环境变量读取接口地址、凭证和超时时间
+func newHTTPWechatSessionClient() *httpWechatSessionClient {
+	baseURL := strings.TrimRight(os.Getenv("WX_API_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = defaultWechatBaseURL
+	}
+
+	timeout := defaultWechatTimeout
+	if seconds, err := strconv.Atoi(os.Getenv("WX_API_TIMEOUT")); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	return &httpWechatSessionClient{
+		baseURL:    baseURL,
+		appID:      os.Getenv("WX_APPID"),
+		appSecret:  os.Getenv("WX_SECRET"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Code2Session 调用 /sns/jscode2session 换取openid、unionid和session_key
+func (w *httpWechatSessionClient) Code2Session(ctx context.Context, code string) (*WechatSessionResponse, error) {
+	if w.appID == "" || w.appSecret == "" {
+		return nil, fmt.Errorf("微信配置未设置")
+	}
+
+	query := url.Values{}
+	query.Set("appid", w.appID)
+	query.Set("secret", w.appSecret)
+	query.Set("js_code", code)
+	query.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.baseURL+"/sns/jscode2session?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var wxResp WechatSessionResponse
+	if err := json.Unmarshal(body, &wxResp); err != nil {
+		return nil, err
+	}
+
+	if wxResp.ErrCode != 0 {
+		return nil, fmt.Errorf("微信API错误: %s", wxResp.ErrMsg)
+	}
+
+	return &wxResp, nil
+}
+
+// mockWechatSessionClient 内置的模拟实现，不访问网络
+type mockWechatSessionClient struct{}
+
+// Code2Session 按固定规则把code映射为openid
+func (mockWechatSessionClient) Code2Session(ctx context.Context, code string) (*WechatSessionResponse, error) {
+	resp := mockWechatSession(code)
+	if resp.ErrCode != 0 {
+		return nil, fmt.Errorf("微信API错误: %s", resp.ErrMsg)
+	}
+	return &resp, nil
+}
+
+// mockWechatSession 模拟code2session的确定性映射规则：
+//   - "mock:<name>" 映射为 openid "mock_openid_<name>"，便于在测试中指定用户
+//   - 以 "invalid" 开头的code返回 40029 错误
+//   - 其他code按哈希映射，同一个code总是得到同一个openid
+func mockWechatSession(code string) WechatSessionResponse {
+	if code == "" || strings.HasPrefix(code, "invalid") {
+		return WechatSessionResponse{ErrCode: 40029, ErrMsg: "invalid code"}
+	}
+
+	sum := sha256.Sum256([]byte(code))
+	suffix := hex.EncodeToString(sum[:12])
+	if name, ok := strings.CutPrefix(code, "mock:"); ok && name != "" {
+		suffix = name
+	}
+
+	sessionKey := sha256.Sum256([]byte("session_key:" + code))
+	return WechatSessionResponse{
+		OpenID:     "mock_openid_" + suffix,
+		UnionID:    "mock_unionid_" + suffix,
+		SessionKey: base64.StdEncoding.EncodeToString(sessionKey[:16]),
+	}
+}
+
+// MockWechatCode2Session 本地模拟的 /sns/jscode2session 接口
+// 设置 WX_MOCK_SERVER=true 后挂载，配合 WX_API_BASE_URL 指向本服务即可完整走通HTTP调用链路
+func MockWechatCode2Session(c *gin.Context) {
+	c.JSON(http.StatusOK, mockWechatSession(c.Query("js_code")))
+}
@@ -0,0 +1,118 @@
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestRTF(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "GBK 代码页",
+			in:   `{\rtf1\ansi\ansicpg936 {\fonttbl{\f0\fnil \'cb\'ce\'cc\'e5;}}\f0 \'d6\'d0\'ce\'c4\par A.\'b6\'d4}`,
+			want: "中文\nA.对",
+		},
+		{
+			name: "Unicode 字符跳过替代字符",
+			in:   `{\rtf1\ansi\uc1\u20013?\u25991?\par{\uc2\u-28647\'3f\'3f}}`,
+			want: "中文\n這",
+		},
+		{
+			name: "跳过不含正文的目标组",
+			in:   `{\rtf1\ansi\ansicpg936{\info{\title \'b1\'ea}}{\*\generator Word;}{\header \'d2\'b3}\'d5\'fd\'ce\'c4\tab \'c1\'d0\line \'cf\'c2}`,
+			want: "正文\t列\n下",
+		},
+		{
+			name: "转义字符",
+			in:   `{\rtf1 a\{b\}c\\d\~e\_f}`,
+			want: "a{b}c\\d\u00a0e-f",
+		},
+		{
+			name: "西文代码页",
+			in:   `{\rtf1\ansi\ansicpg1252 caf\'e9\par}`,
+			want: "café\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RTF([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("RTF() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RTF() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := RTF([]byte("plain text")); err == nil {
+		t.Errorf("RTF() of plain text: want error")
+	}
+}
+
+func TestIsRTFAndLegacyDoc(t *testing.T) {
+	ole := []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0x00}
+	tests := []struct {
+		name        string
+		data        []byte
+		rtf, legacy bool
+	}{
+		{name: "RTF", data: []byte("\r\n {\\rtf1 x}"), rtf: true},
+		{name: "二进制 doc", data: ole, legacy: true},
+		{name: "文本", data: []byte("1. 题目")},
+		{name: "过短", data: ole[:4]},
+	}
+	for _, tt := range tests {
+		if got := IsRTF(tt.data); got != tt.rtf {
+			t.Errorf("%s: IsRTF() = %v, want %v", tt.name, got, tt.rtf)
+		}
+		if got := IsLegacyDoc(tt.data); got != tt.legacy {
+			t.Errorf("%s: IsLegacyDoc() = %v, want %v", tt.name, got, tt.legacy)
+		}
+	}
+}
+
+func TestDocx(t *testing.T) {
+	const document = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>1[单选题]</w:t></w:r><w:r><w:t xml:space="preserve">题干 </w:t></w:r><w:del><w:r><w:delText>删除</w:delText></w:r></w:del></w:p>
+<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>PAGE</w:instrText></w:r><w:r><w:t>A.甲</w:t><w:tab/><w:t>B.乙</w:t><w:br/><w:t>C.丙</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>单元格1</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>单元格2</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"word/document.xml":   document,
+	} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Docx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Docx() error = %v", err)
+	}
+	want := "1[单选题]题干 \nA.甲\tB.乙\nC.丙\n单元格1\n\t单元格2\n\t"
+	if got != want {
+		t.Errorf("Docx() = %q, want %q", got, want)
+	}
+
+	if _, err := Docx(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Errorf("Docx() of invalid data: want error")
+	}
+}
@@ -0,0 +1,94 @@
+// Package doctext 从 Word 文档中提取纯文本，每个段落一行，用于按文本格式解析题库
+package doctext
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrLegacyDoc 二进制格式的 .doc 文件，需要先另存为 .docx 或纯文本
+var ErrLegacyDoc = errors.New("不支持 Word 97-2003 格式，请另存为 .docx 或纯文本后再导入")
+
+// oleSignature 二进制 .doc 等复合文档的文件头
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// Docx 提取 .docx 正文的文本，段落之间以换行分隔，表格单元格之间以制表符分隔
+// 自动编号（如列表生成的 A. B.）不在文档文本中，不会被提取
+func Docx(r io.ReaderAt, size int64) (string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", fmt.Errorf("不是有效的 DOCX 文件: %w", err)
+	}
+	for _, file := range archive.File {
+		if strings.TrimPrefix(file.Name, "/") == "word/document.xml" {
+			return documentText(file)
+		}
+	}
+	return "", errors.New("不是有效的 DOCX 文件: 缺少 document.xml")
+}
+
+// documentText 按顺序读取 document.xml 中的文本
+func documentText(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	var (
+		text   strings.Builder
+		inText bool
+		skip   int // 进入删除修订、域代码等不显示内容的层数
+	)
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "del", "instrText":
+				skip++
+			case "t":
+				inText = skip == 0
+			case "tab":
+				if skip == 0 {
+					text.WriteByte('\t')
+				}
+			case "br", "cr":
+				if skip == 0 {
+					text.WriteByte('\n')
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "del", "instrText":
+				skip--
+			case "t":
+				inText = false
+			case "p":
+				text.WriteByte('\n')
+			case "tc":
+				text.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// IsLegacyDoc 判断内容是否为二进制格式的 .doc 文件
+func IsLegacyDoc(data []byte) bool {
+	return len(data) >= len(oleSignature) && string(data[:len(oleSignature)]) == string(oleSignature)
+}
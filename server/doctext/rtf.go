@@ -0,0 +1,209 @@
+package doctext
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 不包含正文的 RTF 目标组，整组跳过
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"object": true, "listtable": true, "listoverridetable": true, "header": true, "headerl": true,
+	"headerr": true, "headerf": true, "footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"fldinst": true, "themedata": true, "colorschememapping": true, "latentstyles": true,
+	"datastore": true, "xmlnstbl": true, "rsidtbl": true, "generator": true, "footnote": true,
+}
+
+// 表示单个字符的控制字
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n", "page": "\n", "row": "\n",
+	"tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+}
+
+// IsRTF 判断内容是否为 RTF，Word 另存的“.doc”有时实际是 RTF
+func IsRTF(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte(`{\rtf`))
+}
+
+// RTF 提取 RTF 文档的文本，段落之间以换行分隔
+// 非 Unicode 字符按 \ansicpg 指定的代码页解码，支持 936（GBK）、950（Big5）和西文代码页
+func RTF(data []byte) (string, error) {
+	if !IsRTF(data) {
+		return "", errors.New("不是有效的 RTF 文件")
+	}
+
+	type state struct {
+		skip bool
+		uc   int
+	}
+	var (
+		text     strings.Builder
+		pending  []byte // 待按代码页解码的字节
+		stack    []state
+		current  = state{uc: 1}
+		decoder  encoding.Encoding
+		ucRemain int // \u 之后需要跳过的替代字符数
+	)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if decoder == nil {
+			decoder = rtfCodepage(1252)
+		}
+		decoded, err := decoder.NewDecoder().Bytes(pending)
+		if err != nil {
+			decoded = pending
+		}
+		text.Write(decoded)
+		pending = pending[:0]
+	}
+	write := func(s string) {
+		if !current.skip {
+			flush()
+			text.WriteString(s)
+		}
+	}
+
+	for i := 0; i < len(data); {
+		ch := data[i]
+		switch ch {
+		case '{':
+			stack = append(stack, current)
+			i++
+		case '}':
+			flush()
+			if len(stack) > 0 {
+				current = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			ucRemain = 0
+			i++
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch next := data[i]; {
+			case next == '\'':
+				// \'hh 代码页中的一个字节
+				if i+3 > len(data) {
+					i = len(data)
+					break
+				}
+				value, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8)
+				i += 3
+				if err != nil {
+					break
+				}
+				if ucRemain > 0 {
+					ucRemain--
+					break
+				}
+				if !current.skip {
+					pending = append(pending, byte(value))
+				}
+			case isASCIILetter(next):
+				start := i
+				for i < len(data) && isASCIILetter(data[i]) {
+					i++
+				}
+				word := string(data[start:i])
+				start = i
+				if i < len(data) && data[i] == '-' {
+					i++
+				}
+				for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+					i++
+				}
+				arg, hasArg := 0, i > start
+				if hasArg {
+					arg, _ = strconv.Atoi(string(data[start:i]))
+				}
+				if i < len(data) && data[i] == ' ' {
+					i++
+				}
+
+				switch {
+				case word == "bin" && hasArg:
+					if arg > 0 {
+						i += arg
+					}
+				case rtfSkipDestinations[word]:
+					current.skip = true
+				case word == "ansicpg" && hasArg:
+					decoder = rtfCodepage(arg)
+				case word == "uc" && hasArg:
+					current.uc = arg
+				case word == "u" && hasArg:
+					if arg < 0 {
+						arg += 65536
+					}
+					write(string(rune(arg)))
+					ucRemain = current.uc
+				default:
+					if symbol, ok := rtfSymbols[word]; ok {
+						write(symbol)
+					}
+				}
+			default:
+				// 控制符号
+				i++
+				switch next {
+				case '*':
+					current.skip = true
+				case '\\', '{', '}':
+					write(string(next))
+				case '~':
+					write(" ")
+				case '_':
+					write("-")
+				}
+			}
+		case '\r', '\n':
+			i++
+		default:
+			i++
+			if ucRemain > 0 {
+				ucRemain--
+				break
+			}
+			if !current.skip {
+				pending = append(pending, ch)
+			}
+		}
+	}
+	flush()
+	return text.String(), nil
+}
+
+// rtfCodepage 按 Windows 代码页选择解码方式，未知的代码页按 1252 处理
+func rtfCodepage(codepage int) encoding.Encoding {
+	switch codepage {
+	case 936, 54936:
+		return simplifiedchinese.GB18030
+	case 950:
+		return traditionalchinese.Big5
+	case 65001:
+		return unicode.UTF8
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	}
+	return charmap.Windows1252
+}
+
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
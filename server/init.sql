@@ -11,21 +11,27 @@ USE shuashuati;
 CREATE TABLE IF NOT EXISTS `users` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
     `open_id` VARCHAR(100) UNIQUE NOT NULL, -- Changed from openid to open_id
+    `union_id` VARCHAR(100),
+    `session_key` VARCHAR(100),
     `username` VARCHAR(50),
     `email` VARCHAR(100),
     `password` VARCHAR(255),
     `nickname` VARCHAR(100) DEFAULT '',
     `avatar` VARCHAR(500) DEFAULT '',
-    `role` ENUM('user','admin') DEFAULT 'user',
-    `status` VARCHAR(20) DEFAULT 'active',
+    `role` VARCHAR(50) DEFAULT 'user' COMMENT '角色名，对应 roles.name',
+    `status` VARCHAR(20) DEFAULT 'active' COMMENT '状态 active/disabled/banned/pending',
+    `ban_reason` VARCHAR(255) DEFAULT '',
+    `ban_expires_at` TIMESTAMP NULL,
     `is_verified` BOOLEAN DEFAULT false,
     `is_guest` BOOLEAN DEFAULT false,
     `total_answered` INT DEFAULT 0,
     `total_correct` INT DEFAULT 0,
     `accuracy_rate` DECIMAL(5,2) DEFAULT 0.00,
+    `points` INT DEFAULT 0 COMMENT '积分余额',
     `last_active_time` TIMESTAMP NULL,
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_users_union_id` (`union_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
 
 -- 管理员模型 (Added based on models.go)
@@ -62,6 +68,7 @@ CREATE TABLE IF NOT EXISTS `questions` (
     `type` VARCHAR(20) DEFAULT 'single',
     `options` JSON NOT NULL,
     `correct_answer` INT NOT NULL,
+    `fill_answers` JSON NULL COMMENT '填空题答案，每个空一组可接受答案',
     `explanation` TEXT,
     `difficulty` VARCHAR(20) DEFAULT 'medium',
     `category_id` BIGINT UNSIGNED NOT NULL,
@@ -69,8 +76,13 @@ CREATE TABLE IF NOT EXISTS `questions` (
     `total_answered` INT DEFAULT 0,
     `total_correct` INT DEFAULT 0,
     `accuracy_rate` DECIMAL(5,2) DEFAULT 0.00,
+    `estimated_accuracy` DECIMAL(5,4) NULL COMMENT '平滑后的第一次作答正确率',
+    `suggested_difficulty` VARCHAR(20) DEFAULT '' COMMENT '按作答数据建议的难度，样本不足时为空',
+    `calibration_sample` INT DEFAULT 0 COMMENT '校准时的作答人数',
+    `calibrated_at` TIMESTAMP NULL,
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_questions_suggested_difficulty` (`suggested_difficulty`),
     FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE,
     FOREIGN KEY (`creator_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目表';
@@ -81,13 +93,142 @@ CREATE TABLE IF NOT EXISTS `answer_records` (
     `user_id` BIGINT UNSIGNED NOT NULL,
     `question_id` BIGINT UNSIGNED NOT NULL,
     `user_answer` INT NOT NULL,
+    `answer_text` TEXT NULL COMMENT '填空题作答(JSON数组)',
     `is_correct` BOOLEAN NOT NULL,
     `time_spent` INT DEFAULT 0,
+    `attempt_no` INT NOT NULL DEFAULT 1 COMMENT '该用户对该题的第几次作答',
+    `mode` VARCHAR(20) DEFAULT 'practice' COMMENT '答题模式',
+    `session_id` BIGINT UNSIGNED NULL COMMENT '考试等会话ID',
     `answered_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Reverted to original
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Added created_at
-    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP -- Added updated_at
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- Added updated_at
+    UNIQUE INDEX `idx_answer_record_attempt` (`user_id`, `question_id`, `attempt_no`),
+    INDEX `idx_answer_record_session` (`session_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='答题记录表';
 
+-- 最新作答状态表
+CREATE TABLE IF NOT EXISTS `answer_states` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `attempt_count` INT DEFAULT 0,
+    `correct_count` INT DEFAULT 0,
+    `first_correct` BOOLEAN DEFAULT false COMMENT '第一次作答是否正确',
+    `first_answered_at` TIMESTAMP NULL,
+    `last_answer` INT DEFAULT 0,
+    `last_answer_text` TEXT NULL,
+    `last_correct` BOOLEAN DEFAULT false COMMENT '最近一次作答是否正确',
+    `last_answered_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_answer_state_user_question` (`user_id`, `question_id`),
+    INDEX `idx_answer_state_question` (`question_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='最新作答状态表';
+
+-- 顺序练习进度表
+CREATE TABLE IF NOT EXISTS `practice_cursors` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `category_id` BIGINT UNSIGNED NOT NULL,
+    `last_question_id` BIGINT UNSIGNED DEFAULT 0 COMMENT '最后作答的题目ID，题目按ID顺序练习',
+    `round` INT DEFAULT 1 COMMENT '第几轮练习',
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_practice_cursor_user_category` (`user_id`, `category_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='顺序练习进度表';
+
+-- 每日统计汇总表
+CREATE TABLE IF NOT EXISTS `daily_stats` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `stat_date` DATE NOT NULL,
+    `new_users` BIGINT DEFAULT 0,
+    `active_users` BIGINT DEFAULT 0 COMMENT '当日有作答的用户数',
+    `total_answers` BIGINT DEFAULT 0,
+    `correct_answers` BIGINT DEFAULT 0,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_daily_stats_stat_date` (`stat_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日统计汇总表';
+
+-- 每日活跃用户表
+CREATE TABLE IF NOT EXISTS `daily_active_users` (
+    `stat_date` DATE NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`stat_date`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日活跃用户表';
+
+-- 题目导入任务表
+CREATE TABLE IF NOT EXISTS `import_jobs` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `source` VARCHAR(20) NOT NULL COMMENT '来源 json/file/text',
+    `filename` VARCHAR(255) DEFAULT '',
+    `status` VARCHAR(20) NOT NULL,
+    `dry_run` BOOLEAN DEFAULT FALSE COMMENT '只校验不写入',
+    `skip_duplicates` BOOLEAN DEFAULT FALSE,
+    `update_existing` BOOLEAN DEFAULT FALSE,
+    `column_map` JSON NULL COMMENT '表格导入时字段对应的列',
+    `total` INT DEFAULT 0,
+    `processed` INT DEFAULT 0,
+    `created` INT DEFAULT 0,
+    `updated` INT DEFAULT 0,
+    `skipped` INT DEFAULT 0 COMMENT '重复而跳过的题目数',
+    `failed` INT DEFAULT 0,
+    `error` VARCHAR(500) DEFAULT '',
+    `creator_id` BIGINT UNSIGNED,
+    `lease_until` TIMESTAMP NULL,
+    `started_at` TIMESTAMP NULL,
+    `finished_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_import_jobs_status` (`status`),
+    INDEX `idx_import_jobs_creator_id` (`creator_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目导入任务表';
+
+-- 题目导入任务明细表
+CREATE TABLE IF NOT EXISTS `import_job_rows` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `job_id` VARCHAR(36) NOT NULL,
+    `seq` INT NOT NULL COMMENT '处理顺序',
+    `line_no` INT NOT NULL COMMENT '文件中的行号',
+    `item` JSON NOT NULL,
+    `action` VARCHAR(20) NOT NULL DEFAULT '' COMMENT 'created/updated/skipped/failed，未处理时为空',
+    `column_name` VARCHAR(10) DEFAULT '',
+    `header` VARCHAR(100) DEFAULT '',
+    `field` VARCHAR(20) DEFAULT '',
+    `message` VARCHAR(500) DEFAULT '',
+    UNIQUE INDEX `idx_import_job_rows_job_seq` (`job_id`, `seq`),
+    FOREIGN KEY (`job_id`) REFERENCES `import_jobs`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目导入任务明细表';
+
+-- 积分流水表
+CREATE TABLE IF NOT EXISTS `point_records` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `points` INT NOT NULL COMMENT '变动积分，扣分为负数',
+    `balance_after` INT NOT NULL DEFAULT 0 COMMENT '变动后的积分余额',
+    `reason` VARCHAR(30) NOT NULL,
+    `ref_type` VARCHAR(30) NULL COMMENT '关联对象类型',
+    `ref_id` BIGINT UNSIGNED NULL COMMENT '关联对象ID',
+    `category_id` BIGINT UNSIGNED NULL COMMENT '积分所属分类，用于分类排行',
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_point_records_user` (`user_id`),
+    INDEX `idx_point_records_category` (`category_id`),
+    INDEX `idx_point_records_created` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='积分流水表';
+
+-- 积分排行汇总表，category_id 为 0 表示全站排行
+CREATE TABLE IF NOT EXISTS `point_leaderboards` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `period` VARCHAR(10) NOT NULL COMMENT 'daily/weekly/all',
+    `period_key` VARCHAR(20) NOT NULL COMMENT '日期、ISO周或all',
+    `category_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `points` INT NOT NULL DEFAULT 0,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_point_leaderboard_entry` (`period`, `period_key`, `category_id`, `user_id`),
+    INDEX `idx_point_leaderboard_rank` (`period`, `period_key`, `category_id`, `points`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='积分排行汇总表';
+
 -- 错题本表
 CREATE TABLE IF NOT EXISTS `mistake_books` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
@@ -95,8 +236,15 @@ CREATE TABLE IF NOT EXISTS `mistake_books` (
     `question_id` BIGINT UNSIGNED NOT NULL,
     `is_mastered` BOOLEAN DEFAULT false,
     `added_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `ease_factor` DECIMAL(4,2) DEFAULT 2.50 COMMENT '难易系数',
+    `interval_days` INT DEFAULT 0 COMMENT '复习间隔(天)',
+    `repetitions` INT DEFAULT 0 COMMENT '连续答对的复习次数',
+    `review_count` INT DEFAULT 0,
+    `next_review_at` TIMESTAMP NULL COMMENT '下次复习时间',
+    `last_reviewed_at` TIMESTAMP NULL,
     `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Added created_at
-    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP -- Added updated_at
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- Added updated_at
+    INDEX `idx_mistake_books_review` (`user_id`, `is_mastered`, `next_review_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='错题本表';
 
 -- 操作日志表
@@ -123,6 +271,219 @@ CREATE TABLE IF NOT EXISTS `system_settings` (
     `deleted_at` TIMESTAMP NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='系统设置表';
 
+-- 登录失败计数表
+CREATE TABLE IF NOT EXISTS `login_failures` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `scope` VARCHAR(20) NOT NULL COMMENT '统计维度 account/ip',
+    `identifier` VARCHAR(100) NOT NULL COMMENT '用户名或IP',
+    `fail_count` INT DEFAULT 0,
+    `locked_until` TIMESTAMP NULL,
+    `last_failed_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY `idx_login_failure_scope_identifier` (`scope`, `identifier`),
+    INDEX `idx_login_failures_last_failed_at` (`last_failed_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录失败计数表';
+
+-- 登录会话表
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `ip` VARCHAR(45),
+    `user_agent` VARCHAR(500),
+    `expires_at` TIMESTAMP NOT NULL,
+    `last_used_at` TIMESTAMP NULL,
+    `revoked_at` TIMESTAMP NULL,
+    `revoke_reason` VARCHAR(50),
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_user_sessions_user_id` (`user_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录会话表';
+
+-- 刷新令牌表（只保存令牌哈希）
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `session_id` BIGINT UNSIGNED NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `token_hash` VARCHAR(64) UNIQUE NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `used_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_refresh_tokens_session_id` (`session_id`),
+    INDEX `idx_refresh_tokens_user_id` (`user_id`),
+    FOREIGN KEY (`session_id`) REFERENCES `user_sessions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
+
+-- 试卷表
+CREATE TABLE IF NOT EXISTS `papers` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `title` VARCHAR(200) NOT NULL,
+    `description` TEXT,
+    `mode` VARCHAR(20) DEFAULT 'manual' COMMENT '组卷方式 manual/rule',
+    `total_score` DECIMAL(8,2) DEFAULT 0.00,
+    `pass_score` DECIMAL(8,2) DEFAULT 0.00,
+    `time_limit` INT DEFAULT 0 COMMENT '考试时长(分钟) 0-不限时',
+    `status` VARCHAR(20) DEFAULT 'draft' COMMENT '状态 draft/published',
+    `creator_id` BIGINT UNSIGNED NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_papers_status` (`status`),
+    INDEX `idx_papers_creator_id` (`creator_id`),
+    FOREIGN KEY (`creator_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷表';
+
+-- 试卷分节表
+CREATE TABLE IF NOT EXISTS `paper_sections` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `title` VARCHAR(200) NOT NULL,
+    `description` VARCHAR(500),
+    `sort` INT DEFAULT 0,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_paper_sections_paper_id` (`paper_id`),
+    FOREIGN KEY (`paper_id`) REFERENCES `papers`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷分节表';
+
+-- 试卷题目表（手动组卷）
+CREATE TABLE IF NOT EXISTS `paper_questions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `section_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `score` DECIMAL(6,2) NOT NULL,
+    `sort` INT DEFAULT 0,
+    INDEX `idx_paper_questions_paper_id` (`paper_id`),
+    INDEX `idx_paper_questions_section_id` (`section_id`),
+    INDEX `idx_paper_questions_question_id` (`question_id`),
+    FOREIGN KEY (`section_id`) REFERENCES `paper_sections`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`question_id`) REFERENCES `questions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷题目表';
+
+-- 试卷抽题规则表（规则组卷）
+CREATE TABLE IF NOT EXISTS `paper_rules` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `section_id` BIGINT UNSIGNED NOT NULL,
+    `category_id` BIGINT UNSIGNED NULL COMMENT '为空表示不限分类',
+    `type` VARCHAR(20) DEFAULT '' COMMENT '为空表示不限题型',
+    `difficulty` VARCHAR(20) DEFAULT '' COMMENT '为空表示不限难度',
+    `count` INT NOT NULL,
+    `score` DECIMAL(6,2) NOT NULL COMMENT '每题分值',
+    `sort` INT DEFAULT 0,
+    INDEX `idx_paper_rules_paper_id` (`paper_id`),
+    INDEX `idx_paper_rules_section_id` (`section_id`),
+    FOREIGN KEY (`section_id`) REFERENCES `paper_sections`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷抽题规则表';
+
+-- 考试会话表
+CREATE TABLE IF NOT EXISTS `exam_sessions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `status` VARCHAR(20) DEFAULT 'in_progress' COMMENT '状态 in_progress/submitted',
+    `started_at` TIMESTAMP NOT NULL,
+    `deadline` TIMESTAMP NULL COMMENT '为空表示不限时',
+    `submitted_at` TIMESTAMP NULL,
+    `auto_submitted` BOOLEAN DEFAULT false COMMENT '是否超时自动交卷',
+    `time_spent` INT DEFAULT 0 COMMENT '用时(秒)',
+    `total_score` DECIMAL(8,2) DEFAULT 0.00,
+    `pass_score` DECIMAL(8,2) DEFAULT 0.00,
+    `score` DECIMAL(8,2) DEFAULT 0.00,
+    `passed` BOOLEAN DEFAULT false,
+    `question_count` INT DEFAULT 0,
+    `correct_count` INT DEFAULT 0,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_exam_sessions_user_id` (`user_id`),
+    INDEX `idx_exam_sessions_paper_status_score` (`paper_id`, `status`, `score`),
+    INDEX `idx_exam_sessions_status_deadline` (`status`, `deadline`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`paper_id`) REFERENCES `papers`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='考试会话表';
+
+-- 考试作答表
+CREATE TABLE IF NOT EXISTS `exam_answers` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `session_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `section_title` VARCHAR(200),
+    `section_sort` INT DEFAULT 0,
+    `sort` INT DEFAULT 0,
+    `score` DECIMAL(6,2) NOT NULL COMMENT '本题分值',
+    `user_answer` INT NULL COMMENT '选项索引或位掩码，为空表示未作答',
+    `answer_text` TEXT NULL COMMENT '填空题作答(JSON数组)',
+    `is_correct` BOOLEAN DEFAULT false,
+    `earned_score` DECIMAL(6,2) DEFAULT 0.00,
+    `answered_at` TIMESTAMP NULL,
+    INDEX `idx_exam_answers_session_id` (`session_id`),
+    INDEX `idx_exam_answers_question_id` (`question_id`),
+    FOREIGN KEY (`session_id`) REFERENCES `exam_sessions`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`question_id`) REFERENCES `questions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='考试作答表';
+
+-- 角色表
+CREATE TABLE IF NOT EXISTS `roles` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(50) UNIQUE NOT NULL,
+    `display_name` VARCHAR(100),
+    `description` VARCHAR(255),
+    `is_system` BOOLEAN DEFAULT false COMMENT '内置角色不可删除',
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色表';
+
+-- 权限表
+CREATE TABLE IF NOT EXISTS `permissions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `code` VARCHAR(50) UNIQUE NOT NULL,
+    `name` VARCHAR(100),
+    `description` VARCHAR(255),
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='权限表';
+
+-- 角色权限关联表
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+    `role_id` BIGINT UNSIGNED NOT NULL,
+    `permission_id` BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (`role_id`, `permission_id`),
+    FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色权限关联表';
+
+-- 插入内置角色
+INSERT IGNORE INTO `roles` (`name`, `display_name`, `description`, `is_system`) VALUES
+('admin', '超级管理员', '拥有全部后台权限', true),
+('editor', '内容编辑', '维护题目和分类，不能管理用户和系统设置', false),
+('user', '普通用户', '小程序用户，无后台权限', true),
+('guest', '游客', '未绑定账号的游客，无后台权限', true);
+
+-- 插入权限
+INSERT IGNORE INTO `permissions` (`code`, `name`) VALUES
+('question:read', '查看题目'),
+('question:write', '编辑题目'),
+('category:write', '编辑分类'),
+('paper:write', '编辑试卷'),
+('user:read', '查看用户'),
+('user:manage', '管理用户'),
+('statistics:read', '查看统计'),
+('logs:read', '查看操作日志'),
+('settings:read', '查看系统设置'),
+('settings:write', '修改系统设置'),
+('role:manage', '管理角色权限');
+
+-- 超级管理员拥有全部权限
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.id, p.id FROM `roles` r CROSS JOIN `permissions` p WHERE r.name = 'admin';
+
+-- 内容编辑只能维护题目、分类和试卷
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.id, p.id FROM `roles` r JOIN `permissions` p
+    ON p.code IN ('question:read', 'question:write', 'category:write', 'paper:write', 'statistics:read')
+WHERE r.name = 'editor';
+
 -- 插入默认管理员账号
 -- 密码是 123456 的 bcrypt 哈希值
 INSERT INTO `users` (`open_id`, `username`, `password`, `nickname`, `role`, `is_verified`) VALUES
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"qaminiprogram/config"
+	"qaminiprogram/controllers"
 	"qaminiprogram/routes"
 
 	"github.com/gin-gonic/gin"
@@ -26,9 +27,20 @@ func main() {
 	// 初始化数据库
 	config.InitDatabase()
 
+	// 启动超时考试自动交卷任务
+	controllers.StartExamSweeper()
+
+	// 启动导入任务的后台处理
+	controllers.StartImportJobWorker()
+
 	// 创建Gin引擎
 	r := gin.New()
 
+	// 只信任配置的反向代理转发的客户端 IP
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// 设置路由
 	routes.SetupRoutes(r)
 
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"qaminiprogram/config"
+	"qaminiprogram/models"
+	"strconv"
 	"strings"
 	"time"
 
@@ -107,19 +110,94 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 验证会话是否已被注销
+		if !isSessionActive(claims.SessionID, claims.UserID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": 401,
+				"message": "登录状态已失效，请重新登录",
+			})
+			c.Abort()
+			return
+		}
+
+		// 验证账号状态
+		var user models.User
+		if err := config.GetDB().Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": 401,
+				"message": "用户不存在",
+			})
+			c.Abort()
+			return
+		}
+		if code, message, denied := user.AccessDenial(); denied {
+			AbortWithUserStatus(c, &user, code, message)
+			return
+		}
+
 		// 将用户信息存储到上下文
 		c.Set("userId", claims.UserID)
 		c.Set("openid", claims.OpenID)
-		c.Set("role", claims.Role)
+		// 角色以数据库为准，调整角色后无需重新登录即可生效
+		c.Set("role", user.Role)
+		c.Set("sessionId", claims.SessionID)
 		c.Next()
 	}
 }
 
-// AdminAuth 管理员认证中间件
+// OptionalJWTAuth 可选认证中间件：携带有效令牌时写入用户信息，未携带或令牌无效时按匿名访问继续
+func OptionalJWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := ParseJWT(token)
+		if err != nil || !isSessionActive(claims.SessionID, claims.UserID) {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		if err := config.GetDB().Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			c.Next()
+			return
+		}
+		if _, _, denied := user.AccessDenial(); denied {
+			c.Next()
+			return
+		}
+
+		c.Set("userId", claims.UserID)
+		c.Set("openid", claims.OpenID)
+		c.Set("role", user.Role)
+		c.Set("sessionId", claims.SessionID)
+		c.Next()
+	}
+}
+
+// AbortWithUserStatus 以账号状态错误码终止请求，并返回状态详情供前端展示
+func AbortWithUserStatus(c *gin.Context, user *models.User, code int, message string) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"code":    code,
+		"message": message,
+		"data": gin.H{
+			"status":       user.EffectiveStatus(),
+			"banReason":    user.BanReason,
+			"banExpiresAt": user.BanExpiresAt,
+		},
+	})
+	c.Abort()
+}
+
+// AdminAuth 管理员认证中间件，角色拥有任一后台权限即可进入管理后台
 func AdminAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
+		role, _ := c.Get("role")
+		roleName, _ := role.(string)
+		if len(GetRolePermissions(roleName)) == 0 {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code": 403,
 				"message": "需要管理员权限",
@@ -131,30 +209,106 @@ func AdminAuth() gin.HandlerFunc {
 	}
 }
 
+// RequirePermission 权限校验中间件，要求当前角色拥有指定权限
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		roleName, _ := role.(string)
+		if !HasPermission(roleName, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": 403,
+				"message": "权限不足：需要 " + permission,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetRolePermissions 查询角色拥有的权限编码
+func GetRolePermissions(role string) []string {
+	if role == "" {
+		return nil
+	}
+	var codes []string
+	config.GetDB().Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", role).
+		Pluck("permissions.code", &codes)
+	return codes
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role, permission string) bool {
+	for _, code := range GetRolePermissions(role) {
+		if code == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // JWTClaims JWT声明结构
 type JWTClaims struct {
-	UserID uint      `json:"userId"`
-	OpenID string    `json:"openid"`
-	Role   string    `json:"role"`
+	UserID    uint   `json:"userId"`
+	OpenID    string `json:"openid"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT 生成JWT令牌
-func GenerateJWT(userID uint, openID, role string) (string, error) {
-	expireTime := time.Now().Add(24 * time.Hour)
+// AccessTokenTTL 访问令牌有效期，默认30分钟，可通过JWT_ACCESS_EXPIRE_MINUTES配置
+func AccessTokenTTL() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("JWT_ACCESS_EXPIRE_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期，默认7天，可通过JWT_REFRESH_EXPIRE_HOURS配置
+func RefreshTokenTTL() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("JWT_REFRESH_EXPIRE_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// GenerateJWT 生成访问令牌，返回令牌及其过期时间
+func GenerateJWT(userID uint, openID, role string, sessionID uint) (string, time.Time, error) {
+	now := time.Now()
+	expireTime := now.Add(AccessTokenTTL())
 	claims := &JWTClaims{
-		UserID: userID,
-		OpenID: openID,
-		Role:   role,
+		UserID:    userID,
+		OpenID:    openID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expireTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "qaminiprogram",
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expireTime, nil
+}
+
+// isSessionActive 检查令牌所属会话是否仍然有效（未注销且未过期）
+func isSessionActive(sessionID, userID uint) bool {
+	if sessionID == 0 {
+		return false
+	}
+	var count int64
+	config.GetDB().Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).
+		Count(&count)
+	return count > 0
 }
 
 // ParseJWT 解析JWT令牌
@@ -0,0 +1,9 @@
+-- 用户状态枚举及封禁信息
+ALTER TABLE `users`
+    MODIFY COLUMN `status` VARCHAR(20) DEFAULT 'active' COMMENT '状态 active/disabled/banned/pending',
+    ADD COLUMN `ban_reason` VARCHAR(255) DEFAULT '' AFTER `status`,
+    ADD COLUMN `ban_expires_at` TIMESTAMP NULL AFTER `ban_reason`;
+
+-- 历史数据中的非法状态统一视为正常
+UPDATE `users` SET `status` = 'active'
+WHERE `status` IS NULL OR `status` NOT IN ('active', 'disabled', 'banned', 'pending');
@@ -0,0 +1,61 @@
+-- 基于数据库的角色权限
+ALTER TABLE `users` MODIFY COLUMN `role` VARCHAR(50) DEFAULT 'user' COMMENT '角色名，对应 roles.name';
+
+-- 角色表
+CREATE TABLE IF NOT EXISTS `roles` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(50) UNIQUE NOT NULL,
+    `display_name` VARCHAR(100),
+    `description` VARCHAR(255),
+    `is_system` BOOLEAN DEFAULT false COMMENT '内置角色不可删除',
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色表';
+
+-- 权限表
+CREATE TABLE IF NOT EXISTS `permissions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `code` VARCHAR(50) UNIQUE NOT NULL,
+    `name` VARCHAR(100),
+    `description` VARCHAR(255),
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='权限表';
+
+-- 角色权限关联表
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+    `role_id` BIGINT UNSIGNED NOT NULL,
+    `permission_id` BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (`role_id`, `permission_id`),
+    FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色权限关联表';
+
+-- 插入内置角色
+INSERT IGNORE INTO `roles` (`name`, `display_name`, `description`, `is_system`) VALUES
+('admin', '超级管理员', '拥有全部后台权限', true),
+('editor', '内容编辑', '维护题目和分类，不能管理用户和系统设置', false),
+('user', '普通用户', '小程序用户，无后台权限', true),
+('guest', '游客', '未绑定账号的游客，无后台权限', true);
+
+-- 插入权限
+INSERT IGNORE INTO `permissions` (`code`, `name`) VALUES
+('question:read', '查看题目'),
+('question:write', '编辑题目'),
+('category:write', '编辑分类'),
+('user:read', '查看用户'),
+('user:manage', '管理用户'),
+('statistics:read', '查看统计'),
+('logs:read', '查看操作日志'),
+('settings:read', '查看系统设置'),
+('settings:write', '修改系统设置'),
+('role:manage', '管理角色权限');
+
+-- 超级管理员拥有全部权限
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.id, p.id FROM `roles` r CROSS JOIN `permissions` p WHERE r.name = 'admin';
+
+-- 内容编辑只能维护题目和分类
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.id, p.id FROM `roles` r JOIN `permissions` p
+    ON p.code IN ('question:read', 'question:write', 'category:write', 'statistics:read')
+WHERE r.name = 'editor';
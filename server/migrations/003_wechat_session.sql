@@ -0,0 +1,5 @@
+-- 保存微信 code2session 返回的 unionid 和 session_key
+ALTER TABLE `users`
+    ADD COLUMN `union_id` VARCHAR(100) AFTER `open_id`,
+    ADD COLUMN `session_key` VARCHAR(100) AFTER `union_id`,
+    ADD INDEX `idx_users_union_id` (`union_id`);
@@ -0,0 +1,70 @@
+-- 试卷及组卷规则
+-- 试卷表
+CREATE TABLE IF NOT EXISTS `papers` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `title` VARCHAR(200) NOT NULL,
+    `description` TEXT,
+    `mode` VARCHAR(20) DEFAULT 'manual' COMMENT '组卷方式 manual/rule',
+    `total_score` DECIMAL(8,2) DEFAULT 0.00,
+    `pass_score` DECIMAL(8,2) DEFAULT 0.00,
+    `time_limit` INT DEFAULT 0 COMMENT '考试时长(分钟) 0-不限时',
+    `status` VARCHAR(20) DEFAULT 'draft' COMMENT '状态 draft/published',
+    `creator_id` BIGINT UNSIGNED NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_papers_status` (`status`),
+    INDEX `idx_papers_creator_id` (`creator_id`),
+    FOREIGN KEY (`creator_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷表';
+
+-- 试卷分节表
+CREATE TABLE IF NOT EXISTS `paper_sections` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `title` VARCHAR(200) NOT NULL,
+    `description` VARCHAR(500),
+    `sort` INT DEFAULT 0,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_paper_sections_paper_id` (`paper_id`),
+    FOREIGN KEY (`paper_id`) REFERENCES `papers`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷分节表';
+
+-- 试卷题目表（手动组卷）
+CREATE TABLE IF NOT EXISTS `paper_questions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `section_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `score` DECIMAL(6,2) NOT NULL,
+    `sort` INT DEFAULT 0,
+    INDEX `idx_paper_questions_paper_id` (`paper_id`),
+    INDEX `idx_paper_questions_section_id` (`section_id`),
+    INDEX `idx_paper_questions_question_id` (`question_id`),
+    FOREIGN KEY (`section_id`) REFERENCES `paper_sections`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`question_id`) REFERENCES `questions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷题目表';
+
+-- 试卷抽题规则表（规则组卷）
+CREATE TABLE IF NOT EXISTS `paper_rules` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `section_id` BIGINT UNSIGNED NOT NULL,
+    `category_id` BIGINT UNSIGNED NULL COMMENT '为空表示不限分类',
+    `type` VARCHAR(20) DEFAULT '' COMMENT '为空表示不限题型',
+    `difficulty` VARCHAR(20) DEFAULT '' COMMENT '为空表示不限难度',
+    `count` INT NOT NULL,
+    `score` DECIMAL(6,2) NOT NULL COMMENT '每题分值',
+    `sort` INT DEFAULT 0,
+    INDEX `idx_paper_rules_paper_id` (`paper_id`),
+    INDEX `idx_paper_rules_section_id` (`section_id`),
+    FOREIGN KEY (`section_id`) REFERENCES `paper_sections`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='试卷抽题规则表';
+
+-- 试卷编辑权限，授予超级管理员和内容编辑
+INSERT IGNORE INTO `permissions` (`code`, `name`) VALUES ('paper:write', '编辑试卷');
+
+INSERT IGNORE INTO `role_permissions` (`role_id`, `permission_id`)
+SELECT r.id, p.id FROM `roles` r JOIN `permissions` p ON p.code = 'paper:write'
+WHERE r.name IN ('admin', 'editor');
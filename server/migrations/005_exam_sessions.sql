@@ -0,0 +1,45 @@
+-- 限时考试会话
+-- 考试会话表
+CREATE TABLE IF NOT EXISTS `exam_sessions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `paper_id` BIGINT UNSIGNED NOT NULL,
+    `status` VARCHAR(20) DEFAULT 'in_progress' COMMENT '状态 in_progress/submitted',
+    `started_at` TIMESTAMP NOT NULL,
+    `deadline` TIMESTAMP NULL COMMENT '为空表示不限时',
+    `submitted_at` TIMESTAMP NULL,
+    `auto_submitted` BOOLEAN DEFAULT false COMMENT '是否超时自动交卷',
+    `time_spent` INT DEFAULT 0 COMMENT '用时(秒)',
+    `total_score` DECIMAL(8,2) DEFAULT 0.00,
+    `pass_score` DECIMAL(8,2) DEFAULT 0.00,
+    `score` DECIMAL(8,2) DEFAULT 0.00,
+    `passed` BOOLEAN DEFAULT false,
+    `question_count` INT DEFAULT 0,
+    `correct_count` INT DEFAULT 0,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_exam_sessions_user_id` (`user_id`),
+    INDEX `idx_exam_sessions_paper_status_score` (`paper_id`, `status`, `score`),
+    INDEX `idx_exam_sessions_status_deadline` (`status`, `deadline`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`paper_id`) REFERENCES `papers`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='考试会话表';
+
+-- 考试作答表
+CREATE TABLE IF NOT EXISTS `exam_answers` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `session_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `section_title` VARCHAR(200),
+    `section_sort` INT DEFAULT 0,
+    `sort` INT DEFAULT 0,
+    `score` DECIMAL(6,2) NOT NULL COMMENT '本题分值',
+    `user_answer` INT NULL COMMENT '为空表示未作答',
+    `is_correct` BOOLEAN DEFAULT false,
+    `earned_score` DECIMAL(6,2) DEFAULT 0.00,
+    `answered_at` TIMESTAMP NULL,
+    INDEX `idx_exam_answers_session_id` (`session_id`),
+    INDEX `idx_exam_answers_question_id` (`question_id`),
+    FOREIGN KEY (`session_id`) REFERENCES `exam_sessions`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`question_id`) REFERENCES `questions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='考试作答表';
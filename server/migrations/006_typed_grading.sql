@@ -0,0 +1,16 @@
+-- 按题型判分
+-- 填空题作答以JSON数组保存
+ALTER TABLE `answer_records` ADD COLUMN `answer_text` TEXT NULL COMMENT '填空题作答(JSON数组)' AFTER `user_answer`;
+ALTER TABLE `exam_answers` ADD COLUMN `answer_text` TEXT NULL COMMENT '填空题作答(JSON数组)' AFTER `user_answer`;
+
+-- 早期导入的题目没有写入题型，按选项和答案推断
+UPDATE `questions` SET `type` = 'fill'
+WHERE `type` = 'single' AND JSON_LENGTH(`options`) = 0;
+
+UPDATE `questions` SET `type` = 'judge'
+WHERE `type` = 'single' AND JSON_LENGTH(`options`) = 2
+    AND JSON_UNQUOTE(JSON_EXTRACT(`options`, '$[0]')) = '正确'
+    AND JSON_UNQUOTE(JSON_EXTRACT(`options`, '$[1]')) = '错误';
+
+UPDATE `questions` SET `type` = 'multiple'
+WHERE `type` = 'single' AND `correct_answer` >= JSON_LENGTH(`options`);
@@ -0,0 +1,76 @@
+-- 填空题答案独立存储
+ALTER TABLE `questions` ADD COLUMN `fill_answers` JSON NULL COMMENT '填空题答案，每个空一组可接受答案' AFTER `correct_answer`;
+
+-- 把旧导入写入解析第一行的答案迁移到 fill_answers，并从解析中移除
+-- 旧导入的格式：解析只有答案一行，或答案一行后空一行再接原解析；不符合该格式的题目不做处理
+-- 答案行的格式：空之间用分号分隔，同一空的多个答案用竖线分隔，空白答案忽略
+-- 按分隔符逐段拆分后用 JSON_ARRAY/JSON_ARRAY_APPEND 组装，答案中的引号、反斜杠等字符无需转义
+CREATE TEMPORARY TABLE `fill_answers_migration` (
+    `id` BIGINT UNSIGNED PRIMARY KEY,
+    `fill_answers` JSON NOT NULL
+);
+
+INSERT INTO `fill_answers_migration` (`id`, `fill_answers`)
+WITH RECURSIVE
+    -- 答案行统一为半角分隔符，末尾补一个分号作为最后一个空的结束
+    `answer_lines` AS (
+        SELECT `id`,
+            CONCAT(REPLACE(REPLACE(TRIM(SUBSTRING_INDEX(`explanation`, '\n', 1)), '；', ';'), '｜', '|'), ';') AS `line`
+        FROM `questions`
+        WHERE `type` = 'fill' AND `fill_answers` IS NULL
+            AND `explanation` IS NOT NULL
+            AND TRIM(SUBSTRING_INDEX(`explanation`, '\n', 1)) <> ''
+            AND (LOCATE('\n', `explanation`) = 0 OR LOCATE('\n\n', `explanation`) = LOCATE('\n', `explanation`))
+    ),
+    -- 逐段拆分：token 为一个答案，sep 为其后的分隔符（分号结束一个空，竖线分隔同一空的答案）
+    `answer_tokens` AS (
+        SELECT `id`, 1 AS `n`,
+            TRIM(LEFT(`line`, `pos` - 1)) AS `token`,
+            SUBSTRING(`line`, `pos`, 1) AS `sep`,
+            SUBSTRING(`line`, `pos` + 1) AS `rest`
+        FROM (
+            SELECT `id`, `line`,
+                IF(LOCATE('|', `line`) > 0 AND LOCATE('|', `line`) < LOCATE(';', `line`), LOCATE('|', `line`), LOCATE(';', `line`)) AS `pos`
+            FROM `answer_lines`
+        ) AS `first_tokens`
+        UNION ALL
+        SELECT `id`, `n` + 1,
+            TRIM(LEFT(`rest`, IF(LOCATE('|', `rest`) > 0 AND LOCATE('|', `rest`) < LOCATE(';', `rest`), LOCATE('|', `rest`), LOCATE(';', `rest`)) - 1)),
+            SUBSTRING(`rest`, IF(LOCATE('|', `rest`) > 0 AND LOCATE('|', `rest`) < LOCATE(';', `rest`), LOCATE('|', `rest`), LOCATE(';', `rest`)), 1),
+            SUBSTRING(`rest`, IF(LOCATE('|', `rest`) > 0 AND LOCATE('|', `rest`) < LOCATE(';', `rest`), LOCATE('|', `rest`), LOCATE(';', `rest`)) + 1)
+        FROM `answer_tokens`
+        WHERE `rest` <> ''
+    ),
+    -- 依次累积：current 为当前空的答案，遇到分号时追加到 answers；任一空没有答案时 valid 为 0
+    `answer_arrays` AS (
+        SELECT `id`, 0 AS `n`, JSON_ARRAY() AS `answers`, JSON_ARRAY() AS `current`, 1 AS `valid`, 0 AS `done`
+        FROM `answer_lines`
+        UNION ALL
+        SELECT a.`id`, t.`n`,
+            IF(t.`sep` = ';',
+                JSON_ARRAY_APPEND(a.`answers`, '$', IF(t.`token` = '', a.`current`, JSON_ARRAY_APPEND(a.`current`, '$', t.`token`))),
+                a.`answers`),
+            IF(t.`sep` = ';',
+                JSON_ARRAY(),
+                IF(t.`token` = '', a.`current`, JSON_ARRAY_APPEND(a.`current`, '$', t.`token`))),
+            a.`valid` AND (t.`sep` <> ';' OR t.`token` <> '' OR JSON_LENGTH(a.`current`) > 0),
+            t.`rest` = ''
+        FROM `answer_arrays` a
+        JOIN `answer_tokens` t ON t.`id` = a.`id` AND t.`n` = a.`n` + 1
+    )
+SELECT `id`, `answers` FROM `answer_arrays` WHERE `done` AND `valid`;
+
+UPDATE `questions` q
+JOIN `fill_answers_migration` m ON m.`id` = q.`id`
+SET
+    q.`fill_answers` = m.`fill_answers`,
+    q.`explanation` = CASE
+        WHEN LOCATE('\n', q.`explanation`) > 0
+            THEN TRIM(LEADING '\n' FROM SUBSTRING(q.`explanation`, LOCATE('\n', q.`explanation`) + 1))
+        ELSE ''
+    END;
+
+DROP TEMPORARY TABLE `fill_answers_migration`;
+
+-- 未迁移的填空题（解析不是旧导入格式或答案为空）需要在管理后台补充答案：
+-- SELECT `id`, `title` FROM `questions` WHERE `type` = 'fill' AND `fill_answers` IS NULL;
@@ -0,0 +1,48 @@
+-- 保留每次作答
+-- 答题记录改为只追加，记录作答次序、答题模式和所属会话
+ALTER TABLE `answer_records`
+    ADD COLUMN `attempt_no` INT NOT NULL DEFAULT 1 COMMENT '该用户对该题的第几次作答' AFTER `time_spent`,
+    ADD COLUMN `mode` VARCHAR(20) DEFAULT 'practice' COMMENT '答题模式' AFTER `attempt_no`,
+    ADD COLUMN `session_id` BIGINT UNSIGNED NULL COMMENT '考试等会话ID' AFTER `mode`;
+
+UPDATE `answer_records` r
+JOIN (
+    SELECT `id`, ROW_NUMBER() OVER (PARTITION BY `user_id`, `question_id` ORDER BY `answered_at`, `id`) AS `attempt_no`
+    FROM `answer_records`
+) o ON o.`id` = r.`id`
+SET r.`attempt_no` = o.`attempt_no`;
+
+ALTER TABLE `answer_records`
+    ADD UNIQUE INDEX `idx_answer_record_attempt` (`user_id`, `question_id`, `attempt_no`),
+    ADD INDEX `idx_answer_record_session` (`session_id`);
+
+-- 每道题的最新作答状态
+CREATE TABLE IF NOT EXISTS `answer_states` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `question_id` BIGINT UNSIGNED NOT NULL,
+    `attempt_count` INT DEFAULT 0,
+    `correct_count` INT DEFAULT 0,
+    `first_correct` BOOLEAN DEFAULT false COMMENT '第一次作答是否正确',
+    `first_answered_at` TIMESTAMP NULL,
+    `last_answer` INT DEFAULT 0,
+    `last_answer_text` TEXT NULL,
+    `last_correct` BOOLEAN DEFAULT false COMMENT '最近一次作答是否正确',
+    `last_answered_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_answer_state_user_question` (`user_id`, `question_id`),
+    INDEX `idx_answer_state_question` (`question_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='最新作答状态表';
+
+INSERT INTO `answer_states` (`user_id`, `question_id`, `attempt_count`, `correct_count`, `first_correct`, `first_answered_at`,
+    `last_answer`, `last_answer_text`, `last_correct`, `last_answered_at`)
+SELECT f.`user_id`, f.`question_id`, agg.`attempt_count`, agg.`correct_count`, f.`is_correct`, f.`answered_at`,
+    l.`user_answer`, l.`answer_text`, l.`is_correct`, l.`answered_at`
+FROM (
+    SELECT `user_id`, `question_id`, COUNT(*) AS `attempt_count`, SUM(`is_correct`) AS `correct_count`, MAX(`attempt_no`) AS `last_no`
+    FROM `answer_records`
+    GROUP BY `user_id`, `question_id`
+) agg
+JOIN `answer_records` f ON f.`user_id` = agg.`user_id` AND f.`question_id` = agg.`question_id` AND f.`attempt_no` = 1
+JOIN `answer_records` l ON l.`user_id` = agg.`user_id` AND l.`question_id` = agg.`question_id` AND l.`attempt_no` = agg.`last_no`;
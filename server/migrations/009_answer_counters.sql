@@ -0,0 +1,24 @@
+-- 答题计数
+-- 按答题记录回填用户和题目上的答题计数，之后由提交答案时同步累加
+UPDATE `users` u
+LEFT JOIN (
+    SELECT `user_id`, COUNT(*) AS `total_answered`, SUM(`is_correct`) AS `total_correct`, MAX(`answered_at`) AS `last_answered`
+    FROM `answer_records`
+    GROUP BY `user_id`
+) a ON a.`user_id` = u.`id`
+SET
+    u.`total_answered` = COALESCE(a.`total_answered`, 0),
+    u.`total_correct` = COALESCE(a.`total_correct`, 0),
+    u.`accuracy_rate` = CASE WHEN a.`total_answered` > 0 THEN a.`total_correct` * 100.0 / a.`total_answered` ELSE 0 END,
+    u.`last_active_time` = GREATEST(COALESCE(u.`last_active_time`, a.`last_answered`), COALESCE(a.`last_answered`, u.`last_active_time`));
+
+UPDATE `questions` q
+LEFT JOIN (
+    SELECT `question_id`, COUNT(*) AS `total_answered`, SUM(`is_correct`) AS `total_correct`
+    FROM `answer_records`
+    GROUP BY `question_id`
+) a ON a.`question_id` = q.`id`
+SET
+    q.`total_answered` = COALESCE(a.`total_answered`, 0),
+    q.`total_correct` = COALESCE(a.`total_correct`, 0),
+    q.`accuracy_rate` = CASE WHEN a.`total_answered` > 0 THEN a.`total_correct` * 100.0 / a.`total_answered` ELSE 0 END;
@@ -0,0 +1,20 @@
+-- 错题本间隔复习
+-- 为错题记录增加 SM-2 复习计划
+ALTER TABLE `mistake_books`
+    ADD COLUMN `ease_factor` DECIMAL(4,2) DEFAULT 2.50 COMMENT '难易系数' AFTER `added_at`,
+    ADD COLUMN `interval_days` INT DEFAULT 0 COMMENT '复习间隔(天)' AFTER `ease_factor`,
+    ADD COLUMN `repetitions` INT DEFAULT 0 COMMENT '连续答对的复习次数' AFTER `interval_days`,
+    ADD COLUMN `review_count` INT DEFAULT 0 AFTER `repetitions`,
+    ADD COLUMN `next_review_at` TIMESTAMP NULL COMMENT '下次复习时间' AFTER `review_count`,
+    ADD COLUMN `last_reviewed_at` TIMESTAMP NULL AFTER `next_review_at`,
+    ADD INDEX `idx_mistake_books_review` (`user_id`, `is_mastered`, `next_review_at`);
+
+-- 已有的未掌握错题从加入后的第二天开始复习，逾期的直接进入今日队列
+UPDATE `mistake_books`
+SET `interval_days` = 1, `next_review_at` = `added_at` + INTERVAL 1 DAY, `updated_at` = `updated_at`
+WHERE `is_mastered` = false;
+
+-- 已掌握的错题视为已完成复习
+UPDATE `mistake_books`
+SET `repetitions` = 3, `updated_at` = `updated_at`
+WHERE `is_mastered` = true;
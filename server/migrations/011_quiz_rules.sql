@@ -0,0 +1,20 @@
+-- 答题设置生效
+-- 用户积分余额和积分流水
+ALTER TABLE `users` ADD COLUMN `points` INT DEFAULT 0 COMMENT '积分余额' AFTER `accuracy_rate`;
+
+CREATE TABLE IF NOT EXISTS `point_records` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `points` INT NOT NULL COMMENT '变动积分，扣分为负数',
+    `reason` VARCHAR(30) NOT NULL,
+    `question_id` BIGINT UNSIGNED NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_point_records_user` (`user_id`),
+    INDEX `idx_point_records_question` (`question_id`),
+    INDEX `idx_point_records_created` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='积分流水表';
+
+-- 统一解析显示方式的取值
+UPDATE `system_settings`
+SET `value` = JSON_SET(`value`, '$.show_explanation', 'after_answer')
+WHERE `key` = 'quiz' AND JSON_VALID(`value`) AND JSON_UNQUOTE(JSON_EXTRACT(`value`, '$.show_explanation')) = 'afterAnswer';
@@ -0,0 +1,48 @@
+-- 积分流水和排行榜
+-- 积分流水增加余额快照、通用的关联对象和所属分类
+ALTER TABLE `point_records`
+    ADD COLUMN `balance_after` INT NOT NULL DEFAULT 0 COMMENT '变动后的积分余额' AFTER `points`,
+    ADD COLUMN `ref_type` VARCHAR(30) NULL COMMENT '关联对象类型' AFTER `reason`,
+    ADD COLUMN `ref_id` BIGINT UNSIGNED NULL COMMENT '关联对象ID' AFTER `ref_type`,
+    ADD COLUMN `category_id` BIGINT UNSIGNED NULL COMMENT '积分所属分类，用于分类排行' AFTER `ref_id`,
+    ADD INDEX `idx_point_records_category` (`category_id`);
+
+UPDATE `point_records` r
+LEFT JOIN `questions` q ON q.`id` = r.`question_id`
+SET r.`ref_type` = 'question', r.`ref_id` = r.`question_id`, r.`category_id` = q.`category_id`
+WHERE r.`question_id` IS NOT NULL;
+
+ALTER TABLE `point_records` DROP INDEX `idx_point_records_question`, DROP COLUMN `question_id`;
+
+UPDATE `point_records` r
+JOIN (
+    SELECT `id`, SUM(`points`) OVER (PARTITION BY `user_id` ORDER BY `created_at`, `id`) AS `balance_after`
+    FROM `point_records`
+) b ON b.`id` = r.`id`
+SET r.`balance_after` = b.`balance_after`;
+
+-- 积分排行汇总，category_id 为 0 表示全站排行
+CREATE TABLE IF NOT EXISTS `point_leaderboards` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `period` VARCHAR(10) NOT NULL COMMENT 'daily/weekly/all',
+    `period_key` VARCHAR(20) NOT NULL COMMENT '日期、ISO周或all',
+    `category_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `points` INT NOT NULL DEFAULT 0,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_point_leaderboard_entry` (`period`, `period_key`, `category_id`, `user_id`),
+    INDEX `idx_point_leaderboard_rank` (`period`, `period_key`, `category_id`, `points`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='积分排行汇总表';
+
+INSERT INTO `point_leaderboards` (`period`, `period_key`, `category_id`, `user_id`, `points`)
+SELECT 'daily', DATE_FORMAT(`created_at`, '%Y-%m-%d'), 0, `user_id`, SUM(`points`) FROM `point_records` GROUP BY 2, `user_id`
+UNION ALL
+SELECT 'weekly', DATE_FORMAT(`created_at`, '%x-W%v'), 0, `user_id`, SUM(`points`) FROM `point_records` GROUP BY 2, `user_id`
+UNION ALL
+SELECT 'all', 'all', 0, `user_id`, SUM(`points`) FROM `point_records` GROUP BY `user_id`
+UNION ALL
+SELECT 'daily', DATE_FORMAT(`created_at`, '%Y-%m-%d'), `category_id`, `user_id`, SUM(`points`) FROM `point_records` WHERE `category_id` IS NOT NULL GROUP BY 2, `category_id`, `user_id`
+UNION ALL
+SELECT 'weekly', DATE_FORMAT(`created_at`, '%x-W%v'), `category_id`, `user_id`, SUM(`points`) FROM `point_records` WHERE `category_id` IS NOT NULL GROUP BY 2, `category_id`, `user_id`
+UNION ALL
+SELECT 'all', 'all', `category_id`, `user_id`, SUM(`points`) FROM `point_records` WHERE `category_id` IS NOT NULL GROUP BY `category_id`, `user_id`;
@@ -0,0 +1,11 @@
+-- 顺序练习进度
+CREATE TABLE IF NOT EXISTS `practice_cursors` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `category_id` BIGINT UNSIGNED NOT NULL,
+    `last_question_id` BIGINT UNSIGNED DEFAULT 0 COMMENT '最后作答的题目ID，题目按ID顺序练习',
+    `round` INT DEFAULT 1 COMMENT '第几轮练习',
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_practice_cursor_user_category` (`user_id`, `category_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='顺序练习进度表';
@@ -0,0 +1,7 @@
+-- 按作答数据校准题目难度
+ALTER TABLE `questions`
+    ADD COLUMN `estimated_accuracy` DECIMAL(5,4) NULL COMMENT '平滑后的第一次作答正确率' AFTER `accuracy_rate`,
+    ADD COLUMN `suggested_difficulty` VARCHAR(20) DEFAULT '' COMMENT '按作答数据建议的难度，样本不足时为空' AFTER `estimated_accuracy`,
+    ADD COLUMN `calibration_sample` INT DEFAULT 0 COMMENT '校准时的作答人数' AFTER `suggested_difficulty`,
+    ADD COLUMN `calibrated_at` TIMESTAMP NULL AFTER `calibration_sample`,
+    ADD INDEX `idx_questions_suggested_difficulty` (`suggested_difficulty`);
@@ -0,0 +1,37 @@
+-- 每日统计汇总
+CREATE TABLE IF NOT EXISTS `daily_stats` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `stat_date` DATE NOT NULL,
+    `new_users` BIGINT DEFAULT 0,
+    `active_users` BIGINT DEFAULT 0 COMMENT '当日有作答的用户数',
+    `total_answers` BIGINT DEFAULT 0,
+    `correct_answers` BIGINT DEFAULT 0,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX `idx_daily_stats_stat_date` (`stat_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日统计汇总表';
+
+-- 每日有作答的用户
+CREATE TABLE IF NOT EXISTS `daily_active_users` (
+    `stat_date` DATE NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`stat_date`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日活跃用户表';
+
+-- 按数据库中的日期回填历史数据
+-- 数据库时区与 APP_TIMEZONE 不一致时，部署后调用 POST /admin/statistics/daily/rebuild 按业务时区重建
+INSERT IGNORE INTO `daily_active_users` (`stat_date`, `user_id`)
+SELECT DISTINCT DATE(`answered_at`), `user_id` FROM `answer_records`;
+
+INSERT INTO `daily_stats` (`stat_date`, `active_users`, `total_answers`, `correct_answers`)
+SELECT DATE(`answered_at`), COUNT(DISTINCT `user_id`), COUNT(*), SUM(`is_correct`)
+FROM `answer_records`
+GROUP BY DATE(`answered_at`)
+ON DUPLICATE KEY UPDATE
+    `active_users` = VALUES(`active_users`),
+    `total_answers` = VALUES(`total_answers`),
+    `correct_answers` = VALUES(`correct_answers`);
+
+INSERT INTO `daily_stats` (`stat_date`, `new_users`)
+SELECT DATE(`created_at`), COUNT(*) FROM `users` GROUP BY DATE(`created_at`)
+ON DUPLICATE KEY UPDATE `new_users` = VALUES(`new_users`);
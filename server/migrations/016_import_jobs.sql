@@ -0,0 +1,42 @@
+-- 后台题目导入任务
+CREATE TABLE IF NOT EXISTS `import_jobs` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `source` VARCHAR(20) NOT NULL COMMENT '来源 json/file/text',
+    `filename` VARCHAR(255) DEFAULT '',
+    `status` VARCHAR(20) NOT NULL,
+    `dry_run` BOOLEAN DEFAULT FALSE COMMENT '只校验不写入',
+    `skip_duplicates` BOOLEAN DEFAULT FALSE,
+    `update_existing` BOOLEAN DEFAULT FALSE,
+    `column_map` JSON NULL COMMENT '表格导入时字段对应的列',
+    `total` INT DEFAULT 0,
+    `processed` INT DEFAULT 0,
+    `created` INT DEFAULT 0,
+    `updated` INT DEFAULT 0,
+    `skipped` INT DEFAULT 0 COMMENT '重复而跳过的题目数',
+    `failed` INT DEFAULT 0,
+    `error` VARCHAR(500) DEFAULT '',
+    `creator_id` BIGINT UNSIGNED,
+    `lease_until` TIMESTAMP NULL,
+    `started_at` TIMESTAMP NULL,
+    `finished_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_import_jobs_status` (`status`),
+    INDEX `idx_import_jobs_creator_id` (`creator_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目导入任务表';
+
+-- 导入任务中的每道题目及处理结果
+CREATE TABLE IF NOT EXISTS `import_job_rows` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `job_id` VARCHAR(36) NOT NULL,
+    `seq` INT NOT NULL COMMENT '处理顺序',
+    `line_no` INT NOT NULL COMMENT '文件中的行号',
+    `item` JSON NOT NULL,
+    `action` VARCHAR(20) NOT NULL DEFAULT '' COMMENT 'created/updated/skipped/failed，未处理时为空',
+    `column_name` VARCHAR(10) DEFAULT '',
+    `header` VARCHAR(100) DEFAULT '',
+    `field` VARCHAR(20) DEFAULT '',
+    `message` VARCHAR(500) DEFAULT '',
+    UNIQUE INDEX `idx_import_job_rows_job_seq` (`job_id`, `seq`),
+    FOREIGN KEY (`job_id`) REFERENCES `import_jobs`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目导入任务明细表';
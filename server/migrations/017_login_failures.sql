@@ -0,0 +1,14 @@
+-- 登录失败计数，按账号和IP统计，用于暴力破解锁定
+-- 早期版本只在 init.sql 中建表，已有数据库需执行本迁移
+CREATE TABLE IF NOT EXISTS `login_failures` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `scope` VARCHAR(20) NOT NULL COMMENT '统计维度 account/ip',
+    `identifier` VARCHAR(100) NOT NULL COMMENT '用户名或IP',
+    `fail_count` INT DEFAULT 0,
+    `locked_until` TIMESTAMP NULL,
+    `last_failed_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY `idx_login_failure_scope_identifier` (`scope`, `identifier`),
+    INDEX `idx_login_failures_last_failed_at` (`last_failed_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录失败计数表';
@@ -0,0 +1,32 @@
+-- 登录会话和刷新令牌，早期版本只在 init.sql 中建表，已有数据库需执行本迁移
+-- 缺少这两张表时登录无法签发令牌；只依赖 users 表，003–017 均不引用这两张表，因此编号靠后也可以最先执行
+
+-- 登录会话表
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `ip` VARCHAR(45),
+    `user_agent` VARCHAR(500),
+    `expires_at` TIMESTAMP NOT NULL,
+    `last_used_at` TIMESTAMP NULL,
+    `revoked_at` TIMESTAMP NULL,
+    `revoke_reason` VARCHAR(50),
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX `idx_user_sessions_user_id` (`user_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录会话表';
+
+-- 刷新令牌表（只保存令牌哈希）
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `session_id` BIGINT UNSIGNED NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `token_hash` VARCHAR(64) UNIQUE NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `used_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_refresh_tokens_session_id` (`session_id`),
+    INDEX `idx_refresh_tokens_user_id` (`user_id`),
+    FOREIGN KEY (`session_id`) REFERENCES `user_sessions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// 答题模式，记录在每次作答上
+const (
+	AnswerModePractice   = "practice"   // 普通练习
+	AnswerModeRandom     = "random"     // 随机练习
+	AnswerModeCategory   = "category"   // 分类练习
+	AnswerModeSequential = "sequential" // 顺序练习
+	AnswerModeMistake    = "mistake"    // 错题重做
+	AnswerModeExam       = "exam"       // 考试
+)
+
+// IsValidAnswerMode 检查客户端提交的答题模式是否有效，考试作答只能由服务端写入
+func IsValidAnswerMode(mode string) bool {
+	switch mode {
+	case AnswerModePractice, AnswerModeRandom, AnswerModeCategory, AnswerModeSequential, AnswerModeMistake:
+		return true
+	}
+	return false
+}
+
+// AnswerState 用户对每道题的最新作答状态，answer_records 保存全部作答
+type AnswerState struct {
+	ID              uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID          uint       `json:"userId" gorm:"not null;uniqueIndex:idx_answer_state_user_question"`
+	QuestionID      uint       `json:"questionId" gorm:"not null;uniqueIndex:idx_answer_state_user_question;index"`
+	AttemptCount    int        `json:"attemptCount" gorm:"default:0"`
+	CorrectCount    int        `json:"correctCount" gorm:"default:0"`
+	FirstCorrect    bool       `json:"firstCorrect" gorm:"default:false;comment:第一次作答是否正确"`
+	FirstAnsweredAt *time.Time `json:"firstAnsweredAt"`
+	LastAnswer      int        `json:"lastAnswer" gorm:"default:0"`
+	LastAnswerText  string     `json:"lastAnswerText" gorm:"type:text"`
+	LastCorrect     bool       `json:"lastCorrect" gorm:"default:false;comment:最近一次作答是否正确"`
+	LastAnsweredAt  *time.Time `json:"lastAnsweredAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	// 关联
+	Question *Question `json:"question,omitempty" gorm:"foreignKey:QuestionID"`
+}
+
+// TableName 指定表名
+func (AnswerState) TableName() string {
+	return "answer_states"
+}
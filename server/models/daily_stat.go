@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// DailyStat 每日统计汇总，注册和提交答案时同步累加，统计看板只读此表
+// StatDate 按 APP_TIMEZONE 配置的时区划分
+type DailyStat struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StatDate       string    `json:"statDate" gorm:"type:date;not null;uniqueIndex"`
+	NewUsers       int64     `json:"newUsers" gorm:"default:0"`
+	ActiveUsers    int64     `json:"activeUsers" gorm:"default:0;comment:当日有作答的用户数"`
+	TotalAnswers   int64     `json:"totalAnswers" gorm:"default:0"`
+	CorrectAnswers int64     `json:"correctAnswers" gorm:"default:0"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// DailyActiveUser 每日有作答的用户，用于去重累加 DailyStat.ActiveUsers
+type DailyActiveUser struct {
+	StatDate  string    `json:"statDate" gorm:"type:date;primaryKey"`
+	UserID    uint      `json:"userId" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (DailyStat) TableName() string {
+	return "daily_stats"
+}
+
+func (DailyActiveUser) TableName() string {
+	return "daily_active_users"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// 考试状态
+const (
+	ExamStatusInProgress = "in_progress"
+	ExamStatusSubmitted  = "submitted"
+)
+
+// ExamSession 考试会话模型，开考时冻结题目顺序和分值，截止时间以服务端为准
+type ExamSession struct {
+	ID            uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        uint       `json:"userId" gorm:"not null;index"`
+	PaperID       uint       `json:"paperId" gorm:"not null;index"`
+	Status        string     `json:"status" gorm:"type:varchar(20);default:'in_progress';index;comment:状态 in_progress/submitted"`
+	StartedAt     time.Time  `json:"startedAt"`
+	Deadline      *time.Time `json:"deadline" gorm:"index;comment:为空表示不限时"`
+	SubmittedAt   *time.Time `json:"submittedAt"`
+	AutoSubmitted bool       `json:"autoSubmitted" gorm:"default:false;comment:是否超时自动交卷"`
+	TimeSpent     int        `json:"timeSpent" gorm:"default:0;comment:用时(秒)"`
+	TotalScore    float64    `json:"totalScore" gorm:"type:decimal(8,2);default:0.00"`
+	PassScore     float64    `json:"passScore" gorm:"type:decimal(8,2);default:0.00"`
+	Score         float64    `json:"score" gorm:"type:decimal(8,2);default:0.00"`
+	Passed        bool       `json:"passed" gorm:"default:false"`
+	QuestionCount int        `json:"questionCount" gorm:"default:0"`
+	CorrectCount  int        `json:"correctCount" gorm:"default:0"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	// 关联
+	Paper   *Paper       `json:"paper,omitempty" gorm:"foreignKey:PaperID"`
+	Answers []ExamAnswer `json:"answers,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// ExamAnswer 考试中的一道题及用户作答，交卷前为草稿
+type ExamAnswer struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID    uint       `json:"sessionId" gorm:"not null;index"`
+	QuestionID   uint       `json:"questionId" gorm:"not null;index"`
+	SectionTitle string     `json:"sectionTitle" gorm:"size:200"`
+	SectionSort  int        `json:"sectionSort" gorm:"default:0"`
+	Sort         int        `json:"sortOrder" gorm:"default:0"`
+	Score        float64    `json:"score" gorm:"type:decimal(6,2);not null;comment:本题分值"`
+	UserAnswer   *int       `json:"userAnswer" gorm:"comment:选项索引或位掩码，为空表示未作答"`
+	AnswerText   string     `json:"answerText" gorm:"type:text;comment:填空题作答(JSON数组)"`
+	IsCorrect    bool       `json:"isCorrect" gorm:"default:false"`
+	EarnedScore  float64    `json:"earnedScore" gorm:"type:decimal(6,2);default:0.00"`
+	AnsweredAt   *time.Time `json:"answeredAt"`
+
+	// 关联
+	Question *Question `json:"question,omitempty" gorm:"foreignKey:QuestionID"`
+}
+
+// TableName 指定表名
+func (ExamSession) TableName() string {
+	return "exam_sessions"
+}
+
+func (ExamAnswer) TableName() string {
+	return "exam_answers"
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// 导入任务状态
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+	ImportJobCancelled = "cancelled"
+)
+
+// 导入任务中每行的处理结果，未处理时为空
+const (
+	ImportRowCreated = "created"
+	ImportRowUpdated = "updated"
+	ImportRowSkipped = "skipped"
+	ImportRowFailed  = "failed"
+)
+
+// ImportJob 后台导入任务，题目按批次导入，每批与任务进度在同一事务中提交
+// LeaseUntil 为当前处理者的租约，过期后其他进程或轮询请求可以接手继续处理
+type ImportJob struct {
+	ID             string     `json:"id" gorm:"primaryKey;size:36"`
+	Source         string     `json:"source" gorm:"size:20;not null;comment:来源 json/file/text"`
+	Filename       string     `json:"filename" gorm:"size:255"`
+	Status         string     `json:"status" gorm:"size:20;not null;index"`
+	DryRun         bool       `json:"dryRun" gorm:"default:false;comment:只校验不写入"`
+	SkipDuplicates bool       `json:"skipDuplicates" gorm:"default:false"`
+	UpdateExisting bool       `json:"updateExisting" gorm:"default:false"`
+	Columns        string     `json:"-" gorm:"column:column_map;type:json;comment:表格导入时字段对应的列"`
+	Total          int        `json:"total" gorm:"default:0"`
+	Processed      int        `json:"processed" gorm:"default:0"`
+	Created        int        `json:"created" gorm:"default:0"`
+	Updated        int        `json:"updated" gorm:"default:0"`
+	Skipped        int        `json:"skipped" gorm:"default:0;comment:重复而跳过的题目数"`
+	Failed         int        `json:"failed" gorm:"default:0"`
+	Error          string     `json:"error" gorm:"size:500"`
+	CreatorID      uint       `json:"creatorId" gorm:"index"`
+	LeaseUntil     *time.Time `json:"-"`
+	StartedAt      *time.Time `json:"startedAt"`
+	FinishedAt     *time.Time `json:"finishedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	Progress float64 `json:"progress" gorm:"-"` // 已处理的百分比
+}
+
+// ImportJobRow 导入任务中的一道题目，Seq 为处理顺序，Line 为文件中的行号
+// 解析文件时已出错的行在创建任务时写入 Message，处理到该行时计为失败
+type ImportJobRow struct {
+	ID      uint64 `json:"-" gorm:"primaryKey;autoIncrement"`
+	JobID   string `json:"-" gorm:"size:36;not null;uniqueIndex:idx_import_job_rows_job_seq"`
+	Seq     int    `json:"-" gorm:"not null;uniqueIndex:idx_import_job_rows_job_seq"`
+	Line    int    `json:"row" gorm:"column:line_no;not null"`
+	Item    string `json:"-" gorm:"type:json;not null"`
+	Action  string `json:"action" gorm:"size:20;not null;default:''"`
+	Column  string `json:"column" gorm:"column:column_name;size:10"`
+	Header  string `json:"header" gorm:"size:100"`
+	Field   string `json:"field" gorm:"size:20"`
+	Message string `json:"message" gorm:"size:500"`
+}
+
+// TableName 指定表名
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+func (ImportJobRow) TableName() string {
+	return "import_job_rows"
+}
+
+// Finished 任务是否已结束
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportJobCompleted || j.Status == ImportJobFailed || j.Status == ImportJobCancelled
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// 登录失败计数的统计维度
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+)
+
+// LoginFailure 登录失败计数模型（按账号或IP统计，用于暴力破解锁定）
+type LoginFailure struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Scope        string     `json:"scope" gorm:"type:varchar(20);not null;uniqueIndex:idx_login_failure_scope_identifier"`
+	Identifier   string     `json:"identifier" gorm:"size:100;not null;uniqueIndex:idx_login_failure_scope_identifier"`
+	FailCount    int        `json:"failCount" gorm:"default:0"`
+	LockedUntil  *time.Time `json:"lockedUntil"`
+	LastFailedAt time.Time  `json:"lastFailedAt" gorm:"index"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
@@ -12,18 +12,23 @@ import (
 type User struct {
 	ID               uint      `json:"id" gorm:"primaryKey;autoIncrement"`
 	OpenID           string    `json:"openid" gorm:"uniqueIndex;size:100;not null"`
+	UnionID          string    `json:"unionid" gorm:"size:100;index"`
+	SessionKey       string    `json:"-" gorm:"size:100"`
 	Username         string    `json:"username" gorm:"size:50"`
 	Email            string    `json:"email" gorm:"size:100"`
 	Password         string    `json:"-" gorm:"size:255"`
 	Nickname         string    `json:"nickname" gorm:"size:100;default:''"`
 	Avatar           string    `json:"avatar" gorm:"size:500;default:''"`
-	Role             string    `json:"role" gorm:"type:enum('user','admin');default:'user'"`
+	Role             string    `json:"role" gorm:"type:varchar(50);default:'user'"`
 	Status           string    `json:"status" gorm:"type:varchar(20);default:'active'"`
+	BanReason        string    `json:"banReason" gorm:"size:255;default:''"`
+	BanExpiresAt     *time.Time `json:"banExpiresAt"`
 	IsVerified       bool      `json:"isVerified" gorm:"default:false"`
 	IsGuest          bool      `json:"isGuest" gorm:"default:false"`
 	TotalAnswered    int       `json:"totalAnswered" gorm:"default:0"`
 	TotalCorrect     int       `json:"totalCorrect" gorm:"default:0"`
 	AccuracyRate     float64   `json:"accuracyRate" gorm:"type:decimal(5,2);default:0.00"`
+	Points           int       `json:"points" gorm:"default:0;comment:积分余额"`
 	LastActiveTime   *time.Time `json:"lastActiveTime"`
 	CreatedAt        time.Time `json:"createdAt"`
 	UpdatedAt        time.Time `json:"updatedAt"`
@@ -77,6 +82,34 @@ func (j JSONArray) Value() (driver.Value, error) {
 	return json.Marshal(j)
 }
 
+// FillAnswers 填空题答案：每个空一组可接受的答案
+type FillAnswers [][]string
+
+// Scan 实现Scanner接口
+func (f *FillAnswers) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return errors.New("cannot scan into FillAnswers")
+	}
+}
+
+// Value 实现Valuer接口
+func (f FillAnswers) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
 // Question 题目模型
 type Question struct {
 	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -85,6 +118,7 @@ type Question struct {
 	Type          string    `json:"type" gorm:"type:varchar(20);default:'single'"`
 	Options       JSONArray `json:"options" gorm:"type:json;not null"`
 	CorrectAnswer int       `json:"correctAnswer" gorm:"not null"`
+	FillAnswers   FillAnswers `json:"fillAnswers" gorm:"type:json;comment:填空题答案"`
 	Explanation   string    `json:"explanation" gorm:"type:text"`
 	Difficulty    string    `json:"difficulty" gorm:"type:varchar(20);default:'medium'"`
 	CategoryID    uint      `json:"categoryId" gorm:"not null;index"`
@@ -92,6 +126,11 @@ type Question struct {
 	TotalAnswered int       `json:"totalAnswered" gorm:"default:0"`
 	TotalCorrect  int       `json:"totalCorrect" gorm:"default:0"`
 	AccuracyRate  float64   `json:"accuracyRate" gorm:"type:decimal(5,2);default:0.00"`
+	// 按作答数据校准的难度，见 controllers/calibration.go
+	EstimatedAccuracy   *float64   `json:"estimatedAccuracy" gorm:"type:decimal(5,4);comment:平滑后的第一次作答正确率"`
+	SuggestedDifficulty string     `json:"suggestedDifficulty" gorm:"type:varchar(20);default:'';index;comment:按作答数据建议的难度，样本不足时为空"`
+	CalibrationSample   int        `json:"calibrationSample" gorm:"default:0;comment:校准时的作答人数"`
+	CalibratedAt        *time.Time `json:"calibratedAt"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
 	
@@ -100,14 +139,18 @@ type Question struct {
 	Creator  *User     `json:"creator,omitempty" gorm:"foreignKey:CreatorID"`
 }
 
-// AnswerRecord 答题记录模型
+// AnswerRecord 答题记录模型，每次作答追加一条，不覆盖历史
 type AnswerRecord struct {
 	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
 	UserID     uint      `json:"userId" gorm:"not null;index"`
 	QuestionID uint      `json:"questionId" gorm:"not null;index"`
 	UserAnswer int       `json:"userAnswer" gorm:"not null"`
+	AnswerText string    `json:"answerText" gorm:"type:text;comment:填空题作答(JSON数组)"`
 	IsCorrect  bool      `json:"isCorrect" gorm:"not null"`
 	TimeSpent  int       `json:"timeSpent" gorm:"default:0"`
+	AttemptNo  int       `json:"attemptNo" gorm:"default:1;comment:该用户对该题的第几次作答"`
+	Mode       string    `json:"mode" gorm:"type:varchar(20);default:'practice';comment:答题模式"`
+	SessionID  *uint     `json:"sessionId" gorm:"index;comment:考试等会话ID"`
 	AnsweredAt time.Time `json:"answeredAt" gorm:"default:CURRENT_TIMESTAMP"`
 	
 	// 关联
@@ -123,6 +166,14 @@ type MistakeBook struct {
 	IsMastered bool      `json:"isMastered" gorm:"default:false"`
 	AddedAt    time.Time `json:"addedAt" gorm:"default:CURRENT_TIMESTAMP"`
 	
+	// 间隔复习（SM-2）
+	EaseFactor     float64    `json:"easeFactor" gorm:"type:decimal(4,2);default:2.50;comment:难易系数"`
+	IntervalDays   int        `json:"intervalDays" gorm:"default:0;comment:复习间隔(天)"`
+	Repetitions    int        `json:"repetitions" gorm:"default:0;comment:连续答对的复习次数"`
+	ReviewCount    int        `json:"reviewCount" gorm:"default:0"`
+	NextReviewAt   *time.Time `json:"nextReviewAt" gorm:"index;comment:下次复习时间"`
+	LastReviewedAt *time.Time `json:"lastReviewedAt"`
+	
 	// 关联
 	User     *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Question *Question `json:"question,omitempty" gorm:"foreignKey:QuestionID"`
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// 试卷组卷方式
+const (
+	PaperModeManual = "manual" // 手动选题
+	PaperModeRule   = "rule"   // 按规则随机抽题
+)
+
+// 试卷状态
+const (
+	PaperStatusDraft     = "draft"
+	PaperStatusPublished = "published"
+)
+
+// Paper 试卷模型
+type Paper struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title       string    `json:"title" gorm:"size:200;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	Mode        string    `json:"mode" gorm:"type:varchar(20);default:'manual';comment:组卷方式 manual/rule"`
+	TotalScore  float64   `json:"totalScore" gorm:"type:decimal(8,2);default:0.00"`
+	PassScore   float64   `json:"passScore" gorm:"type:decimal(8,2);default:0.00"`
+	TimeLimit   int       `json:"timeLimit" gorm:"default:0;comment:考试时长(分钟) 0-不限时"`
+	Status      string    `json:"status" gorm:"type:varchar(20);default:'draft';comment:状态 draft/published"`
+	CreatorID   *uint     `json:"creatorId" gorm:"index"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// 关联
+	Sections []PaperSection `json:"sections,omitempty" gorm:"foreignKey:PaperID"`
+	Creator  *User          `json:"creator,omitempty" gorm:"foreignKey:CreatorID"`
+
+	// 计算字段（不存储在数据库中）
+	QuestionCount int `json:"questionCount" gorm:"-"`
+}
+
+// PaperSection 试卷大题（分节）模型
+type PaperSection struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaperID     uint      `json:"paperId" gorm:"not null;index"`
+	Title       string    `json:"title" gorm:"size:200;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Sort        int       `json:"sortOrder" gorm:"default:0"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// 关联
+	Questions []PaperQuestion `json:"questions,omitempty" gorm:"foreignKey:SectionID"`
+	Rules     []PaperRule     `json:"rules,omitempty" gorm:"foreignKey:SectionID"`
+}
+
+// PaperQuestion 手动组卷时分节内的题目及分值
+type PaperQuestion struct {
+	ID         uint    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaperID    uint    `json:"paperId" gorm:"not null;index"`
+	SectionID  uint    `json:"sectionId" gorm:"not null;index"`
+	QuestionID uint    `json:"questionId" gorm:"not null;index"`
+	Score      float64 `json:"score" gorm:"type:decimal(6,2);not null"`
+	Sort       int     `json:"sortOrder" gorm:"default:0"`
+
+	// 关联
+	Question *Question `json:"question,omitempty" gorm:"foreignKey:QuestionID"`
+}
+
+// PaperRule 规则组卷时的抽题规则，如"分类X中10道简单单选题，每题2分"
+type PaperRule struct {
+	ID         uint    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaperID    uint    `json:"paperId" gorm:"not null;index"`
+	SectionID  uint    `json:"sectionId" gorm:"not null;index"`
+	CategoryID *uint   `json:"categoryId" gorm:"index;comment:为空表示不限分类"`
+	Type       string  `json:"type" gorm:"type:varchar(20);default:'';comment:为空表示不限题型"`
+	Difficulty string  `json:"difficulty" gorm:"type:varchar(20);default:'';comment:为空表示不限难度"`
+	Count      int     `json:"count" gorm:"not null"`
+	Score      float64 `json:"score" gorm:"type:decimal(6,2);not null;comment:每题分值"`
+	Sort       int     `json:"sortOrder" gorm:"default:0"`
+
+	// 关联
+	Category *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+// TableName 指定表名
+func (Paper) TableName() string {
+	return "papers"
+}
+
+func (PaperSection) TableName() string {
+	return "paper_sections"
+}
+
+func (PaperQuestion) TableName() string {
+	return "paper_questions"
+}
+
+func (PaperRule) TableName() string {
+	return "paper_rules"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// 积分变动原因
+const (
+	PointReasonAnswerCorrect = "answer_correct" // 答对题目
+	PointReasonAnswerWrong   = "answer_wrong"   // 答错题目
+)
+
+// 积分变动关联的对象类型
+const (
+	PointRefQuestion = "question"
+)
+
+// 排行榜周期
+const (
+	LeaderboardDaily  = "daily"
+	LeaderboardWeekly = "weekly"
+	LeaderboardAll    = "all"
+)
+
+// PointRecord 积分流水，每次积分变动追加一条
+type PointRecord struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       uint      `json:"userId" gorm:"not null;index"`
+	Points       int       `json:"points" gorm:"not null;comment:变动积分，扣分为负数"`
+	BalanceAfter int       `json:"balanceAfter" gorm:"not null;default:0;comment:变动后的积分余额"`
+	Reason       string    `json:"reason" gorm:"type:varchar(30);not null"`
+	RefType      string    `json:"refType" gorm:"type:varchar(30);comment:关联对象类型"`
+	RefID        *uint     `json:"refId" gorm:"comment:关联对象ID"`
+	CategoryID   *uint     `json:"categoryId" gorm:"index;comment:积分所属分类，用于分类排行"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
+}
+
+// PointLeaderboard 积分排行汇总，按周期和分类累计每个用户的积分，提交答案时同步更新
+// CategoryID 为 0 表示全站排行，PeriodKey 为日期、ISO 周（如 2024-W05）或 all
+type PointLeaderboard struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Period     string    `json:"period" gorm:"type:varchar(10);not null;uniqueIndex:idx_point_leaderboard_entry;index:idx_point_leaderboard_rank,priority:1"`
+	PeriodKey  string    `json:"periodKey" gorm:"type:varchar(20);not null;uniqueIndex:idx_point_leaderboard_entry;index:idx_point_leaderboard_rank,priority:2"`
+	CategoryID uint      `json:"categoryId" gorm:"not null;default:0;uniqueIndex:idx_point_leaderboard_entry;index:idx_point_leaderboard_rank,priority:3"`
+	UserID     uint      `json:"userId" gorm:"not null;uniqueIndex:idx_point_leaderboard_entry"`
+	Points     int       `json:"points" gorm:"not null;default:0;index:idx_point_leaderboard_rank,priority:4"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (PointRecord) TableName() string {
+	return "point_records"
+}
+
+func (PointLeaderboard) TableName() string {
+	return "point_leaderboards"
+}
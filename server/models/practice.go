@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// PracticeCursor 顺序练习进度，记录用户在每个分类中按顺序练习到的位置
+type PracticeCursor struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID         uint      `json:"userId" gorm:"not null;uniqueIndex:idx_practice_cursor_user_category"`
+	CategoryID     uint      `json:"categoryId" gorm:"not null;uniqueIndex:idx_practice_cursor_user_category"`
+	LastQuestionID uint      `json:"lastQuestionId" gorm:"default:0;comment:最后作答的题目ID，题目按ID顺序练习"`
+	Round          int       `json:"round" gorm:"default:1;comment:第几轮练习"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (PracticeCursor) TableName() string {
+	return "practice_cursors"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// 内置角色
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleUser   = "user"
+	RoleGuest  = "guest"
+)
+
+// 权限编码
+const (
+	PermQuestionRead   = "question:read"
+	PermQuestionWrite  = "question:write"
+	PermCategoryWrite  = "category:write"
+	PermPaperWrite     = "paper:write"
+	PermUserRead       = "user:read"
+	PermUserManage     = "user:manage"
+	PermStatisticsRead = "statistics:read"
+	PermLogsRead       = "logs:read"
+	PermSettingsRead   = "settings:read"
+	PermSettingsWrite  = "settings:write"
+	PermRoleManage     = "role:manage"
+)
+
+// Role 角色模型
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string       `json:"name" gorm:"uniqueIndex;size:50;not null"`
+	DisplayName string       `json:"displayName" gorm:"size:100"`
+	Description string       `json:"description" gorm:"size:255"`
+	IsSystem    bool         `json:"isSystem" gorm:"default:false;comment:内置角色不可删除"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+
+	// 计算字段（不存储在数据库中）
+	UserCount int64 `json:"userCount" gorm:"-"`
+}
+
+// Permission 权限模型
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code        string    `json:"code" gorm:"uniqueIndex;size:50;not null"`
+	Name        string    `json:"name" gorm:"size:100"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession 登录会话模型，每次登录创建一个会话，刷新令牌轮换时会话保持不变
+type UserSession struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       uint       `json:"userId" gorm:"not null;index"`
+	IP           string     `json:"ip" gorm:"size:45"`
+	UserAgent    string     `json:"userAgent" gorm:"size:500"`
+	ExpiresAt    time.Time  `json:"expiresAt" gorm:"not null"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	RevokeReason string     `json:"revokeReason" gorm:"size:50"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// RefreshToken 刷新令牌模型，只保存令牌的SHA-256哈希
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	SessionID uint       `json:"sessionId" gorm:"not null;index"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 用户状态
+const (
+	UserStatusActive   = "active"   // 正常
+	UserStatusDisabled = "disabled" // 已停用
+	UserStatusBanned   = "banned"   // 已封禁，可设置解封时间
+	UserStatusPending  = "pending"  // 待审核
+)
+
+// 账号状态相关的业务错误码
+const (
+	CodeUserDisabled = 40301
+	CodeUserBanned   = 40302
+	CodeUserPending  = 40303
+)
+
+// IsValidUserStatus 检查状态值是否合法
+func IsValidUserStatus(status string) bool {
+	switch status {
+	case UserStatusActive, UserStatusDisabled, UserStatusBanned, UserStatusPending:
+		return true
+	}
+	return false
+}
+
+// EffectiveStatus 返回用户当前实际生效的状态，封禁到期后视为正常
+func (u *User) EffectiveStatus() string {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	if u.Status == UserStatusBanned && u.BanExpiresAt != nil && u.BanExpiresAt.Before(time.Now()) {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+// AccessDenial 判断用户是否被禁止访问，返回业务错误码和提示信息
+func (u *User) AccessDenial() (int, string, bool) {
+	switch u.EffectiveStatus() {
+	case UserStatusDisabled:
+		return CodeUserDisabled, "账号已被停用，请联系管理员", true
+	case UserStatusBanned:
+		message := "账号已被封禁"
+		if u.BanReason != "" {
+			message += "，原因：" + u.BanReason
+		}
+		if u.BanExpiresAt != nil {
+			message += fmt.Sprintf("，解封时间：%s", u.BanExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		return CodeUserBanned, message, true
+	case UserStatusPending:
+		return CodeUserPending, "账号正在审核中，请稍后再试", true
+	}
+	return 0, "", false
+}
@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"os"
 	"qaminiprogram/controllers"
 	"qaminiprogram/middleware"
+	"qaminiprogram/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,10 +32,17 @@ func SetupRoutes(r *gin.Engine) {
 			public.GET("/categories/:id", controllers.GetCategoryByID)
 			
 			// 题目相关（公开读取）
-			public.GET("/questions", controllers.GetQuestions)
-			public.GET("/questions/:id", controllers.GetQuestionByID)
-			public.GET("/questions/random", controllers.GetRandomQuestions)
-			public.GET("/questions/category/:categoryId", controllers.GetQuestionsByCategory)
+			public.GET("/questions", middleware.OptionalJWTAuth(), controllers.GetQuestions)
+			public.GET("/questions/:id", middleware.OptionalJWTAuth(), controllers.GetQuestionByID)
+			public.GET("/questions/random", middleware.OptionalJWTAuth(), controllers.GetRandomQuestions)
+			public.GET("/questions/category/:categoryId", middleware.OptionalJWTAuth(), controllers.GetQuestionsByCategory)
+			
+			// 试卷相关（公开读取已发布试卷）
+			public.GET("/papers", controllers.GetPapers)
+			public.GET("/papers/:id", controllers.GetPaperByID)
+			
+			// 积分排行榜（登录后附带自己的名次）
+			public.GET("/leaderboard", middleware.OptionalJWTAuth(), controllers.GetLeaderboard)
 			
 			// 公开统计数据
 			public.GET("/statistics/overview", controllers.GetOverviewStatistics)
@@ -48,6 +57,14 @@ func SetupRoutes(r *gin.Engine) {
 			// 用户相关
 			auth.GET("/user/profile", controllers.GetUserProfile)
 			auth.PUT("/user/profile", controllers.UpdateUserProfile)
+			auth.GET("/user/points", controllers.GetUserPoints)
+			
+			// 退出登录
+			auth.POST("/auth/logout", controllers.Logout)
+			auth.POST("/auth/logout-all", controllers.LogoutAll)
+			
+			// 游客升级为正式账号
+			auth.POST("/auth/upgrade", controllers.UpgradeGuest)
 			
 			// 答题记录
 			auth.POST("/answers", controllers.SubmitAnswer)
@@ -57,8 +74,24 @@ func SetupRoutes(r *gin.Engine) {
 			// 分类进度
 			auth.GET("/categories/:id/progress", controllers.GetCategoryProgress)
 			
+			// 推荐练习
+			auth.GET("/questions/recommended", controllers.GetRecommendedQuestions)
+			
+			// 顺序练习
+			auth.GET("/categories/:id/practice", controllers.GetPracticeQuestions)
+			auth.DELETE("/categories/:id/practice", controllers.ResetPracticeCursor)
+			
+			// 考试
+			auth.POST("/papers/:id/exams", controllers.StartExam)
+			auth.GET("/exams", controllers.GetMyExams)
+			auth.GET("/exams/:id", controllers.GetExamSession)
+			auth.PUT("/exams/:id/answers", controllers.SaveExamAnswers)
+			auth.POST("/exams/:id/submit", controllers.SubmitExam)
+			auth.GET("/exams/:id/result", controllers.GetExamResult)
+			
 			// 错题本
 			auth.GET("/mistakes", controllers.GetMistakeBooks)
+			auth.GET("/mistakes/due", controllers.GetDueMistakes)
 			auth.POST("/mistakes", controllers.AddToMistakeBook)
 			auth.DELETE("/mistakes/:id", controllers.RemoveFromMistakeBook)
 			auth.DELETE("/mistakes/clear", controllers.ClearMistakeBook)
@@ -74,60 +107,96 @@ func SetupRoutes(r *gin.Engine) {
 		admin.POST("/login", controllers.PasswordLogin)
 		}
 
-		// 需要管理员认证的路由
+		// 需要管理员认证的路由，各接口再按所需权限校验
 		adminAuth := api.Group("/admin")
 		adminAuth.Use(middleware.JWTAuth())
 		adminAuth.Use(middleware.AdminAuth())
 		{
-			
+			perm := middleware.RequirePermission
 			
 			// 用户管理
-			adminAuth.GET("/users", controllers.GetUsers)
-			adminAuth.GET("/users/:id", controllers.GetUserByID)
-			adminAuth.POST("/users", controllers.CreateUser)
-			adminAuth.PUT("/users/:id", controllers.UpdateUser)
-			adminAuth.PUT("/users/:id/status", controllers.UpdateUserStatus)
-			adminAuth.DELETE("/users/:id", controllers.DeleteUser)
-			adminAuth.DELETE("/users/batch", controllers.BatchDeleteUsers)
+			adminAuth.GET("/users", perm(models.PermUserRead), controllers.GetUsers)
+			adminAuth.GET("/users/:id", perm(models.PermUserRead), controllers.GetUserByID)
+			adminAuth.POST("/users", perm(models.PermUserManage), controllers.CreateUser)
+			adminAuth.PUT("/users/:id", perm(models.PermUserManage), controllers.UpdateUser)
+			adminAuth.PUT("/users/:id/status", perm(models.PermUserManage), controllers.UpdateUserStatus)
+			adminAuth.DELETE("/users/:id", perm(models.PermUserManage), controllers.DeleteUser)
+			adminAuth.DELETE("/users/batch", perm(models.PermUserManage), controllers.BatchDeleteUsers)
+			
+			// 角色权限管理
+			adminAuth.GET("/permissions", perm(models.PermRoleManage), controllers.GetPermissions)
+			adminAuth.GET("/roles", perm(models.PermRoleManage), controllers.GetRoles)
+			adminAuth.POST("/roles", perm(models.PermRoleManage), controllers.CreateRole)
+			adminAuth.PUT("/roles/:id", perm(models.PermRoleManage), controllers.UpdateRole)
+			adminAuth.DELETE("/roles/:id", perm(models.PermRoleManage), controllers.DeleteRole)
 			
 			// 分类管理
-            adminAuth.GET("/categories", controllers.GetAdminCategories)
-            adminAuth.POST("/categories", controllers.CreateCategory)
-            adminAuth.PUT("/categories/:id", controllers.UpdateCategory)
-            adminAuth.PUT("/categories/:id/status", controllers.UpdateCategoryStatus)
-            adminAuth.DELETE("/categories/:id", controllers.DeleteCategory)
+			adminAuth.GET("/categories", perm(models.PermQuestionRead), controllers.GetAdminCategories)
+			adminAuth.POST("/categories", perm(models.PermCategoryWrite), controllers.CreateCategory)
+			adminAuth.PUT("/categories/:id", perm(models.PermCategoryWrite), controllers.UpdateCategory)
+			adminAuth.PUT("/categories/:id/status", perm(models.PermCategoryWrite), controllers.UpdateCategoryStatus)
+			adminAuth.DELETE("/categories/:id", perm(models.PermCategoryWrite), controllers.DeleteCategory)
 			
 			// 题目管理
-			adminAuth.GET("/questions", controllers.GetAdminQuestions)
-			adminAuth.GET("/questions/:id", controllers.GetQuestionByID)
-			adminAuth.POST("/questions", controllers.CreateQuestion)
-			adminAuth.PUT("/questions/:id", controllers.UpdateQuestion)
-			adminAuth.DELETE("/questions/:id", controllers.DeleteQuestion)
-			adminAuth.DELETE("/questions/batch", controllers.BatchDeleteQuestions)
-			adminAuth.POST("/questions/import", controllers.ImportQuestions)
-			adminAuth.GET("/questions/export", controllers.ExportQuestions)
+			adminAuth.GET("/questions", perm(models.PermQuestionRead), controllers.GetAdminQuestions)
+			adminAuth.GET("/questions/:id", perm(models.PermQuestionRead), controllers.GetAdminQuestionByID)
+			adminAuth.POST("/questions", perm(models.PermQuestionWrite), controllers.CreateQuestion)
+			adminAuth.PUT("/questions/:id", perm(models.PermQuestionWrite), controllers.UpdateQuestion)
+			adminAuth.DELETE("/questions/:id", perm(models.PermQuestionWrite), controllers.DeleteQuestion)
+			adminAuth.DELETE("/questions/batch", perm(models.PermQuestionWrite), controllers.BatchDeleteQuestions)
+			adminAuth.POST("/questions/import", perm(models.PermQuestionWrite), controllers.ImportQuestions)
+			adminAuth.POST("/questions/import/file", perm(models.PermQuestionWrite), controllers.ImportQuestionsFromFile)
+			adminAuth.POST("/questions/import/text", perm(models.PermQuestionWrite), controllers.ImportQuestionsFromText)
+			adminAuth.POST("/questions/import/jobs", perm(models.PermQuestionWrite), controllers.CreateImportJob)
+			adminAuth.GET("/questions/import/jobs", perm(models.PermQuestionRead), controllers.GetImportJobs)
+			adminAuth.GET("/questions/import/jobs/:id", perm(models.PermQuestionRead), controllers.GetImportJob)
+			adminAuth.GET("/questions/import/jobs/:id/events", perm(models.PermQuestionRead), controllers.StreamImportJob)
+			adminAuth.POST("/questions/import/jobs/:id/cancel", perm(models.PermQuestionWrite), controllers.CancelImportJob)
+			adminAuth.GET("/questions/import/jobs/:id/errors", perm(models.PermQuestionRead), controllers.GetImportJobErrors)
+			adminAuth.GET("/questions/export", perm(models.PermQuestionRead), controllers.ExportQuestions)
+			adminAuth.GET("/questions/calibration", perm(models.PermQuestionRead), controllers.GetDifficultyCalibration)
+			adminAuth.POST("/questions/calibration", perm(models.PermQuestionWrite), controllers.CalibrateDifficulty)
+			adminAuth.POST("/questions/calibration/apply", perm(models.PermQuestionWrite), controllers.ApplyDifficultyCalibration)
+			
+			// 试卷管理
+			adminAuth.GET("/papers", perm(models.PermQuestionRead), controllers.GetAdminPapers)
+			adminAuth.GET("/papers/:id", perm(models.PermQuestionRead), controllers.GetAdminPaperByID)
+			adminAuth.GET("/papers/:id/preview", perm(models.PermQuestionRead), controllers.PreviewPaper)
+			adminAuth.POST("/papers", perm(models.PermPaperWrite), controllers.CreatePaper)
+			adminAuth.PUT("/papers/:id", perm(models.PermPaperWrite), controllers.UpdatePaper)
+			adminAuth.DELETE("/papers/:id", perm(models.PermPaperWrite), controllers.DeletePaper)
 			
 			// 数据统计
-			adminAuth.GET("/statistics/overview", controllers.GetOverviewStatistics)
-			adminAuth.GET("/statistics/questions", controllers.GetQuestionStatistics)
-			adminAuth.GET("/statistics/users", controllers.GetUserStatistics)
+			adminAuth.GET("/statistics/overview", perm(models.PermStatisticsRead), controllers.GetOverviewStatistics)
+			adminAuth.GET("/statistics/questions", perm(models.PermStatisticsRead), controllers.GetQuestionStatistics)
+			adminAuth.GET("/statistics/questions/:id/analysis", perm(models.PermStatisticsRead), controllers.GetQuestionItemAnalysis)
+			adminAuth.GET("/statistics/item-analysis", perm(models.PermStatisticsRead), controllers.GetItemAnalysisReport)
+			adminAuth.GET("/statistics/users", perm(models.PermStatisticsRead), controllers.GetUserStatistics)
+			adminAuth.POST("/statistics/recompute", perm(models.PermSettingsWrite), controllers.RecomputeCounters)
 			
 			// 操作日志
-			adminAuth.GET("/operation-logs", controllers.GetOperationLogs)
-			adminAuth.GET("/logs", controllers.GetOperationLogs)
+			adminAuth.GET("/operation-logs", perm(models.PermLogsRead), controllers.GetOperationLogs)
+			adminAuth.GET("/logs", perm(models.PermLogsRead), controllers.GetOperationLogs)
 			
 			// 系统设置
-			adminAuth.GET("/settings/basic", controllers.GetBasicSettings)
-			adminAuth.PUT("/settings/basic", controllers.UpdateBasicSettings)
-			adminAuth.GET("/settings/quiz", controllers.GetQuizSettings)
-			adminAuth.PUT("/settings/quiz", controllers.UpdateQuizSettings)
+			adminAuth.GET("/settings/basic", perm(models.PermSettingsRead), controllers.GetBasicSettings)
+			adminAuth.PUT("/settings/basic", perm(models.PermSettingsWrite), controllers.UpdateBasicSettings)
+			adminAuth.GET("/settings/quiz", perm(models.PermSettingsRead), controllers.GetQuizSettings)
+			adminAuth.PUT("/settings/quiz", perm(models.PermSettingsWrite), controllers.UpdateQuizSettings)
 			
 			// 系统统计
-			adminAuth.GET("/statistics", controllers.GetSystemStatistics)
-			adminAuth.GET("/statistics/export", controllers.ExportStatistics)
+			adminAuth.GET("/statistics", perm(models.PermStatisticsRead), controllers.GetSystemStatistics)
+			adminAuth.POST("/statistics/daily/rebuild", perm(models.PermSettingsWrite), controllers.RebuildDailyStats)
+			adminAuth.GET("/statistics/export", perm(models.PermStatisticsRead), controllers.ExportStatistics)
 		}
 	}
 
+	// 本地模拟的微信 code2session 接口，仅在 WX_MOCK_SERVER=true 时启用
+	// 将 WX_API_BASE_URL 设置为 http://<host>:<port>/mock/wechat 即可在无微信凭证的环境下走通微信登录
+	if os.Getenv("WX_MOCK_SERVER") == "true" {
+		r.GET("/mock/wechat/sns/jscode2session", controllers.MockWechatCode2Session)
+	}
+
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
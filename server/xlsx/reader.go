@@ -0,0 +1,284 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSheet 文件中没有工作表
+var ErrNoSheet = errors.New("文件中没有工作表")
+
+// ReadFirstSheet 读取第一个工作表的全部单元格文本
+// 返回的行号与表格中的行号一致（第 i 行对应 rows[i-1]），空行为空切片；maxRows 大于0时超过该行数返回 ErrTooManyRows
+func ReadFirstSheet(r io.ReaderAt, size int64, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 XLSX 文件: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrNoSheet
+	}
+
+	var sharedStrings []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if sharedStrings, err = readSharedStrings(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return readSheet(sheet, sharedStrings, maxRows)
+}
+
+// firstSheetPath 按工作簿中的顺序找到第一个工作表的文件路径
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	workbook, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("不是有效的 XLSX 文件: 缺少 workbook.xml")
+	}
+	var book struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXML(workbook, &book); err != nil {
+		return "", err
+	}
+	if len(book.Sheets) == 0 {
+		return "", ErrNoSheet
+	}
+
+	// 没有关系文件时按默认命名查找
+	rels, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	var relationships struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXML(rels, &relationships); err != nil {
+		return "", err
+	}
+	for _, item := range relationships.Items {
+		if item.ID != book.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(item.Target, "/") {
+			return strings.TrimPrefix(item.Target, "/"), nil
+		}
+		return path.Join("xl", item.Target), nil
+	}
+	return "", ErrNoSheet
+}
+
+// readSharedStrings 读取共享字符串表，富文本按顺序拼接，忽略注音
+func readSharedStrings(file *zip.File) ([]string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var (
+		result  []string
+		current strings.Builder
+		inText  bool
+		depth   int // 进入 rPh（注音）的层数
+	)
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "rPh":
+				depth++
+			case "t":
+				inText = depth == 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				result = append(result, current.String())
+			case "rPh":
+				depth--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+// readSheet 逐个读取工作表中的单元格
+func readSheet(file *zip.File, sharedStrings []string, maxRows int) ([][]string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var (
+		rows     [][]string
+		row      []string
+		rowIndex int
+		col      int
+		cellType string
+		value    strings.Builder
+		inValue  bool
+	)
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				rowIndex = len(rows) + 1
+				if r := attr(t, "r"); r != "" {
+					if n, err := strconv.Atoi(r); err == nil && n > len(rows) {
+						rowIndex = n
+					}
+				}
+				if maxRows > 0 && rowIndex > maxRows {
+					return nil, ErrTooManyRows
+				}
+				// 补齐中间的空行，保持行号与表格一致
+				for len(rows) < rowIndex-1 {
+					rows = append(rows, nil)
+				}
+				row = nil
+			case "c":
+				col = len(row)
+				if ref := attr(t, "r"); ref != "" {
+					if c, ok := columnIndex(ref); ok {
+						col = c
+					}
+				}
+				cellType = attr(t, "t")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				for len(row) < col {
+					row = append(row, "")
+				}
+				row = append(row, cellText(cellType, value.String(), sharedStrings))
+			case "row":
+				rows = append(rows, row)
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// cellText 按单元格类型转换为文本
+func cellText(cellType, value string, sharedStrings []string) string {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return ""
+		}
+		return sharedStrings[index]
+	case "b":
+		if strings.TrimSpace(value) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "e":
+		return ""
+	}
+	return value
+}
+
+// columnIndex 从单元格名称（如 AB12）解析从0开始的列号
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+// ColumnIndex 由列名（如 A、AB）得到从0开始的列号
+func ColumnIndex(name string) (int, bool) {
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return 0, false
+		}
+	}
+	return columnIndex(name)
+}
+
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func decodeXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
@@ -0,0 +1,316 @@
+// Package xlsx 提供不依赖第三方库的 XLSX 读写，写入时按行流式输出，不在内存中保存整张表
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRows 单个工作表的最大行数
+const MaxRows = 1048576
+
+// ErrTooManyRows 写入的行数超过单个工作表的上限
+var ErrTooManyRows = errors.New("工作表行数超过上限")
+
+// Writer 流式 XLSX 写入器
+// 工作表按创建顺序依次写入，创建新工作表时上一个工作表即结束，写完后必须调用 Close
+type Writer struct {
+	zip    *zip.Writer
+	sheets []string
+	sheet  *Sheet
+	closed bool
+}
+
+// Sheet 正在写入的工作表
+type Sheet struct {
+	buf  *bufio.Writer
+	rows int
+}
+
+// NewWriter 创建写入 w 的 XLSX 写入器
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w)}
+}
+
+// NewSheet 结束当前工作表并开始一个新工作表，名称中的非法字符会被替换，超过31个字符会被截断
+func (w *Writer) NewSheet(name string) (*Sheet, error) {
+	if w.closed {
+		return nil, errors.New("写入器已关闭")
+	}
+	return w.newSheet(name)
+}
+
+// newSheet 开始一个新工作表
+func (w *Writer) newSheet(name string) (*Sheet, error) {
+	if err := w.finishSheet(); err != nil {
+		return nil, err
+	}
+
+	w.sheets = append(w.sheets, sheetName(name, len(w.sheets)+1))
+	file, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return nil, err
+	}
+	w.sheet = &Sheet{buf: bufio.NewWriter(file)}
+	_, err = w.sheet.buf.WriteString(xml.Header +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return w.sheet, err
+}
+
+// WriteRow 写入一行，支持字符串、整数、浮点数、布尔值、时间和 nil，其他类型按 fmt 格式化为字符串
+func (s *Sheet) WriteRow(values ...interface{}) error {
+	if s.rows >= MaxRows {
+		return ErrTooManyRows
+	}
+	s.rows++
+
+	fmt.Fprintf(s.buf, `<row r="%d">`, s.rows)
+	for i, value := range values {
+		if err := writeCell(s.buf, CellName(i, s.rows-1), value); err != nil {
+			return err
+		}
+	}
+	_, err := s.buf.WriteString(`</row>`)
+	return err
+}
+
+// Rows 已写入的行数
+func (s *Sheet) Rows() int {
+	return s.rows
+}
+
+// Close 结束最后一个工作表并写入工作簿结构，不会关闭底层的 io.Writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if len(w.sheets) == 0 {
+		if _, err := w.newSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, f := range files {
+		file, err := w.zip.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, f.content); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+// finishSheet 写入当前工作表的结尾
+func (w *Writer) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	sheet := w.sheet
+	w.sheet = nil
+	if _, err := sheet.buf.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return sheet.buf.Flush()
+}
+
+// writeCell 写入一个单元格，字符串使用内联字符串，避免缓存共享字符串表
+func writeCell(buf *bufio.Writer, ref string, value interface{}) error {
+	var number string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return writeString(buf, ref, v)
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return writeString(buf, ref, *v)
+	case bool:
+		flag := "0"
+		if v {
+			flag = "1"
+		}
+		_, err := fmt.Fprintf(buf, `<c r="%s" t="b"><v>%s</v></c>`, ref, flag)
+		return err
+	case int:
+		number = strconv.FormatInt(int64(v), 10)
+	case int32:
+		number = strconv.FormatInt(int64(v), 10)
+	case int64:
+		number = strconv.FormatInt(v, 10)
+	case uint:
+		number = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		number = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		number = strconv.FormatUint(v, 10)
+	case float32:
+		number = formatFloat(float64(v))
+	case float64:
+		number = formatFloat(v)
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		number = formatFloat(*v)
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return writeString(buf, ref, v.Format("2006-01-02 15:04:05"))
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return nil
+		}
+		return writeString(buf, ref, v.Format("2006-01-02 15:04:05"))
+	default:
+		return writeString(buf, ref, fmt.Sprint(v))
+	}
+	if number == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(buf, `<c r="%s"><v>%s</v></c>`, ref, number)
+	return err
+}
+
+// formatFloat 格式化数值，NaN 和无穷大写为空单元格
+func formatFloat(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeString 写入内联字符串单元格
+func writeString(buf *bufio.Writer, ref, value string) error {
+	if _, err := fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(buf, []byte(stripInvalidXML(value))); err != nil {
+		return err
+	}
+	_, err := buf.WriteString(`</t></is></c>`)
+	return err
+}
+
+// stripInvalidXML 去掉 XML 1.0 不允许的控制字符
+func stripInvalidXML(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r != 0xFFFE && r != 0xFFFF {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// CellName 由从0开始的列号和行号生成单元格名称，如 (0, 0) 为 A1
+func CellName(col, row int) string {
+	return ColumnName(col) + strconv.Itoa(row+1)
+}
+
+// ColumnName 由从0开始的列号生成列名，如 0 为 A、26 为 AA
+func ColumnName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
+// sheetName 处理工作表名称中的非法字符和长度
+func sheetName(name string, index int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", index)
+	}
+	return name
+}
+
+func (w *Writer) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range w.sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Writer) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const rootRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const styles = xml.Header +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+	`</styleSheet>`
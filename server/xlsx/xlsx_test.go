@@ -0,0 +1,156 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	first, err := w.NewSheet("题目[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	var nilTime *time.Time
+	rows := [][]interface{}{
+		{"题目ID", "题目内容", "选项A", "正确答案"},
+		{1, "含 <特殊> & \"字符\"", "  前后空格  ", "A"},
+		{uint(2), "多行\n内容\t制表符", nil, "B,C"},
+		{int64(-3), 1.5, true, created},
+		{"控制字符\x01被去掉", math.NaN(), nilTime, false},
+	}
+	for _, row := range rows {
+		if err := first.WriteRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.Rows() != len(rows) {
+		t.Errorf("Rows() = %d, want %d", first.Rows(), len(rows))
+	}
+	// 第二个工作表不影响读取第一个工作表
+	second, err := w.NewSheet("第二页")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.WriteRow("其他"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.NewSheet("关闭后"); err == nil {
+		t.Errorf("NewSheet() after Close: want error")
+	}
+
+	got, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 0)
+	if err != nil {
+		t.Fatalf("ReadFirstSheet() error = %v", err)
+	}
+	want := [][]string{
+		{"题目ID", "题目内容", "选项A", "正确答案"},
+		{"1", "含 <特殊> & \"字符\"", "  前后空格  ", "A"},
+		{"2", "多行\n内容\t制表符", "", "B,C"},
+		{"-3", "1.5", "TRUE", "2024-05-06 07:08:09"},
+		{"控制字符被去掉", "", "", "FALSE"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFirstSheet() =\n%q\nwant\n%q", got, want)
+	}
+
+	if _, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 3); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("ReadFirstSheet() with maxRows = %v, want ErrTooManyRows", err)
+	}
+}
+
+// TestReadSharedStrings 读取 Excel 保存的文件：共享字符串、富文本、跳过的单元格和空行
+func TestReadSharedStrings(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="数据" sheetId="1" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>题目</t></si><si><r><t>富</t></r><r><t xml:space="preserve">文本 </t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="3"><c r="B3"><v>42</v></c><c r="C3" t="e"><v>#N/A</v></c><c r="D3" t="s"><v>9</v></c></row>
+</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 0)
+	if err != nil {
+		t.Fatalf("ReadFirstSheet() error = %v", err)
+	}
+	want := [][]string{
+		{"题目", "", "富文本 "},
+		nil,
+		{"", "42", "", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFirstSheet() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		col  int
+		name string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := ColumnName(tt.col); got != tt.name {
+			t.Errorf("ColumnName(%d) = %q, want %q", tt.col, got, tt.name)
+		}
+		if got, ok := ColumnIndex(tt.name); !ok || got != tt.col {
+			t.Errorf("ColumnIndex(%q) = %d, %v, want %d", tt.name, got, ok, tt.col)
+		}
+	}
+	if got := CellName(27, 9); got != "AB10" {
+		t.Errorf("CellName(27, 9) = %q, want AB10", got)
+	}
+	for _, name := range []string{"", "A1", "列"} {
+		if _, ok := ColumnIndex(name); ok {
+			t.Errorf("ColumnIndex(%q) should be invalid", name)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{"题目", 1, "题目"},
+		{"a/b:c*d?e[f]g\\h", 1, "a_b_c_d_e_f_g_h"},
+		{"   ", 3, "Sheet3"},
+		{"一二三四五六七八九十一二三四五六七八九十一二三四五六七八九十一二", 1, "一二三四五六七八九十一二三四五六七八九十一二三四五六七八九十一"},
+	}
+	for _, tt := range tests {
+		if got := sheetName(tt.name, tt.index); got != tt.want {
+			t.Errorf("sheetName(%q, %d) = %q, want %q", tt.name, tt.index, got, tt.want)
+		}
+	}
+}